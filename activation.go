@@ -0,0 +1,188 @@
+package tinyecs
+
+import (
+	"math"
+)
+
+// Activity is how often an entity is simulated, as decided by an Activation.
+type Activity int
+
+const (
+	// Active entities are within the radius of an interest point, and are updated every frame.
+	Active Activity = iota
+	// Dormant entities are outside the radius of every interest point but within the far radius of one,
+	// and are updated every DormantInterval frames.
+	Dormant
+	// Inactive entities are outside the far radius of every interest point, and are not updated at all.
+	Inactive
+)
+
+// String returns the name of the activity.
+func (a Activity) String() string {
+	switch a {
+	case Active:
+		return "active"
+	case Dormant:
+		return "dormant"
+	case Inactive:
+		return "inactive"
+	}
+	return "unknown"
+}
+
+// InterestPoint marks an entity, such as a player or a camera, around which entities are kept active.
+type InterestPoint struct{}
+
+// DormantEntity is the marker component an Activation links to dormant entities.
+type DormantEntity struct{}
+
+// InactiveEntity is the marker component an Activation links to inactive entities.
+type InactiveEntity struct{}
+
+// ActivityChanged is emitted by an Activation when an entity changes activity.
+type ActivityChanged struct {
+	Entity   any
+	Old, New Activity
+}
+
+// Activation switches entities between activities based on their distance to the nearest interest point.
+// Rather than keeping its own list, it links DormantEntity and InactiveEntity marker components to the entities
+// which are not active, so systems skip them by excluding the markers in their queries, as returned by Without.
+//
+//	act := tinyecs.NewActivation(e, 50, func(entity any) (x, y float64, ok bool) {
+//		pos, _, ok := tinyecs.Get[Position](e, entity)
+//		return pos.X, pos.Y, ok
+//	})
+//	act.FarRadius = 200
+//	e.AddSystem(tinyecs.StagePreUpdate, "activation", act.System())
+//	e.AddSystem(tinyecs.StageUpdate, "ai", func(e *tinyecs.Engine) {
+//		e.Query(tinyecs.MaskOf[Brain](e), act.Without()).Each(func(entity any) { ... })
+//	})
+//
+// Entities without a position, and interest points themselves, are always active.
+// The markers are transient, so activities are not saved but recomputed after loading.
+type Activation struct {
+	// Radius is the distance from an interest point within which entities are active.
+	Radius float64
+	// FarRadius is the distance from an interest point within which entities are dormant.
+	// When it is not larger than Radius, entities are either active or inactive.
+	FarRadius float64
+	// DormantInterval is the number of frames between the updates of dormant entities.
+	DormantInterval uint64
+	// Position returns the position of the entity, or false if it has none.
+	Position func(entity any) (x, y float64, ok bool)
+
+	engine *Engine
+}
+
+// NewActivation returns an activation for the engine, where entities are active within the radius of an interest
+// point, and inactive beyond it. Dormant entities are updated every 10 frames, once FarRadius is set.
+func NewActivation(engine *Engine, radius float64, position func(entity any) (x, y float64, ok bool)) *Activation {
+	MarkTransient[DormantEntity](engine)
+	MarkTransient[InactiveEntity](engine)
+
+	return &Activation{Radius: radius, DormantInterval: 10, Position: position, engine: engine}
+}
+
+// System returns a system which updates the activities every frame.
+func (a *Activation) System() System {
+	return func(*Engine) {
+		a.Update()
+	}
+}
+
+// Without returns the mask of marker components which systems should exclude during the current frame:
+// InactiveEntity, and DormantEntity except on the frames where dormant entities are updated.
+func (a *Activation) Without() Mask {
+	without := MaskOf[InactiveEntity](a.engine)
+	if a.DormantInterval == 0 || a.engine.Frame()%a.DormantInterval != 0 {
+		without = without.Or(MaskOf[DormantEntity](a.engine))
+	}
+	return without
+}
+
+// Activity returns the current activity of the entity.
+func (a *Activation) Activity(entity any) Activity {
+	switch {
+	case Has[InactiveEntity](a.engine, entity):
+		return Inactive
+	case Has[DormantEntity](a.engine, entity):
+		return Dormant
+	}
+	return Active
+}
+
+// Update recomputes the activity of every entity with components, linking and unlinking the marker components, and
+// emits an ActivityChanged event for every entity whose activity changed.
+func (a *Activation) Update() {
+	engine := a.engine
+
+	type point struct{ x, y float64 }
+	var points []point
+	EachEntity(engine, func(entity any, _ InterestPoint) {
+		if x, y, ok := a.Position(entity); ok {
+			points = append(points, point{x, y})
+		}
+	})
+
+	for _, entity := range a.entities() {
+		activity := Active
+		if x, y, ok := a.Position(entity); ok && !Has[InterestPoint](engine, entity) {
+			nearest := math.Inf(1)
+			for _, p := range points {
+				nearest = math.Min(nearest, math.Hypot(x-p.x, y-p.y))
+			}
+			switch {
+			case nearest <= a.Radius:
+			case nearest <= a.FarRadius:
+				activity = Dormant
+			default:
+				activity = Inactive
+			}
+		}
+
+		if old := a.Activity(entity); old != activity {
+			a.set(entity, old, activity)
+			Emit(engine, ActivityChanged{Entity: entity, Old: old, New: activity})
+		}
+	}
+}
+
+// entities returns every entity with components, ordered by their lowest component id.
+func (a *Activation) entities() []ecsEntity {
+	engine := a.engine
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	var entities []ecsEntity
+	for _, entity := range engine.entitiesByFirstID() {
+		if ent, ok := entity.(ecsEntity); ok {
+			entities = append(entities, ent)
+		}
+	}
+	return entities
+}
+
+// set replaces the marker component of the old activity of the entity by the one of the new activity.
+func (a *Activation) set(entity ecsEntity, old, activity Activity) {
+	engine := a.engine
+
+	var id uint64
+	var ok bool
+	switch old {
+	case Dormant:
+		_, id, ok = Get[DormantEntity](engine, entity)
+	case Inactive:
+		_, id, ok = Get[InactiveEntity](engine, entity)
+	}
+	if ok {
+		engine.deleteComponentID(id)
+	}
+
+	switch activity {
+	case Dormant:
+		engine.AddComponents(entity, DormantEntity{})
+	case Inactive:
+		engine.AddComponents(entity, InactiveEntity{})
+	}
+}
@@ -0,0 +1,61 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestActivation_Update(t *testing.T) {
+	e := tinyecs.NewEngine()
+	act := tinyecs.NewActivation(e, 10, func(entity any) (x, y float64, ok bool) {
+		pos, _, ok := tinyecs.Get[position](e, entity)
+		return pos.X, pos.Y, ok
+	})
+	act.FarRadius = 50
+	act.DormantInterval = 2
+
+	player := &testEntity{name: "player"}
+	e.AddComponents(player, position{}, tinyecs.InterestPoint{})
+	near := &testEntity{name: "near"}
+	e.AddComponents(near, position{X: 5})
+	mid := &testEntity{name: "mid"}
+	e.AddComponents(mid, position{X: 30})
+	far := &testEntity{name: "far"}
+	e.AddComponents(far, position{X: 100})
+
+	act.Update()
+	assert.Equal(t, tinyecs.Active, act.Activity(player))
+	assert.Equal(t, tinyecs.Active, act.Activity(near))
+	assert.Equal(t, tinyecs.Dormant, act.Activity(mid))
+	assert.Equal(t, tinyecs.Inactive, act.Activity(far))
+
+	e.EndFrame()
+	var changes []tinyecs.ActivityChanged
+	tinyecs.ReadEvents(e, func(event tinyecs.ActivityChanged) { changes = append(changes, event) })
+	assert.Equal(t, []tinyecs.ActivityChanged{
+		{Entity: mid, Old: tinyecs.Active, New: tinyecs.Dormant},
+		{Entity: far, Old: tinyecs.Active, New: tinyecs.Inactive},
+	}, changes)
+
+	// Dormant entities are only updated every DormantInterval frames.
+	var updated []string
+	update := func() {
+		updated = nil
+		e.Query(tinyecs.MaskOf[position](e), act.Without()).Each(func(entity any) {
+			updated = append(updated, entity.(*testEntity).name)
+		})
+	}
+	update()
+	assert.ElementsMatch(t, []string{"player", "near"}, updated)
+	e.EndFrame()
+	update()
+	assert.ElementsMatch(t, []string{"player", "near", "mid"}, updated)
+
+	// Entities are reactivated as they come back into range.
+	_, id, _ := tinyecs.Get[position](e, far)
+	tinyecs.Set(e, id, position{X: 8})
+	act.Update()
+	assert.Equal(t, tinyecs.Active, act.Activity(far))
+	assert.False(t, tinyecs.Has[tinyecs.InactiveEntity](e, far))
+}
@@ -0,0 +1,104 @@
+package tinyecs
+
+import "reflect"
+
+// defaultArenaChunkSize is the number of values per chunk of arenas created by FrameArena.
+const defaultArenaChunkSize = 1024
+
+// resetter is implemented by everything the engine resets at the end of a frame.
+type resetter interface {
+	Reset()
+}
+
+// Arena is a bump allocator for short-lived values of type T, such as events, scratch buffers and commands.
+// Values are handed out from large chunks which are reused after Reset, instead of being allocated one by one,
+// so an arena in steady state does not allocate at all.
+// Pointers and slices handed out by an arena must not be used after it has been reset.
+// An Arena is not safe for concurrent use.
+type Arena[T any] struct {
+	chunks    [][]T
+	chunk     int
+	used      int
+	chunkSize int
+	// handed is the number of values handed out since the last reset.
+	handed int
+}
+
+// NewArena returns an Arena which allocates chunks of chunkSize values at a time.
+func NewArena[T any](chunkSize int) *Arena[T] {
+	if chunkSize <= 0 {
+		chunkSize = defaultArenaChunkSize
+	}
+	return &Arena[T]{chunkSize: chunkSize}
+}
+
+// New returns a pointer to a zeroed value of type T.
+func (a *Arena[T]) New() *T {
+	return &a.Slice(1)[0]
+}
+
+// Slice returns a zeroed slice of n values of type T. The slice has no spare capacity,
+// so appending to it copies it out of the arena instead of overwriting other values.
+func (a *Arena[T]) Slice(n int) []T {
+	if n <= 0 {
+		return nil
+	}
+
+	for a.chunk < len(a.chunks) && a.used+n > len(a.chunks[a.chunk]) {
+		a.chunk++
+		a.used = 0
+	}
+	if a.chunk == len(a.chunks) {
+		size := a.chunkSize
+		if n > size {
+			size = n
+		}
+		a.chunks = append(a.chunks, make([]T, size))
+	}
+
+	start := a.used
+	a.used += n
+	a.handed += n
+	return a.chunks[a.chunk][start:a.used:a.used]
+}
+
+// Reset hands all values back to the arena. The memory is zeroed so that the arena does not keep anything alive.
+func (a *Arena[T]) Reset() {
+	var zero T
+	for i := 0; i <= a.chunk && i < len(a.chunks); i++ {
+		used := len(a.chunks[i])
+		if i == a.chunk {
+			used = a.used
+		}
+		for j := range a.chunks[i][:used] {
+			a.chunks[i][j] = zero
+		}
+	}
+
+	a.chunk = 0
+	a.used = 0
+	a.handed = 0
+}
+
+// Len returns the number of values handed out since the last reset, excluding space skipped at the end of chunks.
+func (a *Arena[T]) Len() int {
+	return a.handed
+}
+
+// FrameArena returns the engine's arena for values of type T, creating it on first use.
+// Frame arenas are reset by EndFrame, so values allocated from them live for the rest of the current frame.
+//
+//	hits := tinyecs.FrameArena[Hit](e).Slice(len(targets))
+func FrameArena[T any](engine *Engine) *Arena[T] {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if a, ok := engine.arenas[t]; ok {
+		return a.(*Arena[T])
+	}
+
+	a := NewArena[T](defaultArenaChunkSize)
+	engine.arenas[t] = a
+	return a
+}
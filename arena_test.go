@@ -0,0 +1,61 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestArena_SliceAndReset(t *testing.T) {
+	a := tinyecs.NewArena[velocity](4)
+
+	first := a.Slice(3)
+	first[0].v = 1
+	assert.Len(t, first, 3)
+	assert.Equal(t, 3, cap(first))
+
+	// Does not fit in the remainder of the first chunk.
+	second := a.Slice(2)
+	second[1].v = 2
+	assert.Equal(t, 5, a.Len())
+
+	// Larger than a chunk.
+	big := a.Slice(10)
+	assert.Len(t, big, 10)
+	assert.Equal(t, 15, a.Len())
+
+	a.Reset()
+	assert.Equal(t, 0, a.Len())
+
+	// The memory is reused and zeroed.
+	v := a.New()
+	assert.Equal(t, velocity{}, *v)
+	assert.Same(t, &first[0], v)
+}
+
+func TestArena_NoAllocationsAfterWarmup(t *testing.T) {
+	a := tinyecs.NewArena[velocity](64)
+
+	frame := func() {
+		for i := 0; i < 100; i++ {
+			a.New().v = float64(i)
+		}
+		a.Reset()
+	}
+	frame()
+
+	assert.Zero(t, testing.AllocsPerRun(10, frame))
+}
+
+func TestEngine_EndFrameResetsFrameArenas(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	a := tinyecs.FrameArena[velocity](e)
+	assert.Same(t, a, tinyecs.FrameArena[velocity](e))
+
+	a.Slice(5)
+	assert.Equal(t, 5, a.Len())
+
+	e.EndFrame()
+	assert.Equal(t, 0, a.Len())
+}
@@ -0,0 +1,97 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// benchEngine returns an engine with n entities, each holding a floater and a velocity.
+// The dense storages are built up front so that benchmarks only measure iteration.
+func benchEngine(n int) *tinyecs.Engine {
+	e := tinyecs.NewEngine()
+	for i := 0; i < n; i++ {
+		e.AddComponents(&testEntity{}, floater{f: float64(i)}, velocity{v: 1})
+	}
+	tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {})
+	tinyecs.EachChunk[velocity](e, 0, func([]velocity, []uint64) {})
+	return e
+}
+
+func Test_QueriesDoNotAllocate(t *testing.T) {
+	e := benchEngine(1000)
+
+	var sum float64
+	queries := map[string]func(){
+		"Each": func() {
+			tinyecs.Each[floater](e, func(id uint64, obj floater) {
+				sum += obj.f
+			})
+		},
+		"EachEntity": func() {
+			tinyecs.EachEntity[*testEntity, floater](e, func(entity *testEntity, component floater) {
+				sum += component.f
+			})
+		},
+		"EachChunk": func() {
+			tinyecs.EachChunk[floater](e, 64, func(components []floater, ids []uint64) {
+				sum += components[0].f
+			})
+		},
+	}
+
+	for name, query := range queries {
+		assert.Zero(t, testing.AllocsPerRun(10, query), name)
+	}
+}
+
+func BenchmarkEach(b *testing.B) {
+	e := benchEngine(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tinyecs.Each[floater](e, func(id uint64, obj floater) {
+			obj.f++
+		})
+	}
+}
+
+func BenchmarkEachSet(b *testing.B) {
+	e := benchEngine(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tinyecs.Each[floater](e, func(id uint64, obj floater) {
+			obj.f++
+			tinyecs.Set(e, id, obj)
+		})
+	}
+}
+
+func BenchmarkEachEntity(b *testing.B) {
+	e := benchEngine(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tinyecs.EachEntity[*testEntity, floater](e, func(entity *testEntity, component floater) {
+			component.f++
+		})
+	}
+}
+
+func BenchmarkEachChunk(b *testing.B) {
+	e := benchEngine(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tinyecs.EachChunk[floater](e, 256, func(components []floater, ids []uint64) {
+			for j := range components {
+				_ = components[j].f + 1
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package tinyecs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// frameSwapper is implemented by channels, which deliver their messages at the end of every frame.
+type frameSwapper interface {
+	swap()
+}
+
+// Channel is a named stream of messages of type T, with a single producer and any number of consumers.
+// Messages sent during a frame are delivered at the end of the frame, and every consumer receives each delivered
+// message once during the following frame. Unlike event queues, sending does not lock, which suits high-volume
+// streams such as damage events.
+type Channel[T any] struct {
+	name string
+
+	mtx      sync.Mutex
+	producer bool
+
+	sending   []T
+	delivered []T
+	// generation is incremented on every delivery, so consumers can tell whether they have received it yet.
+	generation uint64
+}
+
+// ChannelProducer sends messages on a Channel. It must not be used concurrently with itself or with EndFrame.
+type ChannelProducer[T any] struct {
+	channel *Channel[T]
+}
+
+// ChannelConsumer receives the messages delivered on a Channel. Each consumer keeps track of what it has received.
+type ChannelConsumer[T any] struct {
+	channel    *Channel[T]
+	generation uint64
+}
+
+// GetChannel returns the engine's channel with the name, creating it on first use.
+// It panics if a channel with the name already exists with another message type.
+//
+//	damage := tinyecs.GetChannel[Damage](e, "damage")
+//	producer := damage.Producer()
+//	consumer := damage.Consumer()
+func GetChannel[T any](engine *Engine, name string) *Channel[T] {
+	engine.eventMtx.Lock()
+	defer engine.eventMtx.Unlock()
+
+	if existing, ok := engine.channels[name]; ok {
+		c, ok := existing.(*Channel[T])
+		if !ok {
+			panic(fmt.Sprintf("tinyecs: channel %q already exists with message type %T", name, existing))
+		}
+		return c
+	}
+
+	c := &Channel[T]{name: name}
+	engine.channels[name] = c
+	return c
+}
+
+// Name returns the name of the channel.
+func (c *Channel[T]) Name() string {
+	return c.name
+}
+
+// Producer returns the producer of the channel. It panics if the channel already has a producer.
+func (c *Channel[T]) Producer() *ChannelProducer[T] {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.producer {
+		panic(fmt.Sprintf("tinyecs: channel %q already has a producer", c.name))
+	}
+	c.producer = true
+	return &ChannelProducer[T]{channel: c}
+}
+
+// Consumer returns a new consumer of the channel, which receives messages from the next delivery onwards.
+func (c *Channel[T]) Consumer() *ChannelConsumer[T] {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return &ChannelConsumer[T]{channel: c, generation: c.generation}
+}
+
+// swap delivers the messages sent during the frame, and reuses the previous delivery's buffer for sending.
+func (c *Channel[T]) swap() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var zero T
+	for i := range c.delivered {
+		c.delivered[i] = zero
+	}
+	c.delivered, c.sending = c.sending, c.delivered[:0]
+	c.generation++
+}
+
+// Send sends a message, which is delivered at the end of the frame.
+func (p *ChannelProducer[T]) Send(message T) {
+	p.channel.sending = append(p.channel.sending, message)
+}
+
+// Receive calls f with every message of the last delivery, unless this consumer has already received it.
+// It returns the number of messages received.
+func (c *ChannelConsumer[T]) Receive(f func(message T)) int {
+	ch := c.channel
+
+	ch.mtx.Lock()
+	if c.generation == ch.generation {
+		ch.mtx.Unlock()
+		return 0
+	}
+	c.generation = ch.generation
+	messages := ch.delivered
+	ch.mtx.Unlock()
+
+	for _, message := range messages {
+		f(message)
+	}
+	return len(messages)
+}
+
+// swapChannels delivers the messages of every channel.
+func (e *Engine) swapChannels() {
+	e.eventMtx.Lock()
+	defer e.eventMtx.Unlock()
+
+	for _, c := range e.channels {
+		c.swap()
+	}
+}
@@ -0,0 +1,54 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ChannelDeliversOncePerConsumer(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	damage := tinyecs.GetChannel[damageEvent](e, "damage")
+	assert.Same(t, damage, tinyecs.GetChannel[damageEvent](e, "damage"))
+	assert.Equal(t, "damage", damage.Name())
+
+	producer := damage.Producer()
+	health := damage.Consumer()
+	audio := damage.Consumer()
+
+	var healthRead, audioRead []int
+	e.AddSystem(tinyecs.StageUpdate, "combat", func(e *tinyecs.Engine) {
+		producer.Send(damageEvent{amount: int(e.Frame())})
+	})
+	e.AddSystem(tinyecs.StageUpdate, "health", func(e *tinyecs.Engine) {
+		// Receiving twice in a frame must not repeat messages.
+		for i := 0; i < 2; i++ {
+			health.Receive(func(message damageEvent) {
+				healthRead = append(healthRead, message.amount)
+			})
+		}
+	})
+
+	e.Update(time.Millisecond)
+	e.Update(time.Millisecond)
+	e.Update(time.Millisecond)
+
+	audio.Receive(func(message damageEvent) {
+		audioRead = append(audioRead, message.amount)
+	})
+
+	assert.Equal(t, []int{0, 1}, healthRead)
+	assert.Equal(t, []int{2}, audioRead)
+}
+
+func Test_ChannelMisuse(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	c := tinyecs.GetChannel[damageEvent](e, "damage")
+	c.Producer()
+
+	assert.Panics(t, func() { c.Producer() })
+	assert.Panics(t, func() { tinyecs.GetChannel[velocity](e, "damage") })
+}
@@ -0,0 +1,288 @@
+// Command tinyecsgen generates typed accessors and queries for tinyecs component types.
+//
+// It is meant to be run using go:generate from the package declaring the components:
+//
+//	//go:generate tinyecsgen -type Position,Velocity -query Position+Velocity
+//
+// For every type, this generates GetPosition, SetPosition and EachPosition, and for every query
+// EachPositionVelocity, which calls a function for every entity having all of the query's components.
+// The helpers loop over the dense storage of their types through tinyecs.Dense, reading components without the type
+// assertions of tinyecs.Get and the function call per component of tinyecs.Each. SetPosition still stores the
+// component using tinyecs.Set, which boxes it.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+func main() {
+	types := flag.String("type", "", "comma-separated list of component type names")
+	queries := flag.String("query", "", "comma-separated list of queries, each a +-separated list of component type names")
+	output := flag.String("output", "tinyecs_gen.go", "output file name, relative to the package directory")
+	dir := flag.String("dir", ".", "directory of the package declaring the component types")
+	flag.Parse()
+
+	if *types == "" && *queries == "" {
+		fmt.Fprintln(os.Stderr, "tinyecsgen: -type or -query is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generate(*dir, *output, split(*types, ","), parseQueries(*queries))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tinyecsgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "tinyecsgen:", err)
+		os.Exit(1)
+	}
+}
+
+// split splits s by sep, dropping empty and surrounding whitespace.
+func split(s, sep string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// parseQueries parses a list of queries such as "Position+Velocity,Position+Sprite".
+func parseQueries(s string) [][]string {
+	var queries [][]string
+	for _, query := range split(s, ",") {
+		queries = append(queries, split(query, "+"))
+	}
+	return queries
+}
+
+// generate returns the formatted source of the helpers for the package in dir.
+// Components used by queries get accessors too, even when they are not listed in types.
+func generate(dir, output string, types []string, queries [][]string) ([]byte, error) {
+	pkg, declared, err := parsePackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var components []string
+	addComponent := func(name string) error {
+		if !declared[name] {
+			return fmt.Errorf("type %s is not declared in package %s", name, pkg)
+		}
+		if !seen[name] {
+			seen[name] = true
+			components = append(components, name)
+		}
+		return nil
+	}
+
+	for _, name := range types {
+		if err := addComponent(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var qs []query
+	for _, q := range queries {
+		if len(q) < 2 {
+			return nil, fmt.Errorf("query %s must have at least two components", strings.Join(q, "+"))
+		}
+
+		inQuery := make(map[string]bool)
+		for _, name := range q {
+			if inQuery[name] {
+				return nil, fmt.Errorf("query %s lists %s more than once", strings.Join(q, "+"), name)
+			}
+			inQuery[name] = true
+			if err := addComponent(name); err != nil {
+				return nil, err
+			}
+		}
+		qs = append(qs, query{Components: q})
+	}
+	sort.Strings(components)
+
+	var buf bytes.Buffer
+	err = generatedFile.Execute(&buf, struct {
+		Package    string
+		Components []string
+		Queries    []query
+	}{pkg, components, qs})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// parsePackage returns the name of the package in dir along with the names of the types it declares.
+// Test files and the output file are ignored.
+func parsePackage(dir, output string) (string, map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pkg string
+	declared := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == output {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, err
+		}
+		if pkg == "" {
+			pkg = f.Name.Name
+		}
+
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				declared[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+
+	if pkg == "" {
+		return "", nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return pkg, declared, nil
+}
+
+// query is a combination of components queried together.
+type query struct {
+	Components []string
+}
+
+// Name returns the name of the query's function.
+func (q query) Name() string {
+	return "Each" + strings.Join(q.Components, "")
+}
+
+// Doc lists the query's components for its doc comment, such as "Position and Velocity".
+func (q query) Doc() string {
+	last := len(q.Components) - 1
+	return strings.Join(q.Components[:last], ", ") + " and " + q.Components[last]
+}
+
+// Params returns the parameter names of the query's components, which are unique within the query.
+func (q query) Params() []string {
+	params := make([]string, len(q.Components))
+	seen := make(map[string]bool)
+	for i, name := range q.Components {
+		p := paramName(name)
+		for base, n := p, 2; seen[p]; n++ {
+			p = fmt.Sprintf("%s%d", base, n)
+		}
+		seen[p] = true
+		params[i] = p
+	}
+	return params
+}
+
+// templateNames are the identifiers used by the generated code, which parameters must not shadow.
+var templateNames = map[string]bool{
+	"tinyecs": true, "e": true, "f": true, "n": true, "i": true, "id": true, "ok": true, "entity": true,
+	"component": true, "dense": true,
+}
+
+// paramName returns the name of a parameter holding the component type.
+func paramName(name string) string {
+	r := []rune(name)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		// Lowercase leading acronyms entirely, such as in AIState.
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	p := string(r)
+	if token.IsKeyword(p) || templateNames[p] || types.Universe.Lookup(p) != nil || strings.HasPrefix(p, "dense") {
+		p += "Component"
+	}
+	return p
+}
+
+var generatedFile = template.Must(template.New("").Parse(`// Code generated by tinyecsgen; DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/kaiaverkvist/tinyecs"
+{{range .Components}}
+// Get{{.}} returns the first {{.}} component of the entity along with its id, or false if it has none.
+func Get{{.}}(e *tinyecs.Engine, entity any) ({{.}}, uint64, bool) {
+	return tinyecs.DenseOf[{{.}}](e).Get(entity)
+}
+
+// Set{{.}} replaces the component with the id.
+func Set{{.}}(e *tinyecs.Engine, id uint64, component {{.}}) {
+	tinyecs.Set(e, id, component)
+}
+
+// Each{{.}} calls f with every {{.}} component along with its id, and returns the number of components visited.
+func Each{{.}}(e *tinyecs.Engine, f func(id uint64, component {{.}})) uint64 {
+	var n uint64
+	tinyecs.EachDense(e, func(dense tinyecs.Dense[{{.}}]) {
+		for i := 0; i < dense.Len(); i++ {
+			if component, id, _, ok := dense.At(i); ok {
+				n++
+				f(id, component)
+			}
+		}
+	})
+	return n
+}
+{{end}}{{range .Queries}}{{$first := index .Components 0}}{{$params := .Params}}
+// {{.Name}} calls f for every entity having {{.Doc}} components,
+// and returns the number of entities visited.
+func {{.Name}}(e *tinyecs.Engine, f func(entity any{{range $i, $c := .Components}}, {{index $params $i}} {{$c}}{{end}})) uint64 {
+	var n uint64
+{{- range $i, $c := .Components}}{{if $i}}
+	dense{{$i}} := tinyecs.DenseOf[{{$c}}](e){{end}}{{end}}
+	tinyecs.EachDense(e, func(dense tinyecs.Dense[{{$first}}]) {
+		for i := 0; i < dense.Len(); i++ {
+			{{index $params 0}}, _, entity, ok := dense.At(i)
+			if !ok {
+				continue
+			}
+{{- range $i, $c := .Components}}{{if $i}}
+			{{index $params $i}}, _, ok := dense{{$i}}.Get(entity)
+			if !ok {
+				continue
+			}
+{{- end}}{{end}}
+			n++
+			f(entity{{range $params}}, {{.}}{{end}})
+		}
+	})
+	return n
+}
+{{end}}`))
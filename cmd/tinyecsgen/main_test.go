@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func Test_Generate(t *testing.T) {
+	src, err := generate("testdata/game", "tinyecs_gen.go", []string{"Position"}, parseQueries("Position+Velocity+AIState"))
+	assert.NoError(t, err)
+
+	f, err := parser.ParseFile(token.NewFileSet(), "tinyecs_gen.go", src, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "game", f.Name.Name)
+
+	var funcs []string
+	for _, obj := range f.Scope.Objects {
+		funcs = append(funcs, obj.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"GetAIState", "SetAIState", "EachAIState",
+		"GetPosition", "SetPosition", "EachPosition",
+		"GetVelocity", "SetVelocity", "EachVelocity",
+		"EachPositionVelocityAIState",
+	}, funcs)
+
+	assert.Contains(t, string(src), "// EachPositionVelocityAIState calls f for every entity having Position, Velocity and AIState components,")
+	assert.Contains(t, string(src), "func EachPositionVelocityAIState(e *tinyecs.Engine, f func(entity any, position Position, velocity Velocity, aiState AIState)) uint64 {")
+	assert.Contains(t, string(src), "tinyecs.EachDense(e, func(dense tinyecs.Dense[Position]) {")
+	assert.NotContains(t, string(src), "tinyecs.Get[")
+}
+
+func Test_ParamName(t *testing.T) {
+	assert.Equal(t, "position", paramName("Position"))
+	assert.Equal(t, "aiState", paramName("AIState"))
+	assert.Equal(t, "okComponent", paramName("OK"))
+	assert.Equal(t, "tinyecsComponent", paramName("Tinyecs"))
+	assert.Equal(t, "uint64Component", paramName("Uint64"))
+	assert.Equal(t, "typeComponent", paramName("Type"))
+	assert.Equal(t, "denseFogComponent", paramName("DenseFog"))
+
+	assert.Equal(t, []string{"aiState", "aiState2"}, query{Components: []string{"AIState", "AiState"}}.Params())
+}
+
+func Test_GenerateUnknownType(t *testing.T) {
+	_, err := generate("testdata/game", "tinyecs_gen.go", []string{"Sprite"}, nil)
+	assert.ErrorContains(t, err, "type Sprite is not declared in package game")
+
+	_, err = generate("testdata/game", "tinyecs_gen.go", nil, parseQueries("Position"))
+	assert.ErrorContains(t, err, "at least two components")
+}
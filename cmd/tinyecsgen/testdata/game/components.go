@@ -0,0 +1,17 @@
+package game
+
+import "github.com/kaiaverkvist/tinyecs"
+
+type Player struct {
+	tinyecs.Entity
+}
+
+type Position struct {
+	X, Y float64
+}
+
+type Velocity struct {
+	X, Y float64
+}
+
+type AIState int
@@ -0,0 +1,170 @@
+// Command tinyecstop shows the live state of a game in the terminal, by connecting to its tinyecs.DebugServer.
+// It only needs a terminal, so it can be used over SSH on servers where a web inspector is not allowed.
+//
+//	tinyecstop -addr localhost:7777
+//
+// The screen is redrawn every -interval with the frame and memory use of the engine, the systems along with how
+// long they took in their last run, and the entities with their components. Commands are typed followed by enter:
+//
+//	/position+velocity !frozen  only show entities with position and velocity components, and no frozen component
+//	/                           clear the filter
+//	disable <system>            disable a system
+//	enable <system>             enable a system
+//	q                           quit
+//
+// Filters are written in the query DSL of tinyecs.ParseQueryFilter, which the tinyecs console accepts as well.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kaiaverkvist/tinyecs"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:7777", "address of the debug server")
+	interval := flag.Duration("interval", time.Second, "time between refreshes")
+	filterFlag := flag.String("filter", "", "initial entity filter, a query such as \"position+velocity !frozen\"")
+	rows := flag.Int("rows", 20, "maximum number of entities shown")
+	flag.Parse()
+
+	client, err := tinyecs.DialDebug(*addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tinyecstop:", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	t := top{client: client, addr: *addr, filter: tinyecs.ParseQueryFilter(*filterFlag), rows: *rows}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if err := t.refresh(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "tinyecstop:", err)
+			os.Exit(1)
+		}
+
+		select {
+		case line, ok := <-lines:
+			if !ok || strings.TrimSpace(line) == "q" {
+				return
+			}
+			t.message = t.command(line)
+		case <-ticker.C:
+		}
+	}
+}
+
+// top is the state of the terminal UI.
+type top struct {
+	client *tinyecs.DebugClient
+	addr   string
+	filter tinyecs.QueryFilter
+	rows   int
+	// message is the result of the last command, shown at the bottom of the screen.
+	message string
+}
+
+// refresh fetches the state of the engine and redraws the screen.
+func (t *top) refresh(w io.Writer) error {
+	stats, err := t.client.Stats()
+	if err != nil {
+		return err
+	}
+	systems, err := t.client.Systems()
+	if err != nil {
+		return err
+	}
+	entities, err := t.client.Entities()
+	if err != nil {
+		return err
+	}
+
+	// Clear the screen and move the cursor to the top left corner.
+	fmt.Fprint(w, "\x1b[H\x1b[2J")
+	render(w, t.addr, stats, systems, entities, t.filter, t.rows)
+	if t.message != "" {
+		fmt.Fprintln(w, t.message)
+	}
+	fmt.Fprint(w, "> ")
+	return nil
+}
+
+// command runs a command typed by the user, and returns the message to show.
+func (t *top) command(line string) string {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "/"):
+		t.filter = tinyecs.ParseQueryFilter(line[1:])
+		return ""
+	case strings.HasPrefix(line, "enable "), strings.HasPrefix(line, "disable "):
+		verb, name, _ := strings.Cut(line, " ")
+		if err := t.client.EnableSystem(strings.TrimSpace(name), verb == "enable"); err != nil {
+			return "error: " + err.Error()
+		}
+		return verb + "d " + name
+	case line == "":
+		return ""
+	}
+	return fmt.Sprintf("unknown command %q", line)
+}
+
+// matches returns whether the entity has every component type the filter requires and none of those it excludes.
+func matches(f tinyecs.QueryFilter, entity tinyecs.DebugEntity) bool {
+	types := make([]string, len(entity.Components))
+	for i, c := range entity.Components {
+		types[i] = c.Type
+	}
+	return f.MatchesTypes(types)
+}
+
+// render writes the screen, showing at most rows of the entities matching the filter.
+func render(w io.Writer, addr string, stats tinyecs.DebugStats, systems []tinyecs.SystemInfo, entities []tinyecs.DebugEntity, f tinyecs.QueryFilter, rows int) {
+	fmt.Fprintf(w, "tinyecstop %s  frame %d  memory %.1f KiB\n\n", addr, stats.Frame, float64(stats.Memory.Total)/1024)
+
+	fmt.Fprintf(w, "%-16s %-24s %-8s %s\n", "STAGE", "SYSTEM", "ENABLED", "TIME")
+	for _, s := range systems {
+		fmt.Fprintf(w, "%-16s %-24s %-8t %s\n", s.Stage, s.Name, s.Enabled, s.Duration)
+	}
+
+	var matched []tinyecs.DebugEntity
+	for _, entity := range entities {
+		if matches(f, entity) {
+			matched = append(matched, entity)
+		}
+	}
+
+	fmt.Fprintf(w, "\nENTITIES %d of %d", len(matched), len(entities))
+	if s := f.String(); s != "" {
+		fmt.Fprintf(w, " matching %s", s)
+	}
+	fmt.Fprintln(w)
+	for i, entity := range matched {
+		if i == rows {
+			fmt.Fprintf(w, "... %d more\n", len(matched)-rows)
+			break
+		}
+
+		var components []string
+		for _, c := range entity.Components {
+			components = append(components, fmt.Sprintf("%d:%s%s", c.ID, c.Type, c.Text))
+		}
+		fmt.Fprintf(w, "%-16s %s\n", entity.Type, strings.Join(components, " "))
+	}
+	fmt.Fprintln(w)
+}
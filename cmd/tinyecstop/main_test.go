@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Matches(t *testing.T) {
+	f := tinyecs.ParseQueryFilter("position+velocity !frozen")
+	entity := func(types ...string) tinyecs.DebugEntity {
+		var e tinyecs.DebugEntity
+		for _, name := range types {
+			e.Components = append(e.Components, tinyecs.DebugComponent{Type: name})
+		}
+		return e
+	}
+	assert.True(t, matches(f, entity("velocity", "position", "sprite")))
+	assert.False(t, matches(f, entity("position")))
+	assert.False(t, matches(f, entity("position", "velocity", "frozen")))
+	assert.True(t, matches(tinyecs.QueryFilter{}, entity()))
+}
+
+func Test_Render(t *testing.T) {
+	stats := tinyecs.DebugStats{Frame: 42, Memory: tinyecs.MemoryStats{Total: 2048}}
+	systems := []tinyecs.SystemInfo{{Stage: tinyecs.StageUpdate, Name: "movement", Enabled: true, Duration: 3 * time.Microsecond}}
+	entities := []tinyecs.DebugEntity{
+		{Type: "player", Components: []tinyecs.DebugComponent{{ID: 0, Type: "position", Text: "{X:1 Y:2}"}}},
+		{Type: "tree"},
+		{Type: "enemy", Components: []tinyecs.DebugComponent{{ID: 1, Type: "position", Text: "{X:3 Y:4}"}}},
+	}
+
+	var buf bytes.Buffer
+	render(&buf, "localhost:7777", stats, systems, entities, tinyecs.ParseQueryFilter("position"), 1)
+	assert.Equal(t, `tinyecstop localhost:7777  frame 42  memory 2.0 KiB
+
+STAGE            SYSTEM                   ENABLED  TIME
+update           movement                 true     3µs
+
+ENTITIES 2 of 3 matching position
+player           0:position{X:1 Y:2}
+... 1 more
+
+`, buf.String())
+}
@@ -0,0 +1,297 @@
+package tinyecs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Console is a developer console executing commands against a running engine, such as from stdin on a headless
+// server or from an in-game text field. Types are referred to by the names registered on the serializer or engine,
+// values are written in YAML, and entities are referred to through the id of any of their components.
+// The query and count commands take queries in the query DSL of ParseQueryFilter.
+//
+//	> spawn {type: enemy, value: {name: Goblin}, components: [position: {x: 1, y: 2}]}
+//	spawned &{Entity:{} Name:Goblin} with components 12
+//	> set 12 {x: 5}
+//	> count position !frozen
+//	1
+//	> query position+velocity
+//	&{Entity:{} Name:Goblin} with components 12
+type Console struct {
+	engine     *Engine
+	serializer *Serializer
+
+	commands map[string]consoleCommand
+	queue    loopQueue
+}
+
+// ConsoleFunc executes a console command, returning the output to print.
+type ConsoleFunc func(args string) (string, error)
+
+type consoleCommand struct {
+	usage string
+	run   ConsoleFunc
+}
+
+// NewConsole returns a console for the engine, which resolves type names using the serializer.
+func NewConsole(engine *Engine, s *Serializer) *Console {
+	c := &Console{
+		engine:     engine,
+		serializer: s,
+		commands:   make(map[string]consoleCommand),
+		queue:      make(loopQueue),
+	}
+
+	c.Handle("help", "help", c.help)
+	c.Handle("count", "count <query>", c.count)
+	c.Handle("query", "query <query>", c.query)
+	c.Handle("list", "list <type>", c.list)
+	c.Handle("get", "get <id>", c.get)
+	c.Handle("set", "set <id> <value>", c.set)
+	c.Handle("spawn", "spawn <scene entity>", c.spawn)
+	c.Handle("despawn", "despawn <id>", c.despawn)
+	return c
+}
+
+// Handle adds a command, replacing any existing command with the same name. usage is shown by the help command.
+func (c *Console) Handle(name, usage string, run ConsoleFunc) {
+	c.commands[name] = consoleCommand{usage: usage, run: run}
+}
+
+// Exec executes a single command line. It must be called from the goroutine running the engine.
+func (c *Console) Exec(line string) (string, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if name == "" {
+		return "", nil
+	}
+
+	cmd, ok := c.commands[name]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q, try help", name)
+	}
+	return cmd.run(strings.TrimSpace(args))
+}
+
+// Serve reads command lines from r until it is exhausted, and writes their output to w.
+// The commands are executed by the system returned by System, so Serve is meant to run on its own goroutine while
+// the engine runs its frames:
+//
+//	e.AddSystem(tinyecs.StagePreUpdate, "console", console.System())
+//	go console.Serve(os.Stdin, os.Stdout)
+func (c *Console) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	for {
+		if _, err := io.WriteString(w, "> "); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		var output string
+		var err error
+		c.queue.run(func() { output, err = c.Exec(scanner.Text()) })
+
+		if err != nil {
+			output = "error: " + err.Error()
+		}
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		if _, err := io.WriteString(w, output); err != nil {
+			return err
+		}
+	}
+}
+
+// System returns a system executing the commands submitted by Serve.
+func (c *Console) System() System {
+	return c.queue.drain()
+}
+
+func (c *Console) help(string) (string, error) {
+	usages := make([]string, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		usages = append(usages, cmd.usage)
+	}
+	sort.Strings(usages)
+	return strings.Join(usages, "\n"), nil
+}
+
+// componentsOfType returns the ids of the components of the type registered under the name, in ascending order.
+func (c *Console) componentsOfType(name string) ([]uint64, error) {
+	t, err := c.serializer.typeOf(c.engine, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.engine.componentMtx.RLock()
+	defer c.engine.componentMtx.RUnlock()
+
+	var ids []uint64
+	for id, component := range c.engine.components {
+		if reflect.TypeOf(component) == t {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// matching returns the entities matching the query, ordered by the id of their first component.
+func (c *Console) matching(query string) ([]any, error) {
+	q, err := c.serializer.ParseQuery(c.engine, query)
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		entity any
+		first  uint64
+	}
+	var matches []match
+	q.Each(func(entity any) {
+		if ids := c.engine.ComponentIDs(entity); len(ids) > 0 {
+			matches = append(matches, match{entity, ids[0]})
+		}
+	})
+	sort.Slice(matches, func(i, j int) bool { return matches[i].first < matches[j].first })
+
+	entities := make([]any, len(matches))
+	for i, m := range matches {
+		entities[i] = m.entity
+	}
+	return entities, nil
+}
+
+// componentList formats the ids of the entity's components.
+func (c *Console) componentList(entity any) string {
+	ids := c.engine.ComponentIDs(entity)
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func (c *Console) count(args string) (string, error) {
+	entities, err := c.matching(args)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(len(entities)), nil
+}
+
+func (c *Console) query(args string) (string, error) {
+	entities, err := c.matching(args)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, entity := range entities {
+		fmt.Fprintf(&b, "%+v with components %s\n", entity, c.componentList(entity))
+	}
+	return b.String(), nil
+}
+
+func (c *Console) list(args string) (string, error) {
+	ids, err := c.componentsOfType(args)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, id := range ids {
+		entity, component, _ := c.component(id)
+		fmt.Fprintf(&b, "%d: %+v of %+v\n", id, component, entity)
+	}
+	return b.String(), nil
+}
+
+// component returns the component with the id along with its entity.
+func (c *Console) component(id uint64) (any, any, bool) {
+	c.engine.componentMtx.RLock()
+	defer c.engine.componentMtx.RUnlock()
+
+	component, ok := c.engine.components[id]
+	return c.engine.links[id].entity, component, ok
+}
+
+// parseID parses a component id, and returns the component along with its entity.
+func (c *Console) parseID(s string) (uint64, any, any, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("invalid id %q", s)
+	}
+	entity, component, ok := c.component(id)
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("no component with id %d", id)
+	}
+	return id, entity, component, nil
+}
+
+func (c *Console) get(args string) (string, error) {
+	id, entity, component, err := c.parseID(args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d: %+v of %+v", id, component, entity), nil
+}
+
+func (c *Console) set(args string) (string, error) {
+	idArg, value, _ := strings.Cut(args, " ")
+	id, _, component, err := c.parseID(idArg)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(value) == "" {
+		return "", errors.New("usage: set <id> <value>")
+	}
+
+	// Decode on top of the current value, so that only the given fields change.
+	v := reflect.New(reflect.TypeOf(component))
+	v.Elem().Set(reflect.ValueOf(component))
+	if err := yaml.Unmarshal([]byte(value), v.Interface()); err != nil {
+		return "", err
+	}
+
+	Set(c.engine, id, v.Elem().Interface())
+	return "", nil
+}
+
+func (c *Console) spawn(args string) (string, error) {
+	var ent SceneEntity
+	if err := yaml.Unmarshal([]byte(args), &ent); err != nil {
+		return "", err
+	}
+
+	entities, err := c.serializer.spawn(Scene{Entities: []SceneEntity{ent}}, c.engine)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("spawned %+v with components %s", entities[0], c.componentList(entities[0])), nil
+}
+
+func (c *Console) despawn(args string) (string, error) {
+	_, entity, _, err := c.parseID(args)
+	if err != nil {
+		return "", err
+	}
+
+	ent, ok := entity.(ecsEntity)
+	if !ok {
+		return "", fmt.Errorf("%+v does not embed tinyecs.Entity", entity)
+	}
+	c.engine.despawn(ent)
+	return fmt.Sprintf("despawned %+v", entity), nil
+}
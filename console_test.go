@@ -0,0 +1,99 @@
+package tinyecs_test
+
+import (
+	"bytes"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Console(t *testing.T) {
+	e := tinyecs.NewEngine()
+	c := tinyecs.NewConsole(e, newTestSerializer(nil))
+
+	out, err := c.Exec("spawn {type: entity, value: {name: Goblin}, components: [position: {x: 1, y: 2}, inventory: {}]}")
+	assert.NoError(t, err)
+	assert.Equal(t, "spawned &{Entity:{} Name:Goblin} with components 0, 1", out)
+
+	out, err = c.Exec("count position")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", out)
+
+	_, err = c.Exec("spawn {type: entity, value: {name: Chest}, components: [inventory: {}]}")
+	assert.NoError(t, err)
+	out, err = c.Exec("query inventory")
+	assert.NoError(t, err)
+	assert.Equal(t, "&{Entity:{} Name:Goblin} with components 0, 1\n&{Entity:{} Name:Chest} with components 2\n", out)
+	out, err = c.Exec("count inventory !position")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", out)
+	_, err = c.Exec("despawn 2")
+	assert.NoError(t, err)
+
+	// Only the given fields change.
+	_, err = c.Exec("set 0 {x: 5}")
+	assert.NoError(t, err)
+	out, err = c.Exec("get 0")
+	assert.NoError(t, err)
+	assert.Equal(t, "0: {X:5 Y:2} of &{Entity:{} Name:Goblin}", out)
+
+	out, err = c.Exec("list position")
+	assert.NoError(t, err)
+	assert.Equal(t, "0: {X:5 Y:2} of &{Entity:{} Name:Goblin}\n", out)
+
+	out, err = c.Exec("despawn 1")
+	assert.NoError(t, err)
+	assert.Equal(t, "despawned &{Entity:{} Name:Goblin}", out)
+	assert.Empty(t, e.GetEntities())
+	assert.Empty(t, e.GetComponents())
+
+	_, err = c.Exec("get 0")
+	assert.ErrorContains(t, err, "no component with id 0")
+	_, err = c.Exec("count velocity")
+	assert.ErrorContains(t, err, `type "velocity" is not registered`)
+	_, err = c.Exec("fly")
+	assert.ErrorContains(t, err, `unknown command "fly"`)
+}
+
+func Test_ConsoleCustomCommand(t *testing.T) {
+	e := tinyecs.NewEngine()
+	c := tinyecs.NewConsole(e, newTestSerializer(nil))
+	c.Handle("frame", "frame", func(string) (string, error) {
+		return "frame " + strings.Repeat("I", int(e.Frame())), nil
+	})
+
+	out, err := c.Exec("help")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "frame\n")
+
+	e.Update(0)
+	out, err = c.Exec("frame")
+	assert.NoError(t, err)
+	assert.Equal(t, "frame I", out)
+}
+
+func Test_ConsoleServe(t *testing.T) {
+	e := tinyecs.NewEngine()
+	c := tinyecs.NewConsole(e, newTestSerializer(nil))
+	e.AddSystem(tinyecs.StagePreUpdate, "console", c.System())
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- c.Serve(strings.NewReader("spawn {type: entity}\ncount nothing\n"), &out)
+	}()
+
+	for {
+		e.Update(time.Millisecond)
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			assert.Equal(t, "> spawned &{Entity:{} Name:} with components \n> error: type \"nothing\" is not registered\n> ", out.String())
+			assert.Len(t, e.GetEntities(), 1)
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package tinyecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"reflect"
+)
+
+// DebugServer exposes a running engine over JSON-RPC, so that external tools can inspect the world, edit components
+// and enable or disable systems. Requests are executed by the system returned by System, between the engine's other
+// systems, so connections never race with the game loop.
+//
+//	debug := tinyecs.NewDebugServer(e, s)
+//	e.AddSystem(tinyecs.StagePreUpdate, "debug", debug.System())
+//	l, _ := net.Listen("tcp", "localhost:7777")
+//	go debug.Serve(l)
+//
+// Tools connect using DialDebug, or any JSON-RPC 1.0 client calling the methods of DebugService as "Debug.<Method>".
+// Values are exchanged as JSON, and types are named as registered on the serializer or engine.
+type DebugServer struct {
+	engine     *Engine
+	serializer *Serializer
+
+	queue  loopQueue
+	server *rpc.Server
+}
+
+// DebugEntity describes an entity through the debug protocol.
+type DebugEntity struct {
+	Type string
+	// Text is the entity formatted using %+v, for values which can not be encoded as JSON.
+	Text       string
+	Value      json.RawMessage
+	Added      bool
+	Components []DebugComponent
+}
+
+// DebugComponent describes a component through the debug protocol.
+type DebugComponent struct {
+	ID    uint64
+	Type  string
+	Text  string
+	Value json.RawMessage
+}
+
+// DebugSetArgs are the arguments of DebugService.SetComponent.
+type DebugSetArgs struct {
+	ID uint64
+	// Value holds the fields to change, which are decoded on top of the current value.
+	Value json.RawMessage
+}
+
+// DebugEnableArgs are the arguments of DebugService.EnableSystem.
+type DebugEnableArgs struct {
+	Name    string
+	Enabled bool
+}
+
+// DebugStats are the results of DebugService.Stats.
+type DebugStats struct {
+	Frame   uint64
+	Memory  MemoryStats
+	Queries []QueryStats
+}
+
+// NewDebugServer returns a debug server for the engine, which names types using the serializer.
+func NewDebugServer(engine *Engine, s *Serializer) *DebugServer {
+	d := &DebugServer{engine: engine, serializer: s, queue: make(loopQueue), server: rpc.NewServer()}
+	if err := d.server.RegisterName("Debug", &DebugService{d: d}); err != nil {
+		panic("tinyecs: " + err.Error())
+	}
+	return d
+}
+
+// System returns a system executing the requests of connected tools.
+func (d *DebugServer) System() System {
+	return d.queue.drain()
+}
+
+// Serve accepts connections on the listener until it is closed, serving each of them on its own goroutine.
+func (d *DebugServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go d.server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// DebugService holds the methods of the debug protocol. It is only exported for net/rpc.
+type DebugService struct {
+	d *DebugServer
+}
+
+// Entities returns every entity along with its components.
+func (s *DebugService) Entities(_ struct{}, reply *[]DebugEntity) error {
+	s.d.queue.run(func() {
+		e := s.d.engine
+		e.componentMtx.RLock()
+		defer e.componentMtx.RUnlock()
+
+		order, ids, added := e.snapshotOrder()
+		entities := make([]DebugEntity, 0, len(order))
+		for _, entity := range order {
+			ent := DebugEntity{Added: added[entity]}
+			ent.Type, ent.Text, ent.Value = s.d.describe(entity)
+			for _, id := range ids[entity] {
+				ent.Components = append(ent.Components, s.d.component(id))
+			}
+			entities = append(entities, ent)
+		}
+		*reply = entities
+	})
+	return nil
+}
+
+// Component returns the component with the id.
+func (s *DebugService) Component(id uint64, reply *DebugComponent) error {
+	var err error
+	s.d.queue.run(func() {
+		e := s.d.engine
+		e.componentMtx.RLock()
+		defer e.componentMtx.RUnlock()
+
+		if _, ok := e.components[id]; !ok {
+			err = fmt.Errorf("no component with id %d", id)
+			return
+		}
+		*reply = s.d.component(id)
+	})
+	return err
+}
+
+// SetComponent changes fields of the component with the id.
+func (s *DebugService) SetComponent(args DebugSetArgs, _ *struct{}) error {
+	var err error
+	s.d.queue.run(func() {
+		e := s.d.engine
+		e.componentMtx.RLock()
+		component, ok := e.components[args.ID]
+		e.componentMtx.RUnlock()
+		if !ok {
+			err = fmt.Errorf("no component with id %d", args.ID)
+			return
+		}
+
+		v := reflect.New(reflect.TypeOf(component))
+		v.Elem().Set(reflect.ValueOf(component))
+		if err = JSONCodec.Unmarshal(args.Value, v.Interface()); err != nil {
+			return
+		}
+		Set(e, args.ID, v.Elem().Interface())
+	})
+	return err
+}
+
+// DeleteComponent deletes the component with the id.
+func (s *DebugService) DeleteComponent(id uint64, _ *struct{}) error {
+	var err error
+	s.d.queue.run(func() {
+		e := s.d.engine
+		e.componentMtx.RLock()
+		_, ok := e.components[id]
+		e.componentMtx.RUnlock()
+		if !ok {
+			err = fmt.Errorf("no component with id %d", id)
+			return
+		}
+		e.deleteComponentID(id)
+	})
+	return err
+}
+
+// Systems returns every system in the order they run.
+func (s *DebugService) Systems(_ struct{}, reply *[]SystemInfo) error {
+	s.d.queue.run(func() {
+		*reply = s.d.engine.Systems()
+	})
+	return nil
+}
+
+// EnableSystem enables or disables the systems with the name. The system serving the request can not be disabled,
+// since nothing would serve the request to enable it again.
+func (s *DebugService) EnableSystem(args DebugEnableArgs, _ *struct{}) error {
+	var found, serving bool
+	s.d.queue.run(func() {
+		e := s.d.engine
+		if serving = !args.Enabled && e.system != nil && e.system.name == args.Name; serving {
+			return
+		}
+		found = e.EnableSystem(args.Name, args.Enabled)
+	})
+	if serving {
+		return fmt.Errorf("system %q serves the debug server and can not be disabled", args.Name)
+	}
+	if !found {
+		return fmt.Errorf("no system named %q", args.Name)
+	}
+	return nil
+}
+
+// Stats returns the current frame, memory use and query statistics of the engine.
+func (s *DebugService) Stats(_ struct{}, reply *DebugStats) error {
+	s.d.queue.run(func() {
+		*reply = DebugStats{
+			Frame:   s.d.engine.Frame(),
+			Memory:  s.d.engine.MemoryStats(),
+			Queries: s.d.engine.QueryStats(),
+		}
+	})
+	return nil
+}
+
+// component describes the component with the id. The caller must hold componentMtx.
+func (d *DebugServer) component(id uint64) DebugComponent {
+	c := DebugComponent{ID: id}
+	c.Type, c.Text, c.Value = d.describe(d.engine.components[id])
+	return c
+}
+
+// describe returns the type name of the value along with its text and JSON forms.
+// Types which are not registered are named using %T. The caller must hold componentMtx.
+func (d *DebugServer) describe(v any) (string, string, json.RawMessage) {
+	name, ok := d.serializer.nameOf(d.engine, reflect.TypeOf(v))
+	if !ok {
+		name = fmt.Sprintf("%T", v)
+	}
+
+	value, err := JSONCodec.Marshal(v)
+	if err != nil {
+		value = nil
+	}
+	return name, fmt.Sprintf("%+v", v), value
+}
+
+// DebugClient is a client of a DebugServer.
+type DebugClient struct {
+	client *rpc.Client
+}
+
+// DialDebug connects to the debug server at the TCP address.
+func DialDebug(addr string) (*DebugClient, error) {
+	client, err := jsonrpc.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &DebugClient{client: client}, nil
+}
+
+// Close closes the connection.
+func (c *DebugClient) Close() error {
+	return c.client.Close()
+}
+
+// Entities returns every entity along with its components.
+func (c *DebugClient) Entities() ([]DebugEntity, error) {
+	var entities []DebugEntity
+	err := c.client.Call("Debug.Entities", struct{}{}, &entities)
+	return entities, err
+}
+
+// Component returns the component with the id.
+func (c *DebugClient) Component(id uint64) (DebugComponent, error) {
+	var component DebugComponent
+	err := c.client.Call("Debug.Component", id, &component)
+	return component, err
+}
+
+// SetComponent changes the fields of the component with the id to those of value, which is encoded as JSON.
+func (c *DebugClient) SetComponent(id uint64, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Call("Debug.SetComponent", DebugSetArgs{ID: id, Value: data}, &struct{}{})
+}
+
+// DeleteComponent deletes the component with the id.
+func (c *DebugClient) DeleteComponent(id uint64) error {
+	return c.client.Call("Debug.DeleteComponent", id, &struct{}{})
+}
+
+// Systems returns every system in the order they run.
+func (c *DebugClient) Systems() ([]SystemInfo, error) {
+	var systems []SystemInfo
+	err := c.client.Call("Debug.Systems", struct{}{}, &systems)
+	return systems, err
+}
+
+// EnableSystem enables or disables the systems with the name.
+func (c *DebugClient) EnableSystem(name string, enabled bool) error {
+	return c.client.Call("Debug.EnableSystem", DebugEnableArgs{Name: name, Enabled: enabled}, &struct{}{})
+}
+
+// Stats returns the current frame, memory use and query statistics of the engine.
+func (c *DebugClient) Stats() (DebugStats, error) {
+	var stats DebugStats
+	err := c.client.Call("Debug.Stats", struct{}{}, &stats)
+	return stats, err
+}
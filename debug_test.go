@@ -0,0 +1,91 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_DebugServer(t *testing.T) {
+	e := tinyecs.NewEngine()
+	player := &savedEntity{Name: "player"}
+	e.AddComponents(player, position{X: 1, Y: 2}, floater{f: 3})
+	e.AddEntity(player)
+
+	e.AddSystem(tinyecs.StageUpdate, "movement", func(*tinyecs.Engine) {})
+
+	debug := tinyecs.NewDebugServer(e, newTestSerializer(nil))
+	e.AddSystem(tinyecs.StagePreUpdate, "debug", debug.System())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go debug.Serve(l)
+
+	// Run the game loop until the test is done.
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				e.Update(time.Millisecond)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+
+	client, err := tinyecs.DialDebug(l.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	entities, err := client.Entities()
+	assert.NoError(t, err)
+	assert.Len(t, entities, 1)
+	assert.Equal(t, "entity", entities[0].Type)
+	assert.JSONEq(t, `{"Name":"player"}`, string(entities[0].Value))
+	assert.True(t, entities[0].Added)
+	assert.Len(t, entities[0].Components, 2)
+	assert.Equal(t, "position", entities[0].Components[0].Type)
+	// Unregistered types are still described.
+	assert.Equal(t, "tinyecs_test.floater", entities[0].Components[1].Type)
+	assert.Equal(t, "{f:3}", entities[0].Components[1].Text)
+
+	assert.NoError(t, client.SetComponent(0, map[string]float64{"X": 10}))
+	c, err := client.Component(0)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"X":10,"Y":2}`, string(c.Value))
+
+	_, err = client.Component(99)
+	assert.EqualError(t, err, "no component with id 99")
+
+	assert.NoError(t, client.DeleteComponent(1))
+	assert.EqualError(t, client.DeleteComponent(1), "no component with id 1")
+	entities, err = client.Entities()
+	assert.NoError(t, err)
+	assert.Len(t, entities[0].Components, 1)
+
+	assert.NoError(t, client.EnableSystem("movement", false))
+	assert.EqualError(t, client.EnableSystem("debug", false), `system "debug" serves the debug server and can not be disabled`)
+	// The debug server keeps serving requests.
+	assert.NoError(t, client.EnableSystem("debug", true))
+	assert.Error(t, client.EnableSystem("missing", false))
+	systems, err := client.Systems()
+	assert.NoError(t, err)
+	assert.Len(t, systems, 2)
+	assert.Equal(t, "movement", systems[1].Name)
+	assert.False(t, systems[1].Enabled)
+
+	stats, err := client.Stats()
+	assert.NoError(t, err)
+	assert.NotZero(t, stats.Frame)
+	assert.NotZero(t, stats.Memory.Total)
+}
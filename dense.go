@@ -0,0 +1,70 @@
+package tinyecs
+
+// Dense gives typed access to the dense storage of component type T, for code which loops over components of known
+// types itself, such as the helpers generated by tinyecsgen. Components are read straight from the storage, without
+// the type assertions of Get or the function call per component of Each.
+type Dense[T any] struct {
+	engine *Engine
+	s      *denseStorage[T]
+}
+
+// DenseOf returns the dense storage of T, which must be a concrete component type.
+// Its indices are only stable within EachDense; outside of it, use Get.
+func DenseOf[T any](engine *Engine) Dense[T] {
+	return Dense[T]{engine: engine, s: storageOf[T](engine)}
+}
+
+// EachDense calls f with the dense storage of T, which must be a concrete component type.
+// Structural changes made during the call are deferred, as with Each, so its indices stay valid until f returns.
+//
+//	tinyecs.EachDense(e, func(positions tinyecs.Dense[Position]) {
+//		for i := 0; i < positions.Len(); i++ {
+//			if p, id, _, ok := positions.At(i); ok {
+//				tinyecs.Set(e, id, Position{X: p.X + 1})
+//			}
+//		}
+//	})
+func EachDense[T any](engine *Engine, f func(d Dense[T])) {
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	f(DenseOf[T](engine))
+}
+
+// Len returns the number of components in the storage, including those of hidden entities.
+func (d Dense[T]) Len() int {
+	return len(d.s.components)
+}
+
+// At returns the component at index i of the storage along with its id and entity, or false if its entity is hidden
+// from queries, having been despawned using DespawnDeferred or released to a Pool.
+func (d Dense[T]) At(i int) (component T, id uint64, entity any, ok bool) {
+	id = d.s.ids[i]
+	entity = d.engine.links[id].entity
+	if d.engine.hidden(entity) {
+		return component, id, entity, false
+	}
+	return d.s.components[i], id, entity, true
+}
+
+// Get returns the first component of type T of the entity along with its id, or false if it has none, like Get.
+func (d Dense[T]) Get(entity any) (component T, id uint64, ok bool) {
+	e := d.engine
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	m, ok := e.masks[entity]
+	if !ok || len(e.disabled) > 0 && e.disabled[entity] {
+		return component, 0, false
+	}
+	bit, ok := lookupTypeBit[T](e)
+	if !ok || len(m.ids[bit]) == 0 {
+		return component, 0, false
+	}
+	id = m.ids[bit][0]
+	i, ok := d.s.index[id]
+	if !ok {
+		return component, 0, false
+	}
+	return d.s.components[i], id, true
+}
@@ -0,0 +1,45 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Dense(t *testing.T) {
+	e := tinyecs.NewEngine()
+	a, b, c := &testEntity{name: "a"}, &testEntity{name: "b"}, &testEntity{name: "c"}
+	e.AddComponents(a, position{X: 1}, velocity{v: 1})
+	e.AddComponents(b, position{X: 2})
+	e.AddComponents(c, position{X: 3}, velocity{v: 3})
+	e.DespawnDeferred(c)
+
+	var visited []string
+	tinyecs.EachDense(e, func(positions tinyecs.Dense[position]) {
+		assert.Equal(t, 3, positions.Len())
+		for i := 0; i < positions.Len(); i++ {
+			p, id, entity, ok := positions.At(i)
+			if !ok {
+				assert.Same(t, c, entity)
+				continue
+			}
+			visited = append(visited, entity.(*testEntity).name)
+			// Structural changes are deferred until EachDense returns.
+			e.DeleteComponentID(id)
+			assert.Equal(t, 3, positions.Len())
+			assert.NotZero(t, p.X)
+		}
+	})
+	assert.ElementsMatch(t, []string{"a", "b"}, visited)
+	assert.False(t, tinyecs.Has[position](e, a))
+
+	velocities := tinyecs.DenseOf[velocity](e)
+	v, id, ok := velocities.Get(a)
+	assert.True(t, ok)
+	assert.Equal(t, velocity{v: 1}, v)
+	_, want, _ := tinyecs.Get[velocity](e, a)
+	assert.Equal(t, want, id)
+
+	_, _, ok = velocities.Get(b)
+	assert.False(t, ok)
+}
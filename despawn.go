@@ -0,0 +1,68 @@
+package tinyecs
+
+// DespawnDeferred marks the entity as despawned right away, so that queries skip it from then on, but only removes it
+// along with its components at the end of the frame. Every system of a frame thus sees the same world, whichever
+// system despawned an entity, while Get still finds the entity's components until the frame ends.
+// Despawning an entity twice in a frame has no further effect.
+//
+//	tinyecs.EachWithEntity(e, func(entity any, id uint64, h Health) {
+//		if h.Current <= 0 {
+//			e.DespawnDeferred(entity.(*Enemy))
+//		}
+//	})
+func (e *Engine) DespawnDeferred(entity ecsEntity) {
+	checkEntity(entity, "DespawnDeferred")
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if e.despawned[entity] {
+		return
+	}
+	e.despawned[entity] = true
+	e.despawnQueue = append(e.despawnQueue, entity)
+}
+
+// Despawning returns whether the entity has been despawned using DespawnDeferred during this frame.
+func (e *Engine) Despawning(entity any) bool {
+	if !comparableEntity(entity) {
+		return false
+	}
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	return e.despawning(entity)
+}
+
+// despawning is Despawning for callers which already hold componentMtx, or run within a query.
+func (e *Engine) despawning(entity any) bool {
+	return len(e.despawned) > 0 && e.despawned[entity]
+}
+
+// hidden returns whether queries skip the entity, because it was despawned using DespawnDeferred or released to a
+// Pool. The caller must hold componentMtx, or run within a query.
+func (e *Engine) hidden(entity any) bool {
+	return len(e.despawned) > 0 && e.despawned[entity] || len(e.disabled) > 0 && e.disabled[entity]
+}
+
+// hiddenID returns whether queries skip the component with the id, because its entity is hidden.
+func (e *Engine) hiddenID(id uint64) bool {
+	return (len(e.despawned) > 0 || len(e.disabled) > 0) && e.hidden(e.links[id].entity)
+}
+
+// despawnQueued despawns the entities queued by DespawnDeferred, in the order they were queued.
+func (e *Engine) despawnQueued() {
+	e.componentMtx.Lock()
+	queue := e.despawnQueue
+	e.despawnQueue = nil
+	e.componentMtx.Unlock()
+
+	for _, entity := range queue {
+		e.despawn(entity)
+	}
+
+	e.componentMtx.Lock()
+	for _, entity := range queue {
+		delete(e.despawned, entity)
+	}
+	e.componentMtx.Unlock()
+}
@@ -0,0 +1,74 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DespawnDeferred(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	doomed := &testEntity{name: "doomed"}
+	survivor := &testEntity{name: "survivor"}
+	for _, entity := range []*testEntity{doomed, survivor} {
+		e.AddComponents(entity, velocity{}, playerData{})
+		e.AddEntity(entity)
+		e.AddToGroup(entity, "all")
+	}
+	moving := e.Query(tinyecs.MaskOf[velocity](e), tinyecs.Mask{})
+
+	var seen [][]string
+	e.AddSystem(tinyecs.StageUpdate, "kill", func(engine *tinyecs.Engine) {
+		engine.DespawnDeferred(doomed)
+		engine.DespawnDeferred(doomed)
+	})
+	e.AddSystem(tinyecs.StageUpdate, "observe", func(engine *tinyecs.Engine) {
+		var names []string
+		tinyecs.EachEntity(engine, func(entity *testEntity, v velocity) {
+			names = append(names, entity.name)
+		})
+		assert.Equal(t, uint64(1), tinyecs.Each(engine, func(id uint64, v velocity) {}))
+		assert.Equal(t, uint64(2), tinyecs.Each(engine, func(id uint64, component any) {}))
+		assert.Equal(t, uint64(1), moving.Each(func(entity any) {}))
+		assert.Equal(t, uint64(1), engine.EachInGroup("all", func(entity any) {}))
+		seen = append(seen, names)
+
+		// The components of the entity remain until the end of the frame.
+		assert.True(t, engine.Despawning(doomed))
+		_, _, ok := tinyecs.Get[velocity](engine, doomed)
+		assert.True(t, ok)
+	})
+
+	e.Update(0)
+	assert.Equal(t, [][]string{{"survivor"}}, seen)
+	assert.False(t, e.Despawning(doomed))
+	_, _, ok := tinyecs.Get[velocity](e, doomed)
+	assert.False(t, ok)
+	assert.Len(t, e.GetEntities(), 1)
+}
+
+func Test_DespawnDeferredChunksAndAdded(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.EachAdded(e, func(entity any, id uint64, v velocity) {})
+
+	entities := []*testEntity{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}}
+	for i, entity := range entities {
+		e.AddComponents(entity, velocity{v: float64(i)})
+		e.AddEntity(entity)
+	}
+	e.DespawnDeferred(entities[1])
+
+	var chunks [][]velocity
+	n := tinyecs.EachChunk(e, 2, func(components []velocity, ids []uint64) {
+		chunks = append(chunks, append([]velocity(nil), components...))
+	})
+	assert.Equal(t, uint64(3), n)
+	assert.Equal(t, [][]velocity{{{v: 0}}, {{v: 2}, {v: 3}}}, chunks)
+
+	var added []string
+	tinyecs.EachAdded(e, func(entity any, id uint64, v velocity) {
+		added = append(added, entity.(*testEntity).name)
+	})
+	assert.Equal(t, []string{"a", "c", "d"}, added)
+}
@@ -0,0 +1,137 @@
+package tinyecs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WorldDiff is the difference between two engines, as returned by Diff.
+type WorldDiff struct {
+	AddedEntities   []any
+	RemovedEntities []any
+
+	AddedComponents   []ComponentDiff
+	RemovedComponents []ComponentDiff
+	ChangedComponents []ComponentDiff
+}
+
+// ComponentDiff describes a component which differs between two engines.
+// Old and OldEntity are nil for added components, and New and NewEntity are nil for removed ones.
+type ComponentDiff struct {
+	ID uint64
+
+	OldEntity any
+	NewEntity any
+
+	Old any
+	New any
+}
+
+// Diff returns the entities and components added, removed and changed going from engine a to engine b.
+// Components are matched by id, and entities and component values are compared using reflect.DeepEqual,
+// so engines which were saved and loaded separately can be compared. To compare snapshots, load them first.
+// Note: Matching entities is quadratic, so Diff is meant for tests and debugging.
+//
+//	if d := tinyecs.Diff(expected, actual); !d.Empty() {
+//		t.Error(d)
+//	}
+func Diff(a, b *Engine) WorldDiff {
+	var d WorldDiff
+	if a == b {
+		return d
+	}
+
+	a.componentMtx.RLock()
+	defer a.componentMtx.RUnlock()
+	b.componentMtx.RLock()
+	defer b.componentMtx.RUnlock()
+
+	d.AddedEntities = missingEntities(b.entities, a.entities)
+	d.RemovedEntities = missingEntities(a.entities, b.entities)
+
+	for id, old := range a.components {
+		oldEntity := a.links[id].entity
+
+		new, ok := b.components[id]
+		if !ok {
+			d.RemovedComponents = append(d.RemovedComponents, ComponentDiff{ID: id, OldEntity: oldEntity, Old: old})
+			continue
+		}
+
+		newEntity := b.links[id].entity
+		if !reflect.DeepEqual(old, new) || !reflect.DeepEqual(oldEntity, newEntity) {
+			d.ChangedComponents = append(d.ChangedComponents, ComponentDiff{
+				ID:        id,
+				OldEntity: oldEntity,
+				NewEntity: newEntity,
+				Old:       old,
+				New:       new,
+			})
+		}
+	}
+	for id, new := range b.components {
+		if _, ok := a.components[id]; !ok {
+			d.AddedComponents = append(d.AddedComponents, ComponentDiff{ID: id, NewEntity: b.links[id].entity, New: new})
+		}
+	}
+
+	for _, diffs := range [][]ComponentDiff{d.AddedComponents, d.RemovedComponents, d.ChangedComponents} {
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].ID < diffs[j].ID })
+	}
+	return d
+}
+
+// missingEntities returns the entities of from which are not in to.
+func missingEntities(from, to []ecsEntity) []any {
+	var missing []any
+	for _, entity := range from {
+		found := false
+		for _, other := range to {
+			if reflect.DeepEqual(entity, other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, entity)
+		}
+	}
+	return missing
+}
+
+// Empty reports whether the engines were equal.
+func (d WorldDiff) Empty() bool {
+	return len(d.AddedEntities) == 0 && len(d.RemovedEntities) == 0 &&
+		len(d.AddedComponents) == 0 && len(d.RemovedComponents) == 0 && len(d.ChangedComponents) == 0
+}
+
+// String returns a report of the differences, one per line:
+//
+//	added entity &{Entity:{} Name:goblin}
+//	removed component 3 main.Position {X:1 Y:2}
+//	changed component 4 main.Position {X:1 Y:2} -> {X:2 Y:2}
+func (d WorldDiff) String() string {
+	var b strings.Builder
+	for _, entity := range d.AddedEntities {
+		fmt.Fprintf(&b, "added entity %+v\n", entity)
+	}
+	for _, entity := range d.RemovedEntities {
+		fmt.Fprintf(&b, "removed entity %+v\n", entity)
+	}
+	for _, c := range d.AddedComponents {
+		fmt.Fprintf(&b, "added component %d %T %+v\n", c.ID, c.New, c.New)
+	}
+	for _, c := range d.RemovedComponents {
+		fmt.Fprintf(&b, "removed component %d %T %+v\n", c.ID, c.Old, c.Old)
+	}
+	for _, c := range d.ChangedComponents {
+		fmt.Fprintf(&b, "changed component %d %T %+v -> %+v", c.ID, c.Old, c.Old, c.New)
+		if !reflect.DeepEqual(c.OldEntity, c.NewEntity) {
+			fmt.Fprintf(&b, " (entity %+v -> %+v)", c.OldEntity, c.NewEntity)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
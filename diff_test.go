@@ -0,0 +1,59 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DiffLoadedCopyIsEmpty(t *testing.T) {
+	e := tinyecs.NewEngine()
+	player := &savedEntity{Name: "player"}
+	e.AddComponents(player, position{X: 1, Y: 2}, inventory{Items: map[string]int{"potion": 1}})
+	e.AddEntity(player)
+
+	s := newTestSerializer(nil)
+	data, err := s.Marshal(e)
+	assert.NoError(t, err)
+	loaded := tinyecs.NewEngine()
+	assert.NoError(t, s.Unmarshal(data, loaded))
+
+	d := tinyecs.Diff(e, loaded)
+	assert.True(t, d.Empty(), d.String())
+	assert.Empty(t, d.String())
+}
+
+func Test_Diff(t *testing.T) {
+	a := tinyecs.NewEngine()
+	player := &savedEntity{Name: "player"}
+	a.AddComponents(player, position{X: 1}, position{X: 2})
+	a.AddEntity(player)
+
+	b := tinyecs.NewEngine()
+	enemy := &savedEntity{Name: "enemy"}
+	b.AddComponents(&savedEntity{Name: "player"}, position{X: 1}) // 0: unchanged
+	b.AddComponents(enemy, position{X: 3})                        // 1: changed value and entity
+	b.AddComponents(enemy, position{X: 4})                        // 2: added
+	b.AddEntity(enemy)
+
+	d := tinyecs.Diff(a, b)
+	assert.False(t, d.Empty())
+	assert.Equal(t, []any{enemy}, d.AddedEntities)
+	assert.Equal(t, []any{player}, d.RemovedEntities)
+	assert.Equal(t, []tinyecs.ComponentDiff{{ID: 2, NewEntity: enemy, New: position{X: 4}}}, d.AddedComponents)
+	assert.Empty(t, d.RemovedComponents)
+	assert.Equal(t, []tinyecs.ComponentDiff{
+		{ID: 1, OldEntity: player, NewEntity: enemy, Old: position{X: 2}, New: position{X: 3}},
+	}, d.ChangedComponents)
+
+	assert.Equal(t, `added entity &{Entity:{} Name:enemy}
+removed entity &{Entity:{} Name:player}
+added component 2 tinyecs_test.position {X:4 Y:0}
+changed component 1 tinyecs_test.position {X:2 Y:0} -> {X:3 Y:0} (entity &{Entity:{} Name:player} -> &{Entity:{} Name:enemy})
+`, d.String())
+
+	// Diffing the other way around swaps additions and removals.
+	reverse := tinyecs.Diff(b, a)
+	assert.Equal(t, []any{player}, reverse.AddedEntities)
+	assert.Len(t, reverse.RemovedComponents, 1)
+}
@@ -0,0 +1,169 @@
+package donburi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kaiaverkvist/tinyecs"
+)
+
+// ComponentTypeId identifies a component type.
+type ComponentTypeId int
+
+// IComponentType is a component type, as returned by NewComponentType.
+type IComponentType interface {
+	Id() ComponentTypeId
+	Name() string
+	Typ() reflect.Type
+
+	// newValue returns a pointer to a new component set to the default value.
+	newValue() any
+}
+
+// nextComponentTypeID is the id of the next component type created.
+var nextComponentTypeID ComponentTypeId = 1
+
+// ComponentType is a type of component, storing values of type T.
+type ComponentType[T any] struct {
+	id         ComponentTypeId
+	name       string
+	defaultVal *T
+}
+
+// NewComponentType returns a new component type. The first option, if any, is the default value of the component.
+func NewComponentType[T any](opts ...any) *ComponentType[T] {
+	c := &ComponentType[T]{id: nextComponentTypeID, name: reflect.TypeOf((*T)(nil)).Elem().Name()}
+	nextComponentTypeID++
+
+	if len(opts) > 0 {
+		v, ok := opts[0].(T)
+		if !ok {
+			panic(fmt.Sprintf("donburi: default value is not assignable to component type: %s", c.name))
+		}
+		c.defaultVal = &v
+	}
+	return c
+}
+
+// Id returns the id of the component type.
+func (c *ComponentType[T]) Id() ComponentTypeId {
+	return c.id
+}
+
+// Name returns the name of the component type, which defaults to the name of T.
+func (c *ComponentType[T]) Name() string {
+	return c.name
+}
+
+// SetName sets the name of the component type.
+func (c *ComponentType[T]) SetName(name string) *ComponentType[T] {
+	c.name = name
+	return c
+}
+
+// String returns the name of the component type.
+func (c *ComponentType[T]) String() string {
+	return c.name
+}
+
+// Typ returns the type of the component values.
+func (c *ComponentType[T]) Typ() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (c *ComponentType[T]) newValue() any {
+	v := new(T)
+	if c.defaultVal != nil {
+		*v = *c.defaultVal
+	}
+	return v
+}
+
+// Get returns a pointer to the component of the entry, which panics if it has none.
+func (c *ComponentType[T]) Get(entry *Entry) *T {
+	v, ok := entry.component(c).(*T)
+	if !ok {
+		panic(fmt.Sprintf("donburi: entry %d has no %s component", entry.entity, c.name))
+	}
+	return v
+}
+
+// GetValue returns the component of the entry.
+func (c *ComponentType[T]) GetValue(entry *Entry) T {
+	return *c.Get(entry)
+}
+
+// Set replaces the component of the entry by the one pointed to.
+func (c *ComponentType[T]) Set(entry *Entry, component *T) {
+	id, ok := entry.ids[c]
+	if !ok {
+		panic(fmt.Sprintf("donburi: entry %d has no %s component", entry.entity, c.name))
+	}
+	tinyecs.Set(entry.world.engine, id, component)
+}
+
+// SetValue sets the value of the component of the entry.
+func (c *ComponentType[T]) SetValue(entry *Entry, value T) {
+	*c.Get(entry) = value
+}
+
+// Each calls f with every entry of the world which has the component.
+func (c *ComponentType[T]) Each(w World, f func(*Entry)) {
+	NewQuery(Contains(c)).Each(w, f)
+}
+
+// First returns the first entry of the world which has the component, or false if there is none.
+func (c *ComponentType[T]) First(w World) (*Entry, bool) {
+	return NewQuery(Contains(c)).First(w)
+}
+
+// MustFirst is First, which panics if no entry has the component.
+func (c *ComponentType[T]) MustFirst(w World) *Entry {
+	entry, ok := c.First(w)
+	if !ok {
+		panic(fmt.Sprintf("donburi: no entry has a %s component", c.name))
+	}
+	return entry
+}
+
+// Tag is the type of tag components, which hold no data.
+type Tag string
+
+// NewTag returns a new tag component type. Its name is the first option, if it is a string.
+func NewTag(opts ...any) *ComponentType[Tag] {
+	if len(opts) > 0 {
+		if name, ok := opts[0].(string); ok {
+			return NewComponentType[Tag](Tag(name)).SetName(name)
+		}
+	}
+	return NewComponentType[Tag]()
+}
+
+// Get returns a pointer to the component of the type of the entry, which panics if it has none.
+func Get[T any](e *Entry, c IComponentType) *T {
+	v, ok := e.component(c).(*T)
+	if !ok {
+		panic(fmt.Sprintf("donburi: entry %d has no %s component", e.entity, c.Name()))
+	}
+	return v
+}
+
+// GetValue returns the component of the type of the entry.
+func GetValue[T any](e *Entry, c IComponentType) T {
+	return *Get[T](e, c)
+}
+
+// SetValue sets the value of the component of the type of the entry.
+func SetValue[T any](e *Entry, c IComponentType, value T) {
+	*Get[T](e, c) = value
+}
+
+// Add adds the component pointed to under the type. Adding a type the entry has does nothing.
+func Add[T any](e *Entry, c IComponentType, component *T) {
+	e.add(c, component)
+}
+
+// Remove removes the component of the type from the entry.
+func Remove[T any](e *Entry, c IComponentType) {
+	e.RemoveComponent(c)
+}
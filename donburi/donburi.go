@@ -0,0 +1,225 @@
+// Package donburi is a compatibility layer exposing the API of github.com/yohamta/donburi backed by a tinyecs engine,
+// to migrate existing projects one system at a time. Most code only needs its import path changed:
+//
+//	var Position = donburi.NewComponentType[Position]()
+//
+//	world := donburi.NewWorld()
+//	player := world.Create(Position, Velocity)
+//	Position.Get(world.Entry(player)).X = 10
+//	donburi.NewQuery(donburi.Contains(Position, Velocity)).Each(world, func(entry *donburi.Entry) { ... })
+//
+// The filters of donburi/filter are declared in this package, so filter.Contains becomes donburi.Contains.
+// Components are stored in the engine as pointers, so tinyecs code can read and update them alongside, using
+// Engine to reach the engine:
+//
+//	tinyecs.EachEntity(world.Engine(), func(entry *donburi.Entry, pos *Position) { ... })
+//
+// Import copies the entities of a donburi world into a World.
+package donburi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kaiaverkvist/tinyecs"
+)
+
+// Entity identifies an entity of a World.
+type Entity uint64
+
+// Null is the zero Entity, which never identifies an entity.
+const Null Entity = 0
+
+// World is a collection of entities, as in donburi.
+type World interface {
+	// Create creates an entity with the components, set to their default values.
+	Create(components ...IComponentType) Entity
+	// CreateMany creates n entities with the components.
+	CreateMany(n int, components ...IComponentType) []Entity
+	// Entry returns the entry of the entity, or nil if it does not exist.
+	Entry(entity Entity) *Entry
+	// Remove removes the entity along with its components.
+	Remove(entity Entity)
+	// Valid returns whether the entity exists.
+	Valid(entity Entity) bool
+	// Len returns the number of entities.
+	Len() int
+	// Engine returns the engine holding the entities.
+	Engine() *tinyecs.Engine
+}
+
+// world is a World backed by a tinyecs engine.
+type world struct {
+	engine *tinyecs.Engine
+	next   Entity
+	// entries holds the entries in the order they were created, which is ascending by entity.
+	entries []*Entry
+	byID    map[Entity]*Entry
+}
+
+// NewWorld returns an empty world with an engine of its own.
+func NewWorld() World {
+	return WrapEngine(tinyecs.NewEngine())
+}
+
+// WrapEngine returns an empty world adding its entities to the engine.
+func WrapEngine(engine *tinyecs.Engine) World {
+	return &world{engine: engine, byID: make(map[Entity]*Entry)}
+}
+
+func (w *world) Create(components ...IComponentType) Entity {
+	w.next++
+	entry := &Entry{world: w, entity: w.next, ids: make(map[IComponentType]uint64)}
+	for _, c := range components {
+		entry.AddComponent(c)
+	}
+
+	w.entries = append(w.entries, entry)
+	w.byID[entry.entity] = entry
+	w.engine.AddEntity(entry)
+	return entry.entity
+}
+
+func (w *world) CreateMany(n int, components ...IComponentType) []Entity {
+	entities := make([]Entity, n)
+	for i := range entities {
+		entities[i] = w.Create(components...)
+	}
+	return entities
+}
+
+func (w *world) Entry(entity Entity) *Entry {
+	return w.byID[entity]
+}
+
+func (w *world) Remove(entity Entity) {
+	entry, ok := w.byID[entity]
+	if !ok {
+		return
+	}
+
+	for len(entry.types) > 0 {
+		entry.RemoveComponent(entry.types[0])
+	}
+	w.engine.RemoveEntity(entry)
+
+	i := sort.Search(len(w.entries), func(i int) bool { return w.entries[i].entity >= entity })
+	w.entries = append(w.entries[:i], w.entries[i+1:]...)
+	delete(w.byID, entity)
+	entry.world = nil
+}
+
+func (w *world) Valid(entity Entity) bool {
+	_, ok := w.byID[entity]
+	return ok
+}
+
+func (w *world) Len() int {
+	return len(w.entries)
+}
+
+func (w *world) Engine() *tinyecs.Engine {
+	return w.engine
+}
+
+// Entry is an entity of a World along with its components. It is the tinyecs entity the components are linked to.
+type Entry struct {
+	world  *world
+	entity Entity
+	// types holds the component types of the entry in the order they were added, and ids the tinyecs id of each.
+	// Component types are tracked by the entry since donburi allows several of them to share a Go type, like tags.
+	types []IComponentType
+	ids   map[IComponentType]uint64
+}
+
+// GetComponents returns the ids of the tinyecs components of the entry.
+func (e *Entry) GetComponents(engine *tinyecs.Engine) []uint64 {
+	return engine.ComponentIDs(e)
+}
+
+// Entity returns the entity of the entry.
+func (e *Entry) Entity() Entity {
+	return e.entity
+}
+
+// Valid returns whether the entity of the entry still exists.
+func (e *Entry) Valid() bool {
+	return e.world != nil
+}
+
+// HasComponent returns whether the entry has a component of the type.
+func (e *Entry) HasComponent(c IComponentType) bool {
+	_, ok := e.ids[c]
+	return ok
+}
+
+// AddComponent adds a component of the type set to its default value. Adding a type the entry has does nothing.
+func (e *Entry) AddComponent(c IComponentType) {
+	e.add(c, c.newValue())
+}
+
+// add adds the component, a pointer, under the type.
+func (e *Entry) add(c IComponentType, component any) {
+	if e.HasComponent(c) {
+		return
+	}
+
+	engine := e.world.engine
+	engine.AddComponents(e, component)
+	e.ids[c] = idOf(engine, e, component)
+	e.types = append(e.types, c)
+}
+
+// RemoveComponent removes the component of the type, if the entry has one.
+func (e *Entry) RemoveComponent(c IComponentType) {
+	id, ok := e.ids[c]
+	if !ok {
+		return
+	}
+
+	e.world.engine.DeleteComponentID(id)
+	delete(e.ids, c)
+	for i, t := range e.types {
+		if t == c {
+			e.types = append(e.types[:i], e.types[i+1:]...)
+			break
+		}
+	}
+}
+
+// Remove removes the entity of the entry from its world.
+func (e *Entry) Remove() {
+	if e.world != nil {
+		e.world.Remove(e.entity)
+	}
+}
+
+// component returns the component of the type, or nil if the entry has none.
+func (e *Entry) component(c IComponentType) any {
+	id, ok := e.ids[c]
+	if !ok {
+		return nil
+	}
+	return e.world.engine.GetComponents()[id]
+}
+
+// String returns the entity and the names of the component types of the entry.
+func (e *Entry) String() string {
+	names := make([]string, len(e.types))
+	for i, c := range e.types {
+		names[i] = c.Name()
+	}
+	return fmt.Sprintf("Entry: {Entity: %d, Components: %v, Valid: %t}", e.entity, names, e.Valid())
+}
+
+// idOf returns the id of the component linked to the entry, which is a pointer.
+func idOf(engine *tinyecs.Engine, entry *Entry, component any) uint64 {
+	ids := engine.ComponentIDs(entry)
+	// The component was just added, so it is the newest one.
+	for i := len(ids) - 1; i >= 0; i-- {
+		if engine.GetComponents()[ids[i]] == component {
+			return ids[i]
+		}
+	}
+	panic("donburi: component was not added")
+}
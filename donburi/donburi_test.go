@@ -0,0 +1,71 @@
+package donburi_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/kaiaverkvist/tinyecs/donburi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type position struct {
+	X, Y float64
+}
+
+type velocity struct {
+	X, Y float64
+}
+
+var (
+	positionType = donburi.NewComponentType[position]()
+	velocityType = donburi.NewComponentType[velocity](velocity{X: 1})
+	enemyTag     = donburi.NewTag("enemy")
+	frozenTag    = donburi.NewTag("frozen")
+)
+
+func TestWorld(t *testing.T) {
+	w := donburi.NewWorld()
+
+	player := w.Create(positionType, velocityType)
+	goblin := w.Create(positionType, velocityType, enemyTag)
+	statue := w.Create(positionType, enemyTag, frozenTag)
+	assert.Equal(t, 3, w.Len())
+
+	entry := w.Entry(player)
+	assert.Equal(t, velocity{X: 1}, velocityType.GetValue(entry))
+	positionType.Get(entry).X = 5
+	donburi.SetValue(w.Entry(goblin), positionType, position{Y: 2})
+
+	var moved []donburi.Entity
+	donburi.NewQuery(donburi.And(
+		donburi.Contains(positionType, velocityType),
+		donburi.Not(donburi.Contains(frozenTag)),
+	)).Each(w, func(entry *donburi.Entry) {
+		moved = append(moved, entry.Entity())
+	})
+	assert.Equal(t, []donburi.Entity{player, goblin}, moved)
+
+	// Tags share the Tag type, but are distinct component types.
+	assert.True(t, w.Entry(statue).HasComponent(frozenTag))
+	assert.False(t, w.Entry(goblin).HasComponent(frozenTag))
+	assert.Equal(t, 2, donburi.NewQuery(donburi.Contains(enemyTag)).Count(w))
+	assert.Equal(t, donburi.Tag("enemy"), enemyTag.GetValue(w.Entry(goblin)))
+
+	// Components are visible to tinyecs code.
+	var xs []float64
+	tinyecs.EachEntity(w.Engine(), func(entry *donburi.Entry, pos *position) {
+		xs = append(xs, pos.X)
+	})
+	assert.ElementsMatch(t, []float64{5, 0, 0}, xs)
+
+	entry.RemoveComponent(velocityType)
+	assert.False(t, entry.HasComponent(velocityType))
+	assert.Panics(t, func() { velocityType.Get(entry) })
+
+	w.Remove(goblin)
+	assert.False(t, w.Valid(goblin))
+	assert.Equal(t, 2, w.Len())
+	first, ok := enemyTag.First(w)
+	assert.True(t, ok)
+	assert.Equal(t, statue, first.Entity())
+	assert.Len(t, w.Engine().GetComponents(), 4)
+}
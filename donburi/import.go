@@ -0,0 +1,67 @@
+package donburi
+
+import (
+	"fmt"
+	"reflect"
+
+	upstream "github.com/yohamta/donburi"
+)
+
+// Import creates an entity in the world for every entity of a donburi world, with copies of its components, and
+// returns the new entity of every imported one. The donburi component types are matched to the given component
+// types by name, and their values converted to the type of the match, so that donburi.Tag components become Tag
+// components. Import fails before creating any entity if a component type has no match.
+//
+//	entities, err := donburi.Import(world, old, Position, Velocity, Enemy)
+func Import(w World, from upstream.World, types ...IComponentType) (map[upstream.Entity]Entity, error) {
+	archetypes := from.Archetypes()
+
+	matches := make(map[upstream.IComponentType]IComponentType)
+	for _, archetype := range archetypes {
+		for _, c := range archetype.Layout().Components() {
+			if _, ok := matches[c]; ok {
+				continue
+			}
+			match, err := matchType(c, types)
+			if err != nil {
+				return nil, err
+			}
+			matches[c] = match
+		}
+	}
+
+	imported := make(map[upstream.Entity]Entity)
+	for _, archetype := range archetypes {
+		for _, entity := range archetype.Entities() {
+			if !from.Valid(entity) {
+				continue
+			}
+
+			src := from.Entry(entity)
+			dst := w.Entry(w.Create())
+			for _, c := range archetype.Layout().Components() {
+				match := matches[c]
+				v := match.newValue()
+				value := reflect.NewAt(c.Typ(), src.Component(c)).Elem()
+				reflect.ValueOf(v).Elem().Set(value.Convert(match.Typ()))
+				dst.add(match, v)
+			}
+			imported[entity] = dst.Entity()
+		}
+	}
+	return imported, nil
+}
+
+// matchType returns the component type with the name and type of the donburi component type.
+func matchType(c upstream.IComponentType, types []IComponentType) (IComponentType, error) {
+	for _, t := range types {
+		if t.Name() != c.Name() {
+			continue
+		}
+		if !c.Typ().ConvertibleTo(t.Typ()) {
+			return nil, fmt.Errorf("component type %s holds %s, which can not hold %s", c.Name(), t.Typ(), c.Typ())
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("no component type matches %s", c.Name())
+}
@@ -0,0 +1,35 @@
+package donburi_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs/donburi"
+	"github.com/stretchr/testify/assert"
+	upstream "github.com/yohamta/donburi"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	upPosition := upstream.NewComponentType[position]()
+	upEnemy := upstream.NewTag("enemy")
+
+	from := upstream.NewWorld()
+	player := from.Create(upPosition)
+	goblin := from.Create(upPosition, upEnemy)
+	upPosition.SetValue(from.Entry(player), position{X: 1})
+	upPosition.SetValue(from.Entry(goblin), position{X: 2})
+	removed := from.Create(upPosition)
+	from.Remove(removed)
+
+	w := donburi.NewWorld()
+	entities, err := donburi.Import(w, from, positionType, enemyTag)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 2)
+	assert.Equal(t, 2, w.Len())
+
+	assert.Equal(t, position{X: 1}, positionType.GetValue(w.Entry(entities[player])))
+	assert.Equal(t, position{X: 2}, positionType.GetValue(w.Entry(entities[goblin])))
+	assert.True(t, w.Entry(entities[goblin]).HasComponent(enemyTag))
+	assert.False(t, w.Entry(entities[player]).HasComponent(enemyTag))
+
+	_, err = donburi.Import(donburi.NewWorld(), from, positionType)
+	assert.ErrorContains(t, err, "no component type matches enemy")
+}
@@ -0,0 +1,134 @@
+package donburi
+
+// LayoutFilter selects entries by the types of their components.
+type LayoutFilter interface {
+	MatchesLayout(components []IComponentType) bool
+}
+
+type contains []IComponentType
+
+// Contains matches the entries which have all of the component types.
+func Contains(components ...IComponentType) LayoutFilter {
+	return contains(components)
+}
+
+func (f contains) MatchesLayout(components []IComponentType) bool {
+	for _, c := range f {
+		if !hasType(components, c) {
+			return false
+		}
+	}
+	return true
+}
+
+type exact []IComponentType
+
+// Exact matches the entries which have exactly the component types.
+func Exact(components []IComponentType) LayoutFilter {
+	return exact(components)
+}
+
+func (f exact) MatchesLayout(components []IComponentType) bool {
+	return len(f) == len(components) && contains(f).MatchesLayout(components)
+}
+
+type not struct {
+	filter LayoutFilter
+}
+
+// Not matches the entries which the filter does not match.
+func Not(filter LayoutFilter) LayoutFilter {
+	return not{filter: filter}
+}
+
+func (f not) MatchesLayout(components []IComponentType) bool {
+	return !f.filter.MatchesLayout(components)
+}
+
+type and []LayoutFilter
+
+// And matches the entries which every filter matches.
+func And(filters ...LayoutFilter) LayoutFilter {
+	return and(filters)
+}
+
+func (f and) MatchesLayout(components []IComponentType) bool {
+	for _, filter := range f {
+		if !filter.MatchesLayout(components) {
+			return false
+		}
+	}
+	return true
+}
+
+type or []LayoutFilter
+
+// Or matches the entries which any of the filters matches.
+func Or(filters ...LayoutFilter) LayoutFilter {
+	return or(filters)
+}
+
+func (f or) MatchesLayout(components []IComponentType) bool {
+	for _, filter := range f {
+		if filter.MatchesLayout(components) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasType returns whether the component type is in components.
+func hasType(components []IComponentType, c IComponentType) bool {
+	for _, other := range components {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Query selects the entries of a world matched by a filter.
+type Query struct {
+	filter LayoutFilter
+}
+
+// NewQuery returns a query of the entries the filter matches.
+func NewQuery(filter LayoutFilter) *Query {
+	return &Query{filter: filter}
+}
+
+// Each calls f with every entry matched by the query, in the order they were created.
+// Entries may be created and removed during the call; created ones are not visited.
+func (q *Query) Each(w World, f func(*Entry)) {
+	for _, entry := range append([]*Entry(nil), entriesOf(w)...) {
+		if entry.Valid() && q.filter.MatchesLayout(entry.types) {
+			f(entry)
+		}
+	}
+}
+
+// Count returns the number of entries matched by the query.
+func (q *Query) Count(w World) int {
+	n := 0
+	for _, entry := range entriesOf(w) {
+		if q.filter.MatchesLayout(entry.types) {
+			n++
+		}
+	}
+	return n
+}
+
+// First returns the first entry matched by the query, or false if there is none.
+func (q *Query) First(w World) (*Entry, bool) {
+	for _, entry := range entriesOf(w) {
+		if q.filter.MatchesLayout(entry.types) {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// entriesOf returns the entries of the world, in the order they were created.
+func entriesOf(w World) []*Entry {
+	return w.(*world).entries
+}
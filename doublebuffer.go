@@ -0,0 +1,60 @@
+package tinyecs
+
+import "reflect"
+
+// DoubleBuffer makes components of type T double-buffered: values stored using Set are held back until the end of
+// the frame, so that every system reads the values of the previous frame, however the systems are ordered.
+// EndFrame then applies all held back values at once. Adding and deleting components takes effect right away.
+//
+//	tinyecs.DoubleBuffer[Position](e)
+//
+//	// Physics writes the next positions, while rendering keeps drawing the current ones.
+//	tinyecs.Set(e, id, Position{X: p.X + v.X})
+//
+// Systems which need to read values written earlier in the frame use Next.
+func DoubleBuffer[T any](engine *Engine) {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	engine.doubleBuffered.set(typeBit[T](engine))
+}
+
+// Next returns the value the component with the id will have in the next frame: the value last stored using Set if it
+// is double-buffered and has been written during this frame, or otherwise its current value.
+func Next[T any](engine *Engine, id uint64) (T, bool) {
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	component, ok := engine.backBuffer[id]
+	if !ok {
+		component, ok = engine.components[id]
+	}
+	c, isT := component.(T)
+	return c, ok && isT
+}
+
+// buffer holds back the component if it replaces a double-buffered component of the same type, and returns whether
+// it did so. The caller must hold componentMtx.
+func (e *Engine) buffer(id uint64, component any) bool {
+	if len(e.doubleBuffered) == 0 {
+		return false
+	}
+
+	link, ok := e.links[id]
+	if !ok || !e.doubleBuffered.has(link.componentType) || e.componentType(reflect.TypeOf(component)) != link.componentType {
+		return false
+	}
+	e.backBuffer[id] = component
+	return true
+}
+
+// swapBuffers applies the values held back by buffer, dropping those of components which have been deleted since.
+// The caller must hold componentMtx.
+func (e *Engine) swapBuffers() {
+	for id, component := range e.backBuffer {
+		if _, ok := e.links[id]; ok {
+			e.setNow(id, component)
+		}
+		delete(e.backBuffer, id)
+	}
+}
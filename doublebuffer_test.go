@@ -0,0 +1,51 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DoubleBuffer(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.DoubleBuffer[velocity](e)
+
+	entity := &testEntity{}
+	e.AddComponents(entity, velocity{v: 1}, floater{f: 1})
+	_, vid, _ := tinyecs.Get[velocity](e, entity)
+	_, fid, _ := tinyecs.Get[floater](e, entity)
+
+	var read []float64
+	e.AddSystem(tinyecs.StageUpdate, "physics", func(engine *tinyecs.Engine) {
+		tinyecs.Each(engine, func(id uint64, v velocity) {
+			tinyecs.Set(engine, id, velocity{v: v.v + 1})
+		})
+		tinyecs.Set(engine, fid, floater{f: 2})
+	})
+	e.AddSystem(tinyecs.StageUpdate, "render", func(engine *tinyecs.Engine) {
+		v, _, _ := tinyecs.Get[velocity](engine, entity)
+		read = append(read, v.v)
+
+		next, ok := tinyecs.Next[velocity](engine, vid)
+		assert.True(t, ok)
+		assert.Equal(t, v.v+1, next.v)
+
+		// Types which are not double-buffered are written right away.
+		f, _, _ := tinyecs.Get[floater](engine, entity)
+		assert.Equal(t, float64(2), f.f)
+	})
+
+	e.Update(0)
+	e.Update(0)
+	assert.Equal(t, []float64{1, 2}, read)
+
+	v, _, _ := tinyecs.Get[velocity](e, entity)
+	assert.Equal(t, velocity{v: 3}, v)
+
+	// Values written to components deleted during the frame are dropped.
+	tinyecs.Set(e, vid, velocity{v: 10})
+	e.DeleteComponent(velocity{v: 3})
+	e.EndFrame()
+	_, _, ok := tinyecs.Get[velocity](e, entity)
+	assert.False(t, ok)
+}
@@ -0,0 +1,38 @@
+package tinyecs
+
+// Each2 calls f with every component of type A whose entity also has a component of type B, along with the entity
+// and the entity's first B component. It returns the number of calls.
+//
+//	tinyecs.Each2(e, func(entity any, pos Position, vel Velocity) {
+//		// Move the entity.
+//	})
+func Each2[A any, B any](engine *Engine, f func(entity any, a A, b B)) uint64 {
+	var counter uint64
+	EachEntity(engine, func(entity any, a A) {
+		if b, _, ok := get[B](engine, entity); ok {
+			counter++
+			f(entity, a, b)
+		}
+	})
+	return counter
+}
+
+// Each2Optional is like Each2, but treats B as optional: f is called for every component of type A, with b pointing
+// to a copy of the entity's first B component, or nil when the entity has none. Use Set to store changes to b.
+//
+//	tinyecs.Each2Optional(e, func(entity any, sprite Sprite, anim *Animation) {
+//		if anim != nil {
+//			// Advance the animation.
+//		}
+//	})
+func Each2Optional[A any, B any](engine *Engine, f func(entity any, a A, b *B)) uint64 {
+	var b B
+	return EachEntity(engine, func(entity any, a A) {
+		var ok bool
+		if b, _, ok = get[B](engine, entity); ok {
+			f(entity, a, &b)
+		} else {
+			f(entity, a, nil)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Each2(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	moving := &testEntity{name: "moving"}
+	e.AddComponents(moving, position{X: 1}, velocity{v: 2})
+	still := &testEntity{name: "still"}
+	e.AddComponents(still, position{X: 3})
+	e.AddComponents(&testEntity{name: "ghost"}, velocity{v: 4})
+
+	var visited []string
+	n := tinyecs.Each2(e, func(entity any, p position, v velocity) {
+		assert.Equal(t, position{X: 1}, p)
+		assert.Equal(t, velocity{v: 2}, v)
+		visited = append(visited, entity.(*testEntity).name)
+	})
+	assert.Equal(t, uint64(1), n)
+	assert.Equal(t, []string{"moving"}, visited)
+
+	velocities := make(map[string]*velocity)
+	n = tinyecs.Each2Optional(e, func(entity any, p position, v *velocity) {
+		if v != nil {
+			copied := *v
+			v = &copied
+		}
+		velocities[entity.(*testEntity).name] = v
+	})
+	assert.Equal(t, uint64(2), n)
+	assert.Equal(t, map[string]*velocity{"moving": {v: 2}, "still": nil}, velocities)
+}
@@ -0,0 +1,102 @@
+package tinyecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The errors returned by the error returning variants of the engine's functions, such as AddComponentsE.
+// They are wrapped with details, so compare them using errors.Is.
+var (
+	// ErrInvalidEntity is returned for entities which are nil or not comparable, and so can not identify components.
+	ErrInvalidEntity = errors.New("tinyecs: invalid entity")
+	// ErrInvalidComponent is returned for nil components.
+	ErrInvalidComponent = errors.New("tinyecs: invalid component")
+	// ErrUnknownEntity is returned for entities which were never added to the engine, or have been removed.
+	ErrUnknownEntity = errors.New("tinyecs: unknown entity")
+	// ErrUnknownComponent is returned for component ids which were never assigned, or are stale since the component
+	// has been deleted.
+	ErrUnknownComponent = errors.New("tinyecs: unknown component")
+	// ErrNoComponent is returned when an entity has no component of the requested type.
+	ErrNoComponent = errors.New("tinyecs: no component of type")
+	// ErrTypeNotRegistered is returned for component types not registered using RegisterType, when the engine was
+	// created with RequireRegisteredTypes.
+	ErrTypeNotRegistered = errors.New("tinyecs: type not registered")
+)
+
+// AddComponentsE is like AddComponents, but returns an error instead of adding anything if the entity or any of
+// the components is invalid.
+//
+//	if err := e.AddComponentsE(player, Position{}, Velocity{}); err != nil {
+//		return fmt.Errorf("spawning player: %w", err)
+//	}
+func (e *Engine) AddComponentsE(entity ecsEntity, components ...any) error {
+	if entity == nil || !comparableEntity(entity) {
+		return fmt.Errorf("%w: %T", ErrInvalidEntity, entity)
+	}
+
+	e.componentMtx.RLock()
+	for i, component := range components {
+		if err := e.checkComponent(component); err != nil {
+			e.componentMtx.RUnlock()
+			return fmt.Errorf("component %d: %w", i, err)
+		}
+	}
+	e.componentMtx.RUnlock()
+
+	e.AddComponents(entity, components...)
+	return nil
+}
+
+// SetE is like Set, but returns an error instead of storing the component if the id is unknown or the component is
+// invalid.
+func SetE(engine *Engine, id uint64, component any) error {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	if _, ok := engine.links[id]; !ok {
+		return fmt.Errorf("%w: id %d", ErrUnknownComponent, id)
+	}
+	if err := engine.checkComponent(component); err != nil {
+		return err
+	}
+
+	engine.set(id, component)
+	return nil
+}
+
+// RemoveEntityE is like RemoveEntity, but returns ErrUnknownEntity if the entity was never added to the engine.
+func (e *Engine) RemoveEntityE(entity ecsEntity) error {
+	if !e.removeEntity(entity) {
+		return fmt.Errorf("%w: %+v", ErrUnknownEntity, entity)
+	}
+	return nil
+}
+
+// GetE is like Get, but returns ErrNoComponent if the entity has no component of type T.
+func GetE[T any](engine *Engine, entity any) (T, uint64, error) {
+	component, id, ok := Get[T](engine, entity)
+	if !ok {
+		return component, 0, fmt.Errorf("%w %v", ErrNoComponent, reflect.TypeOf(typeKey[T]()).Elem())
+	}
+	return component, id, nil
+}
+
+// checkComponent returns an error if the component can not be stored. The caller must hold componentMtx.
+func (e *Engine) checkComponent(component any) error {
+	if component == nil {
+		return ErrInvalidComponent
+	}
+	if !e.requireRegistered {
+		return nil
+	}
+
+	t := reflect.TypeOf(component)
+	if bit, ok := e.componentTypes[t]; ok {
+		if _, ok := e.typeNames[bit]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrTypeNotRegistered, t)
+}
@@ -0,0 +1,51 @@
+package tinyecs_test
+
+import (
+	"errors"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type uncomparableEntity struct {
+	tinyecs.Entity
+	tags []string
+}
+
+func Test_ErrorVariants(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{name: "a"}
+	assert.NoError(t, e.AddComponentsE(entity, velocity{v: 1}))
+	assert.ErrorIs(t, e.AddComponentsE(entity, floater{}, nil), tinyecs.ErrInvalidComponent)
+	assert.ErrorIs(t, e.AddComponentsE(uncomparableEntity{}, velocity{}), tinyecs.ErrInvalidEntity)
+	_, _, ok := tinyecs.Get[floater](e, entity)
+	assert.False(t, ok, "nothing is added when a component is invalid")
+
+	_, id, err := tinyecs.GetE[velocity](e, entity)
+	assert.NoError(t, err)
+	_, _, err = tinyecs.GetE[floater](e, entity)
+	assert.ErrorIs(t, err, tinyecs.ErrNoComponent)
+	assert.EqualError(t, err, "tinyecs: no component of type tinyecs_test.floater")
+
+	assert.NoError(t, tinyecs.SetE(e, id, velocity{v: 2}))
+	e.DeleteComponent(velocity{v: 2})
+	err = tinyecs.SetE(e, id, velocity{v: 3})
+	assert.ErrorIs(t, err, tinyecs.ErrUnknownComponent)
+
+	e.AddEntity(entity)
+	assert.NoError(t, e.RemoveEntityE(entity))
+	assert.True(t, errors.Is(e.RemoveEntityE(entity), tinyecs.ErrUnknownEntity))
+}
+
+func Test_RequireRegisteredTypes(t *testing.T) {
+	e := tinyecs.NewEngine(tinyecs.RequireRegisteredTypes())
+	tinyecs.RegisterType[velocity](e, "velocity")
+
+	entity := &testEntity{}
+	assert.NoError(t, e.AddComponentsE(entity, velocity{}))
+	assert.ErrorIs(t, e.AddComponentsE(entity, floater{}), tinyecs.ErrTypeNotRegistered)
+
+	_, id, _ := tinyecs.Get[velocity](e, entity)
+	assert.ErrorIs(t, tinyecs.SetE(e, id, floater{}), tinyecs.ErrTypeNotRegistered)
+}
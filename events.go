@@ -0,0 +1,129 @@
+package tinyecs
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// eventQueue is the type-erased interface of an EventQueue, used by the engine to deliver events.
+type eventQueue interface {
+	deliveryStage() Stage
+	swap()
+}
+
+// EventQueue is a double-buffered queue of events of type T.
+// Events emitted during a frame are not visible to readers until they are delivered: by default at the end of the frame,
+// so they can be read throughout the next one. Every reader sees every delivered event, and delivered events are
+// discarded at the next delivery. This makes the order of events well-defined relative to the systems, regardless of
+// which system runs first. Emitting is safe for concurrent use.
+type EventQueue[T any] struct {
+	mtx sync.Mutex
+
+	writing []T
+	reading []T
+
+	// stage is the stage before which events are delivered, or empty to deliver at the end of the frame.
+	stage Stage
+}
+
+// Emit adds an event to the queue, to be delivered at the next delivery.
+func (q *EventQueue[T]) Emit(event T) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	q.writing = append(q.writing, event)
+}
+
+// Read calls f with every delivered event, in the order they were emitted, and returns the number of events.
+func (q *EventQueue[T]) Read(f func(event T)) int {
+	q.mtx.Lock()
+	events := q.reading
+	q.mtx.Unlock()
+
+	for _, event := range events {
+		f(event)
+	}
+	return len(events)
+}
+
+// Len returns the number of delivered events.
+func (q *EventQueue[T]) Len() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	return len(q.reading)
+}
+
+func (q *EventQueue[T]) deliveryStage() Stage {
+	return q.stage
+}
+
+// swap delivers the emitted events, and reuses the buffer of the previously delivered events for writing.
+func (q *EventQueue[T]) swap() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	var zero T
+	for i := range q.reading {
+		q.reading[i] = zero
+	}
+	q.reading, q.writing = q.writing, q.reading[:0]
+}
+
+// Events returns the engine's event queue for events of type T, creating it on first use.
+func Events[T any](engine *Engine) *EventQueue[T] {
+	engine.eventMtx.Lock()
+	defer engine.eventMtx.Unlock()
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if q, ok := engine.events[t]; ok {
+		return q.(*EventQueue[T])
+	}
+
+	q := &EventQueue[T]{}
+	engine.events[t] = q
+	return q
+}
+
+// DeliverEventsAt makes events of type T get delivered right before the stage runs, instead of at the end of the frame.
+// Events emitted by the stage and the ones after it are then read from the stage onwards in the next frame, while
+// events emitted by earlier stages are read in the same frame.
+func DeliverEventsAt[T any](engine *Engine, stage Stage) {
+	q := Events[T](engine)
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	q.stage = stage
+}
+
+// Emit emits an event of type T on the engine.
+//
+//	tinyecs.Emit(e, Damage{Target: id, Amount: 10})
+func Emit[T any](engine *Engine, event T) {
+	Events[T](engine).Emit(event)
+	atomic.AddUint64(&engine.emitted, 1)
+}
+
+// ReadEvents calls f with every delivered event of type T, and returns the number of events.
+//
+//	tinyecs.ReadEvents[Damage](e, func(d Damage) {
+//		log.Println("damage: ", d.Amount)
+//	})
+func ReadEvents[T any](engine *Engine, f func(event T)) int {
+	return Events[T](engine).Read(f)
+}
+
+// deliverEvents delivers the events of every queue which is delivered at the stage.
+// The empty stage stands for the end of the frame.
+func (e *Engine) deliverEvents(stage Stage) {
+	e.eventMtx.Lock()
+	defer e.eventMtx.Unlock()
+
+	for _, q := range e.events {
+		if q.deliveryStage() == stage {
+			q.swap()
+		}
+	}
+}
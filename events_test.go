@@ -0,0 +1,63 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type damageEvent struct {
+	amount int
+}
+
+func Test_EventsAreDeliveredNextFrame(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var frame int
+	var read [][]int
+
+	// The reader runs first, but still sees a consistent set of events: the ones emitted during the previous frame.
+	e.AddSystem(tinyecs.StagePreUpdate, "reader", func(e *tinyecs.Engine) {
+		var amounts []int
+		tinyecs.ReadEvents[damageEvent](e, func(event damageEvent) {
+			amounts = append(amounts, event.amount)
+		})
+		read = append(read, amounts)
+	})
+	e.AddSystem(tinyecs.StageUpdate, "writer", func(e *tinyecs.Engine) {
+		frame++
+		tinyecs.Emit(e, damageEvent{amount: frame})
+		tinyecs.Emit(e, damageEvent{amount: frame * 10})
+	})
+
+	for i := 0; i < 3; i++ {
+		e.Update(time.Millisecond)
+	}
+
+	assert.Equal(t, [][]int{nil, {1, 10}, {2, 20}}, read)
+	assert.Equal(t, 2, tinyecs.Events[damageEvent](e).Len())
+}
+
+func Test_EventsDeliveredAtStage(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.DeliverEventsAt[damageEvent](e, tinyecs.StagePostUpdate)
+
+	var frame int
+	var read []int
+	e.AddSystem(tinyecs.StageUpdate, "writer", func(e *tinyecs.Engine) {
+		frame++
+		tinyecs.Emit(e, damageEvent{amount: frame})
+	})
+	e.AddSystem(tinyecs.StagePostUpdate, "reader", func(e *tinyecs.Engine) {
+		tinyecs.ReadEvents[damageEvent](e, func(event damageEvent) {
+			read = append(read, event.amount)
+		})
+	})
+
+	e.Update(time.Millisecond)
+	e.Update(time.Millisecond)
+
+	// Delivered within the same frame, since the writer runs before the delivery stage.
+	assert.Equal(t, []int{1, 2}, read)
+}
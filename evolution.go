@@ -0,0 +1,90 @@
+package tinyecs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// UnknownFields holds the encoded fields of a struct which the struct does not declare, so that fields written by a
+// newer or older version of a component survive being loaded and saved again.
+// Codecs fill an exported field of this type when decoding a struct, and write its fields back when encoding, after
+// the declared fields. Fields are keyed by name, except for ProtobufCodec, which keys them by field number.
+//
+//	type Health struct {
+//		Current int
+//		Unknown tinyecs.UnknownFields
+//	}
+type UnknownFields map[string]RawValue
+
+var (
+	unknownFieldsType = reflect.TypeOf(UnknownFields(nil))
+	unknownFields     sync.Map // map[reflect.Type][]int
+)
+
+// unknownFieldsOf returns the index path of the UnknownFields field of the struct type t, or nil if it has none.
+// Like other fields, an UnknownFields field may be promoted from an embedded struct.
+func unknownFieldsOf(t reflect.Type) []int {
+	if index, ok := unknownFields.Load(t); ok {
+		return index.([]int)
+	}
+
+	var find func(t reflect.Type, index []int) []int
+	find = func(t reflect.Type, index []int) []int {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			path := append(append([]int(nil), index...), i)
+			if f.Type == unknownFieldsType && f.IsExported() {
+				return path
+			}
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if found := find(f.Type, path); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	index := find(t, nil)
+
+	unknownFields.Store(t, index)
+	return index
+}
+
+// keepUnknown stores a copy of the encoded field in the UnknownFields value.
+func keepUnknown(v reflect.Value, name string, data []byte) {
+	if v.IsNil() {
+		v.Set(reflect.ValueOf(make(UnknownFields)))
+	}
+	v.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(RawValue(append([]byte(nil), data...))))
+}
+
+// Alias makes the serializer load values saved under the old name as the type registered under name,
+// so that saves keep working after an entity or component type is renamed.
+//
+//	tinyecs.Register[Position](s, "position")
+//	s.Alias("pos", "position")
+func (s *Serializer) Alias(old, name string) {
+	s.aliases[old] = name
+}
+
+// typeOf returns the type registered under the name or one of its aliases,
+// on the serializer or otherwise on the engine using RegisterType.
+func (s *Serializer) typeOf(engine *Engine, name string) (reflect.Type, error) {
+	if alias, ok := s.aliases[name]; ok {
+		if _, ok := s.types[name]; !ok {
+			name = alias
+		}
+	}
+	if t, ok := s.types[name]; ok {
+		return t, nil
+	}
+
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	if t, ok := engine.registeredType(name); ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("type %q is not registered", name)
+}
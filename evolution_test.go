@@ -0,0 +1,96 @@
+package tinyecs_test
+
+import (
+	"errors"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// healthV1 is an old version of health, which had a regeneration field.
+type healthV1 struct {
+	Current int
+	Regen   float64
+	Tags    []string
+}
+
+// healthV2 no longer declares the regeneration and tags, but keeps them around.
+type healthV2 struct {
+	Current int
+	Unknown tinyecs.UnknownFields
+}
+
+func Test_UnknownFieldsArePreserved(t *testing.T) {
+	for name, codec := range map[string]tinyecs.Codec{
+		"json":     tinyecs.JSONCodec,
+		"msgpack":  tinyecs.MsgpackCodec,
+		"protobuf": tinyecs.ProtobufCodec,
+	} {
+		t.Run(name, func(t *testing.T) {
+			old := healthV1{Current: 10, Regen: 0.5, Tags: []string{"boss", "undead"}}
+			data, err := codec.Marshal(old)
+			assert.NoError(t, err)
+
+			var current healthV2
+			assert.NoError(t, codec.Unmarshal(data, &current))
+			assert.Equal(t, 10, current.Current)
+			assert.Len(t, current.Unknown, 2)
+
+			current.Current = 5
+			data, err = codec.Marshal(current)
+			assert.NoError(t, err)
+
+			var roundTripped healthV1
+			assert.NoError(t, codec.Unmarshal(data, &roundTripped))
+			assert.Equal(t, healthV1{Current: 5, Regen: 0.5, Tags: []string{"boss", "undead"}}, roundTripped)
+		})
+	}
+}
+
+func Test_SerializerAlias(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{Name: "player"}, position{X: 1})
+
+	old := tinyecs.NewSerializer(nil)
+	tinyecs.Register[*savedEntity](old, "entity")
+	tinyecs.Register[position](old, "pos")
+	data, err := old.Marshal(e)
+	assert.NoError(t, err)
+
+	s := newTestSerializer(nil)
+	loaded := tinyecs.NewEngine()
+	assert.Error(t, s.Unmarshal(data, loaded))
+
+	s.Alias("pos", "position")
+	loaded = tinyecs.NewEngine()
+	assert.NoError(t, s.Unmarshal(data, loaded))
+	assert.True(t, tinyecs.Diff(e, loaded).Empty())
+}
+
+func Test_SerializerFallback(t *testing.T) {
+	type legacyScore struct{ Points int }
+
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{Name: "player"}, position{X: 1}, legacyScore{Points: 3})
+
+	old := newTestSerializer(nil)
+	tinyecs.Register[legacyScore](old, "score")
+	data, err := old.Marshal(e)
+	assert.NoError(t, err)
+
+	s := newTestSerializer(nil)
+	var dropped []string
+	s.Fallback = func(engine *tinyecs.Engine, entity any, c tinyecs.ComponentSnapshot) error {
+		dropped = append(dropped, c.Type)
+		return nil
+	}
+	loaded := tinyecs.NewEngine()
+	assert.NoError(t, s.Unmarshal(data, loaded))
+	assert.Equal(t, []string{"score"}, dropped)
+	assert.Len(t, loaded.GetComponents(), 1)
+
+	s.Fallback = func(engine *tinyecs.Engine, entity any, c tinyecs.ComponentSnapshot) error {
+		return errors.New("no migration")
+	}
+	assert.ErrorContains(t, s.Unmarshal(data, loaded), "no migration")
+}
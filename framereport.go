@@ -0,0 +1,128 @@
+package tinyecs
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// FrameReport summarizes a frame run by Update, for performance overlays and profiling tools.
+type FrameReport struct {
+	Frame    uint64
+	Duration time.Duration
+	Stages   []StageReport
+	// StructuralChanges counts the components added, deleted or replaced with another type, and the entities added
+	// and removed, during the frame.
+	StructuralChanges uint64
+	// Events counts the events emitted using Emit during the frame.
+	Events uint64
+	// Allocations and AllocatedBytes count the heap allocations made during the frame.
+	// They are only counted once enabled using ReportAllocations.
+	Allocations    uint64
+	AllocatedBytes uint64
+}
+
+// StageReport is the part of a FrameReport describing a stage.
+type StageReport struct {
+	Stage    Stage
+	Duration time.Duration
+	Systems  []SystemReport
+}
+
+// SystemReport is the part of a FrameReport describing a system. Disabled systems are left out.
+type SystemReport struct {
+	Name        string
+	Duration    time.Duration
+	Allocations uint64
+}
+
+// FrameReport returns the report of the last frame run by Update.
+//
+//	r := e.FrameReport()
+//	overlay.Printf("%v, %d changes, %d events", r.Duration, r.StructuralChanges, r.Events)
+func (e *Engine) FrameReport() FrameReport {
+	return e.lastReport
+}
+
+// ReportAllocations enables or disables counting the heap allocations of every frame and system in FrameReport.
+// Counting stops the world briefly before and after every system, so it is disabled by default.
+func (e *Engine) ReportAllocations(enabled bool) {
+	e.reportAllocs = enabled
+}
+
+// structuralChange counts a structural change for the frame report.
+func (e *Engine) structuralChange() {
+	atomic.AddUint64(&e.structural, 1)
+}
+
+// frameReporter builds the FrameReport of a running frame.
+type frameReporter struct {
+	engine *Engine
+	report FrameReport
+	start  time.Time
+	allocs runtime.MemStats
+
+	// structural and events are the engine's counters when the frame started.
+	structural uint64
+	events     uint64
+}
+
+// beginFrame starts the report of a frame.
+func (e *Engine) beginFrame() *frameReporter {
+	r := &frameReporter{
+		engine:     e,
+		report:     FrameReport{Frame: e.frame, Stages: make([]StageReport, 0, len(e.stages))},
+		start:      time.Now(),
+		structural: atomic.LoadUint64(&e.structural),
+		events:     atomic.LoadUint64(&e.emitted),
+	}
+	if e.reportAllocs {
+		runtime.ReadMemStats(&r.allocs)
+	}
+	return r
+}
+
+// beginStage adds a stage to the report.
+func (r *frameReporter) beginStage(stage Stage) {
+	r.report.Stages = append(r.report.Stages, StageReport{Stage: stage})
+}
+
+// mallocs returns the number of heap allocations made so far, or zero if allocations are not reported.
+func (r *frameReporter) mallocs() uint64 {
+	if !r.engine.reportAllocs {
+		return 0
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Mallocs
+}
+
+// system adds a system which took d to the current stage. mallocs is the result of mallocs before the system ran.
+func (r *frameReporter) system(name string, d time.Duration, mallocs uint64) {
+	s := &r.report.Stages[len(r.report.Stages)-1]
+	report := SystemReport{Name: name, Duration: d}
+	if r.engine.reportAllocs {
+		report.Allocations = r.mallocs() - mallocs
+	}
+	s.Systems = append(s.Systems, report)
+}
+
+// endStage records the duration of the current stage.
+func (r *frameReporter) endStage(start time.Time) {
+	r.report.Stages[len(r.report.Stages)-1].Duration = time.Since(start)
+}
+
+// end completes the report, which FrameReport returns from then on.
+func (r *frameReporter) end() {
+	e := r.engine
+	r.report.Duration = time.Since(r.start)
+	r.report.StructuralChanges = atomic.LoadUint64(&e.structural) - r.structural
+	r.report.Events = atomic.LoadUint64(&e.emitted) - r.events
+	if e.reportAllocs {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		r.report.Allocations = after.Mallocs - r.allocs.Mallocs
+		r.report.AllocatedBytes = after.TotalAlloc - r.allocs.TotalAlloc
+	}
+	e.lastReport = r.report
+}
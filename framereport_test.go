@@ -0,0 +1,48 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var reportSink []*playerData
+
+func Test_FrameReport(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.ReportAllocations(true)
+
+	e.AddSystem(tinyecs.StageUpdate, "spawn", func(engine *tinyecs.Engine) {
+		entity := &testEntity{name: "spawned"}
+		engine.AddComponents(entity, velocity{}, floater{})
+		engine.AddEntity(entity)
+		tinyecs.Emit(engine, damageEvent{})
+	})
+	e.AddSystem(tinyecs.StagePostUpdate, "alloc", func(engine *tinyecs.Engine) {
+		for i := 0; i < 10; i++ {
+			reportSink = append(reportSink, &playerData{})
+		}
+	})
+	e.AddSystem(tinyecs.StagePostUpdate, "disabled", func(engine *tinyecs.Engine) {})
+	e.EnableSystem("disabled", false)
+
+	assert.Equal(t, tinyecs.FrameReport{}, e.FrameReport())
+	e.Update(0)
+
+	r := e.FrameReport()
+	assert.Equal(t, uint64(0), r.Frame)
+	assert.Equal(t, uint64(3), r.StructuralChanges)
+	// AddEntity emits EntitySpawned.
+	assert.Equal(t, uint64(2), r.Events)
+	assert.GreaterOrEqual(t, r.Allocations, uint64(10))
+	assert.Greater(t, r.AllocatedBytes, uint64(0))
+	assert.Greater(t, r.Duration, r.Stages[2].Duration)
+
+	assert.Len(t, r.Stages, 3)
+	assert.Equal(t, tinyecs.StagePreUpdate, r.Stages[0].Stage)
+	assert.Empty(t, r.Stages[0].Systems)
+	assert.Equal(t, "spawn", r.Stages[1].Systems[0].Name)
+	assert.Len(t, r.Stages[2].Systems, 1)
+	assert.Equal(t, "alloc", r.Stages[2].Systems[0].Name)
+	assert.GreaterOrEqual(t, r.Stages[2].Systems[0].Allocations, uint64(10))
+}
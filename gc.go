@@ -0,0 +1,73 @@
+package tinyecs
+
+import "sort"
+
+// GCReport describes what GC reclaimed. IDs are sorted in ascending order.
+type GCReport struct {
+	// Components holds the components whose entity was removed, and Entities the number of such entities.
+	Components []uint64
+	Entities   int
+	// Links holds the links whose component had been deleted.
+	Links []uint64
+	// Unlinked holds the components which were not linked to any entity.
+	Unlinked []uint64
+}
+
+// Reclaimed returns the total number of components and links reclaimed.
+func (r GCReport) Reclaimed() int {
+	return len(r.Components) + len(r.Links) + len(r.Unlinked)
+}
+
+// GC deletes the components linked to entities which were removed using RemoveEntity without deleting their
+// components, along with any links and components left dangling by either side having been deleted on its own.
+// It returns what was reclaimed. Components of entities which were never added using AddEntity are kept, since
+// entities need not be added, and components are often linked to an entity value whose pointer is added.
+//
+//	if r := e.GC(); r.Reclaimed() > 0 {
+//		log.Printf("reclaimed %d components of %d entities", len(r.Components), r.Entities)
+//	}
+//
+// GC walks every component, so it is best called between levels. It must not be called during a query.
+func (e *Engine) GC() GCReport {
+	e.componentMtx.Lock()
+	if e.iterating > 0 {
+		e.componentMtx.Unlock()
+		panic("tinyecs: GC called during a query")
+	}
+
+	var r GCReport
+	dead := make(map[any]bool)
+	for id, link := range e.links {
+		if _, ok := e.components[id]; !ok {
+			r.Links = append(r.Links, id)
+		} else if e.removed[link.entity] && !e.disabled[link.entity] {
+			r.Components = append(r.Components, id)
+			dead[link.entity] = true
+		}
+	}
+	for id := range e.components {
+		if _, ok := e.links[id]; !ok {
+			r.Unlinked = append(r.Unlinked, id)
+		}
+	}
+	r.Entities = len(dead)
+	e.componentMtx.Unlock()
+
+	for _, ids := range [][]uint64{r.Components, r.Links, r.Unlinked} {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			e.deleteComponent(id)
+		}
+	}
+	return r
+}
+
+// markRemoved records that the entity was removed from the engine, if it still has components for GC to delete.
+func (e *Engine) markRemoved(entity any) {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if _, ok := e.masks[entity]; ok {
+		e.removed[entity] = true
+	}
+}
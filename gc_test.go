@@ -0,0 +1,70 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_GC(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	alive := &testEntity{name: "alive"}
+	e.AddComponents(alive, velocity{v: 1})
+	e.AddEntity(alive)
+
+	removed := &testEntity{name: "removed"}
+	e.AddComponents(removed, velocity{v: 2}, floater{f: 2})
+	e.AddEntity(removed)
+	e.RemoveEntity(removed)
+	_, vid, _ := tinyecs.Get[velocity](e, removed)
+	_, fid, _ := tinyecs.Get[floater](e, removed)
+
+	// Setting an unknown id leaves a component without a link.
+	tinyecs.Set(e, 1000, floater{f: 3})
+
+	r := e.GC()
+	assert.Equal(t, tinyecs.GCReport{Components: []uint64{vid, fid}, Entities: 1, Unlinked: []uint64{1000}}, r)
+	assert.Equal(t, 3, r.Reclaimed())
+	assert.Len(t, e.GetComponents(), 1)
+
+	_, _, ok := tinyecs.Get[velocity](e, alive)
+	assert.True(t, ok)
+	_, _, ok = tinyecs.Get[velocity](e, removed)
+	assert.False(t, ok)
+
+	assert.Equal(t, 0, e.GC().Reclaimed())
+}
+
+func Test_GCDuringQuery(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddComponents(&testEntity{}, velocity{})
+
+	assert.PanicsWithValue(t, "tinyecs: GC called during a query", func() {
+		tinyecs.Each(e, func(id uint64, v velocity) {
+			e.GC()
+		})
+	})
+}
+
+func Test_GCKeepsEntitiesNeverAdded(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	// Components linked to the entity value while its pointer is added, as in the README.
+	entity := testEntity{name: "player"}
+	e.AddComponents(entity, velocity{v: 1})
+	e.AddEntity(&entity)
+	loose := &testEntity{name: "loose"}
+	e.AddComponents(loose, floater{})
+
+	assert.Equal(t, 0, e.GC().Reclaimed())
+	assert.Len(t, e.GetComponents(), 2)
+
+	// Removing and adding an entity again keeps its components as well.
+	e.RemoveEntity(&entity)
+	e.AddEntity(loose)
+	e.RemoveEntity(loose)
+	e.AddEntity(loose)
+	assert.Equal(t, 0, e.GC().Reclaimed())
+	assert.True(t, tinyecs.Has[floater](e, loose))
+}
@@ -0,0 +1,284 @@
+package tinyecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// GLTFNode is the entity created for glTF nodes whose extras do not name an entity type.
+type GLTFNode struct {
+	Entity
+
+	Name string
+}
+
+// MeshRef is a component referencing the mesh of a glTF node, by its index in the file and its name.
+type MeshRef struct {
+	Index int
+	Name  string
+}
+
+// gltfFile is the subset of a glTF 2.0 file read by ImportGLTF.
+type gltfFile struct {
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes  []gltfNode `json:"nodes"`
+	Meshes []struct {
+		Name string `json:"name"`
+	} `json:"meshes"`
+}
+
+// gltfNode is a node of a glTF file.
+type gltfNode struct {
+	Name        string        `json:"name"`
+	Children    []int         `json:"children"`
+	Mesh        *int          `json:"mesh"`
+	Matrix      []float64     `json:"matrix"`
+	Translation []float64     `json:"translation"`
+	Rotation    []float64     `json:"rotation"`
+	Scale       []float64     `json:"scale"`
+	Extras      gltfNodeExtra `json:"extras"`
+}
+
+// gltfNodeExtra holds the extras of a node understood by ImportGLTF.
+type gltfNodeExtra struct {
+	Type       string                     `json:"type"`
+	Value      json.RawMessage            `json:"value"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// LoadGLTF reads the glTF file (.gltf or .glb) at the path and spawns the entities of its nodes, as ImportGLTF does.
+func (e *Engine) LoadGLTF(path string, s *Serializer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ImportGLTF(data, e); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// ImportGLTF spawns an entity for every node of the default scene of a glTF 2.0 file, given as JSON or as binary
+// glTF, and returns the entities in depth-first order. Each entity gets a Transform holding the node's translation,
+// rotation about the Z axis and scale projected onto the XY plane, a MeshRef if the node has a mesh, a Name if the node
+// is named, and a Parent for child nodes, so that nodes can be found using FindPath. The node extras can name the
+// types, registered on the serializer or engine, of the entity and of additional components, which are decoded from
+// JSON:
+//
+//	"extras": {
+//		"type": "enemy",
+//		"value": {"name": "Goblin"},
+//		"components": {"health": {"hp": 10}}
+//	}
+//
+// Nodes without an entity type are GLTFNode entities. Nothing is added if any node fails to decode.
+func (s *Serializer) ImportGLTF(data []byte, engine *Engine) ([]any, error) {
+	data, err := gltfJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var file gltfFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing glTF: %w", err)
+	}
+
+	roots, err := file.roots()
+	if err != nil {
+		return nil, err
+	}
+
+	var spawns []sceneSpawn
+	visited := make(map[int]bool)
+	var visit func(i int, parent ecsEntity) error
+	visit = func(i int, parent ecsEntity) error {
+		if i < 0 || i >= len(file.Nodes) {
+			return fmt.Errorf("node %d does not exist", i)
+		}
+		if visited[i] {
+			return fmt.Errorf("node %d has more than one parent", i)
+		}
+		visited[i] = true
+
+		sp, err := s.decodeGLTFNode(engine, &file, i)
+		if err != nil {
+			return fmt.Errorf("node %d: %w", i, err)
+		}
+		if parent != nil {
+			sp.components = append(sp.components, Parent{Entity: parent})
+		}
+		spawns = append(spawns, sp)
+
+		for _, child := range file.Nodes[i].Children {
+			if err := visit(child, sp.entity); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := visit(root, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	entities := make([]any, len(spawns))
+	for i, entity := range addSpawns(spawns, engine) {
+		entities[i] = entity
+	}
+	return entities, nil
+}
+
+// gltfJSON returns the JSON of a glTF file, extracting it from the first chunk of binary glTF.
+func gltfJSON(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte("glTF")) {
+		return data, nil
+	}
+
+	// The 12 byte header is followed by the JSON chunk: its length, its type and its data.
+	if len(data) < 20 {
+		return nil, errors.New("binary glTF is truncated")
+	}
+	length := binary.LittleEndian.Uint32(data[12:16])
+	if binary.LittleEndian.Uint32(data[16:20]) != 0x4e4f534a {
+		return nil, errors.New("binary glTF does not start with a JSON chunk")
+	}
+	if uint64(len(data)) < 20+uint64(length) {
+		return nil, errors.New("binary glTF is truncated")
+	}
+	return data[20 : 20+length], nil
+}
+
+// roots returns the root nodes of the default scene, or of the first scene if there is no default.
+// When the file has no scenes, every node which is not a child is a root.
+func (f *gltfFile) roots() ([]int, error) {
+	if len(f.Scenes) == 0 {
+		children := make(map[int]bool)
+		for _, node := range f.Nodes {
+			for _, child := range node.Children {
+				children[child] = true
+			}
+		}
+
+		var roots []int
+		for i := range f.Nodes {
+			if !children[i] {
+				roots = append(roots, i)
+			}
+		}
+		return roots, nil
+	}
+
+	scene := 0
+	if f.Scene != nil {
+		scene = *f.Scene
+	}
+	if scene < 0 || scene >= len(f.Scenes) {
+		return nil, fmt.Errorf("scene %d does not exist", scene)
+	}
+	return f.Scenes[scene].Nodes, nil
+}
+
+// decodeGLTFNode decodes the entity and components of the node with the index.
+func (s *Serializer) decodeGLTFNode(engine *Engine, file *gltfFile, i int) (sceneSpawn, error) {
+	node := file.Nodes[i]
+
+	var entity any = &GLTFNode{Name: node.Name}
+	if node.Extras.Type != "" {
+		v, err := s.decodeJSON(engine, node.Extras.Type, node.Extras.Value)
+		if err != nil {
+			return sceneSpawn{}, err
+		}
+		entity = v
+	}
+	ecsEnt, ok := entity.(ecsEntity)
+	if !ok {
+		return sceneSpawn{}, fmt.Errorf("type %q does not embed tinyecs.Entity", node.Extras.Type)
+	}
+
+	sp := sceneSpawn{entity: ecsEnt, components: []any{node.transform()}}
+	if node.Mesh != nil {
+		if *node.Mesh < 0 || *node.Mesh >= len(file.Meshes) {
+			return sceneSpawn{}, fmt.Errorf("mesh %d does not exist", *node.Mesh)
+		}
+		sp.components = append(sp.components, MeshRef{Index: *node.Mesh, Name: file.Meshes[*node.Mesh].Name})
+	}
+	if node.Name != "" {
+		sp.components = append(sp.components, Name(node.Name))
+	}
+
+	names := make([]string, 0, len(node.Extras.Components))
+	for name := range node.Extras.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c, err := s.decodeJSON(engine, name, node.Extras.Components[name])
+		if err != nil {
+			return sceneSpawn{}, err
+		}
+		sp.components = append(sp.components, c)
+	}
+	return sp, nil
+}
+
+// transform returns the node's transform projected onto the XY plane.
+func (n gltfNode) transform() Transform {
+	if len(n.Matrix) == 16 {
+		// The matrix is column-major, so the first two columns hold the rotated and scaled X and Y axes.
+		m := n.Matrix
+		return Transform{
+			X:        m[12],
+			Y:        m[13],
+			Rotation: math.Atan2(m[1], m[0]),
+			ScaleX:   math.Hypot(m[0], m[1]),
+			ScaleY:   math.Hypot(m[4], m[5]),
+		}
+	}
+
+	t := Transform{ScaleX: 1, ScaleY: 1}
+	if len(n.Translation) == 3 {
+		t.X, t.Y = n.Translation[0], n.Translation[1]
+	}
+	if len(n.Rotation) == 4 {
+		x, y, z, w := n.Rotation[0], n.Rotation[1], n.Rotation[2], n.Rotation[3]
+		t.Rotation = math.Atan2(2*(w*z+x*y), 1-2*(y*y+z*z))
+	}
+	if len(n.Scale) == 3 {
+		t.ScaleX, t.ScaleY = n.Scale[0], n.Scale[1]
+	}
+	return t
+}
+
+// decodeJSON decodes the JSON value as the type registered under the name.
+// Pointer types are allocated even when the value is empty, so that every entity gets its own identity.
+func (s *Serializer) decodeJSON(engine *Engine, name string, data json.RawMessage) (any, error) {
+	t, err := s.typeOf(engine, name)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.New(t)
+	target := v
+	if t.Kind() == reflect.Pointer {
+		v.Elem().Set(reflect.New(t.Elem()))
+		target = v.Elem()
+	}
+
+	if len(data) > 0 {
+		if err := JSONCodec.Unmarshal(data, target.Interface()); err != nil {
+			return nil, fmt.Errorf("decoding %q: %w", name, err)
+		}
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,96 @@
+package tinyecs_test
+
+import (
+	"encoding/binary"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGLTF = `{
+	"asset": {"version": "2.0"},
+	"scene": 0,
+	"scenes": [{"nodes": [0, 3]}],
+	"nodes": [
+		{"name": "level", "children": [1, 2]},
+		{"name": "hero", "translation": [1, 2, 3], "rotation": [0, 0, 0.7071068, 0.7071068], "mesh": 0,
+		 "extras": {"type": "entity", "value": {"name": "Hero"}, "components": {"position": {"x": 5}}}},
+		{"name": "tree", "matrix": [2, 0, 0, 0, 0, 3, 0, 0, 0, 0, 1, 0, 7, 8, 0, 1]},
+		{"name": "light"},
+		{"name": "unused"}
+	],
+	"meshes": [{"name": "hero_mesh"}]
+}`
+
+func Test_ImportGLTF(t *testing.T) {
+	e := tinyecs.NewEngine()
+	entities, err := newTestSerializer(nil).ImportGLTF([]byte(testGLTF), e)
+	assert.NoError(t, err)
+	assert.Len(t, entities, 4)
+
+	level := entities[0].(*tinyecs.GLTFNode)
+	hero := entities[1].(*savedEntity)
+	tree := entities[2].(*tinyecs.GLTFNode)
+	assert.Equal(t, "level", level.Name)
+	assert.Equal(t, "Hero", hero.Name)
+	assert.Equal(t, "light", entities[3].(*tinyecs.GLTFNode).Name)
+
+	transform, _, _ := tinyecs.Get[tinyecs.Transform](e, hero)
+	assert.Equal(t, 1.0, transform.X)
+	assert.Equal(t, 2.0, transform.Y)
+	assert.InDelta(t, math.Pi/2, transform.Rotation, 1e-6)
+	assert.Equal(t, 1.0, transform.ScaleX)
+
+	transform, _, _ = tinyecs.Get[tinyecs.Transform](e, tree)
+	assert.Equal(t, tinyecs.Transform{X: 7, Y: 8, ScaleX: 2, ScaleY: 3}, transform)
+
+	mesh, _, _ := tinyecs.Get[tinyecs.MeshRef](e, hero)
+	assert.Equal(t, tinyecs.MeshRef{Index: 0, Name: "hero_mesh"}, mesh)
+	pos, _, _ := tinyecs.Get[position](e, hero)
+	assert.Equal(t, position{X: 5}, pos)
+
+	parent, _, _ := tinyecs.Get[tinyecs.Parent](e, tree)
+	assert.Same(t, level, parent.Entity)
+	assert.False(t, tinyecs.Has[tinyecs.Parent](e, level))
+
+	found, ok := e.FindPath("level/hero")
+	assert.True(t, ok)
+	assert.Same(t, hero, found)
+}
+
+func Test_LoadGLTFBinary(t *testing.T) {
+	json := []byte(`{"nodes": [{"name": "a", "children": [1]}, {"name": "b"}]}`)
+	for len(json)%4 != 0 {
+		json = append(json, ' ')
+	}
+
+	glb := make([]byte, 20, 20+len(json))
+	copy(glb, "glTF")
+	binary.LittleEndian.PutUint32(glb[4:], 2)
+	binary.LittleEndian.PutUint32(glb[8:], uint32(20+len(json)))
+	binary.LittleEndian.PutUint32(glb[12:], uint32(len(json)))
+	binary.LittleEndian.PutUint32(glb[16:], 0x4e4f534a)
+	glb = append(glb, json...)
+
+	path := filepath.Join(t.TempDir(), "scene.glb")
+	assert.NoError(t, os.WriteFile(path, glb, 0o644))
+
+	e := tinyecs.NewEngine()
+	assert.NoError(t, e.LoadGLTF(path, newTestSerializer(nil)))
+	assert.Len(t, e.GetEntities(), 2)
+	assert.True(t, tinyecs.Has[tinyecs.Parent](e, e.GetEntities()[1]))
+}
+
+func Test_ImportGLTFErrors(t *testing.T) {
+	e := tinyecs.NewEngine()
+	s := newTestSerializer(nil)
+
+	_, err := s.ImportGLTF([]byte(`{"nodes": [{"extras": {"components": {"velocity": {}}}}]}`), e)
+	assert.ErrorContains(t, err, "node 0")
+	_, err = s.ImportGLTF([]byte(`{"scenes": [{"nodes": [0]}], "nodes": [{"children": [0]}]}`), e)
+	assert.ErrorContains(t, err, "more than one parent")
+	assert.Empty(t, e.GetEntities())
+}
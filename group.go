@@ -0,0 +1,134 @@
+package tinyecs
+
+// entityGroup is an indexed set of entities, which keeps the order they were added in until one is removed.
+type entityGroup struct {
+	members []any
+	index   map[any]int
+}
+
+// AddToGroup adds the entity to the named group, creating the group if needed. Adding a member again does nothing.
+// Groups are independent of components, so they suit dynamic collections such as the enemies targeting a player,
+// without declaring a marker component type for each of them.
+// When called during a query such as EachInGroup, the entity is added once the query has finished.
+//
+//	e.AddToGroup(goblin, "enemies")
+//	e.EachInGroup("enemies", func(entity any) {
+//		// Chase the player.
+//	})
+func (e *Engine) AddToGroup(entity any, group string) {
+	checkEntity(entity, "AddToGroup")
+	if e.deferIfIterating(func() { e.AddToGroup(entity, group) }) {
+		return
+	}
+
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	g, ok := e.groups[group]
+	if !ok {
+		g = &entityGroup{index: make(map[any]int)}
+		e.groups[group] = g
+	}
+	if _, ok := g.index[entity]; ok {
+		return
+	}
+	g.index[entity] = len(g.members)
+	g.members = append(g.members, entity)
+}
+
+// RemoveFromGroup removes the entity from the named group, which is deleted once it is empty.
+// When called during a query such as EachInGroup, the entity is removed once the query has finished.
+func (e *Engine) RemoveFromGroup(entity any, group string) {
+	if !comparableEntity(entity) {
+		return
+	}
+	if e.deferIfIterating(func() { e.RemoveFromGroup(entity, group) }) {
+		return
+	}
+
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	e.removeFromGroup(entity, group)
+}
+
+// removeFromGroup removes the entity from the group by moving the last member into its place.
+// The caller must hold componentMtx.
+func (e *Engine) removeFromGroup(entity any, group string) {
+	g, ok := e.groups[group]
+	if !ok {
+		return
+	}
+	i, ok := g.index[entity]
+	if !ok {
+		return
+	}
+
+	last := g.members[len(g.members)-1]
+	g.members[i] = last
+	g.index[last] = i
+	g.members[len(g.members)-1] = nil
+	g.members = g.members[:len(g.members)-1]
+	delete(g.index, entity)
+
+	if len(g.members) == 0 {
+		delete(e.groups, group)
+	}
+}
+
+// removeFromGroups removes the entity from every group.
+func (e *Engine) removeFromGroups(entity any) {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	for name := range e.groups {
+		e.removeFromGroup(entity, name)
+	}
+}
+
+// InGroup returns whether the entity is in the named group.
+func (e *Engine) InGroup(entity any, group string) bool {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	g, ok := e.groups[group]
+	if !ok || !comparableEntity(entity) {
+		return false
+	}
+	_, ok = g.index[entity]
+	return ok
+}
+
+// GroupLen returns the number of entities in the named group.
+func (e *Engine) GroupLen(group string) int {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	if g, ok := e.groups[group]; ok {
+		return len(g.members)
+	}
+	return 0
+}
+
+// EachInGroup calls f with every entity in the named group, and returns the number of entities visited.
+// Structural changes made during the call, including changes to groups, are deferred, as with the other queries.
+func (e *Engine) EachInGroup(group string, f func(entity any)) uint64 {
+	e.beginIteration()
+	defer e.endIteration()
+
+	g, ok := e.groups[group]
+	if !ok {
+		return 0
+	}
+
+	var counter uint64
+	for _, entity := range g.members {
+		if e.hidden(entity) {
+			continue
+		}
+		counter++
+		f(entity)
+	}
+	e.touch(counter)
+	return counter
+}
@@ -0,0 +1,45 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEngine_Groups(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	a, b, c := &testEntity{name: "a"}, &testEntity{name: "b"}, &testEntity{name: "c"}
+	for _, entity := range []*testEntity{a, b, c} {
+		e.AddEntity(entity)
+		e.AddToGroup(entity, "enemies")
+	}
+	e.AddToGroup(a, "enemies")
+	e.AddToGroup(a, "targets")
+
+	names := func(group string) []string {
+		var names []string
+		e.EachInGroup(group, func(entity any) {
+			names = append(names, entity.(*testEntity).name)
+		})
+		return names
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names("enemies"))
+	assert.Equal(t, 3, e.GroupLen("enemies"))
+	assert.True(t, e.InGroup(a, "targets"))
+
+	e.RemoveFromGroup(a, "enemies")
+	assert.Equal(t, []string{"c", "b"}, names("enemies"))
+	assert.False(t, e.InGroup(a, "enemies"))
+
+	// Membership changes during iteration are deferred.
+	e.EachInGroup("enemies", func(entity any) {
+		e.RemoveFromGroup(entity, "enemies")
+		assert.Equal(t, 2, e.GroupLen("enemies"))
+	})
+	assert.Zero(t, e.GroupLen("enemies"))
+
+	e.RemoveEntity(a)
+	assert.False(t, e.InGroup(a, "targets"))
+	assert.Zero(t, e.EachInGroup("targets", func(any) {}))
+}
@@ -0,0 +1,56 @@
+package tinyecs
+
+import "reflect"
+
+// beginIteration marks the start of a query over the engine.
+// Until the matching endIteration, structural changes to the engine are deferred, since applying them would
+// mutate the storage which is being iterated.
+func (e *Engine) beginIteration() {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	e.iterating++
+}
+
+// endIteration marks the end of a query. When the outermost query ends, all deferred changes are applied in order.
+func (e *Engine) endIteration() {
+	e.componentMtx.Lock()
+	e.iterating--
+
+	var pending []func()
+	if e.iterating == 0 {
+		pending = e.pending
+		e.pending = nil
+	}
+	e.componentMtx.Unlock()
+
+	for _, op := range pending {
+		op()
+	}
+}
+
+// deferIfIterating queues op to run once all queries have finished, and returns whether it did so.
+// When no query is running it returns false, and the caller should apply the change right away.
+func (e *Engine) deferIfIterating(op func()) bool {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	return e.deferLocked(op)
+}
+
+// deferLocked is deferIfIterating for callers which already hold componentMtx.
+func (e *Engine) deferLocked(op func()) bool {
+	if e.iterating == 0 {
+		return false
+	}
+
+	e.pending = append(e.pending, op)
+	return true
+}
+
+// changesType returns whether replacing the component with the id by component would change its type,
+// which moves it between storages. The caller must hold componentMtx.
+func (e *Engine) changesType(id uint64, component any) bool {
+	link, ok := e.links[id]
+	return ok && e.componentType(reflect.TypeOf(component)) != link.componentType
+}
@@ -0,0 +1,96 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DeleteDuringEachIsDeferred(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	for i := 0; i < 10; i++ {
+		e.AddComponents(entity, floater{f: float64(i)})
+	}
+
+	// Every component must be visited exactly once, even though each is deleted as it is visited.
+	seen := make(map[float64]int)
+	c := tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		seen[obj.f]++
+		e.DeleteComponent(obj)
+
+		assert.Len(t, e.GetComponents(), 10)
+	})
+
+	assert.Equal(t, uint64(10), c)
+	assert.Len(t, seen, 10)
+	for _, n := range seen {
+		assert.Equal(t, 1, n)
+	}
+	assert.Len(t, e.GetComponents(), 0)
+	assert.False(t, tinyecs.Has[floater](e, entity))
+}
+
+func Test_DeleteComponentIDDuringEachIsDeferred(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	e.AddComponents(entity, floater{f: 1}, floater{f: 2}, velocity{v: 3})
+
+	c := tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		e.DeleteComponentID(id)
+		assert.Len(t, e.GetComponents(), 3)
+	})
+
+	assert.Equal(t, uint64(2), c)
+	assert.False(t, tinyecs.Has[floater](e, entity))
+	assert.True(t, tinyecs.Has[velocity](e, entity))
+}
+
+func Test_AddDuringEachIsDeferred(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	e.AddComponents(entity, floater{f: 1}, floater{f: 2})
+
+	c := tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		e.AddComponents(entity, floater{f: obj.f * 10})
+
+		// Nested queries see the world as it was when the outer query started.
+		assert.Equal(t, uint64(2), tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {}))
+	})
+	assert.Equal(t, uint64(2), c)
+
+	var values []float64
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		values = append(values, obj.f)
+	})
+	assert.ElementsMatch(t, []float64{1, 2, 10, 20}, values)
+}
+
+func Test_SetChangingTypeDuringEachIsDeferred(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	e.AddComponents(entity, floater{f: 1}, floater{f: 2})
+
+	tinyecs.EachEntity[*testEntity, floater](e, func(entity *testEntity, component floater) {
+		tinyecs.Each[floater](e, func(id uint64, obj floater) {
+			// Same type, applied right away.
+			tinyecs.Set(e, id, floater{f: obj.f + 1})
+		})
+	})
+
+	var values []float64
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		values = append(values, obj.f)
+		tinyecs.Set(e, id, velocity{v: obj.f})
+
+		assert.False(t, tinyecs.Has[velocity](e, entity))
+	})
+	assert.ElementsMatch(t, []float64{3, 4}, values)
+
+	assert.Equal(t, uint64(0), tinyecs.Each[floater](e, func(uint64, floater) {}))
+	assert.Equal(t, uint64(2), tinyecs.Each[velocity](e, func(uint64, velocity) {}))
+}
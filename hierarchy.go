@@ -0,0 +1,171 @@
+package tinyecs
+
+import (
+	"sort"
+	"strings"
+)
+
+// Parent is a component linking an entity to its parent in a hierarchy.
+type Parent struct {
+	Entity any
+}
+
+// Children returns the entities whose Parent component links them to the entity.
+func (e *Engine) Children(entity any) []any {
+	if !comparableEntity(entity) {
+		return nil
+	}
+	return e.childrenByParent()[entity]
+}
+
+// DespawnRecursive removes the entity and all of its descendants in the hierarchy formed by Parent components from
+// the engine, along with all of their components. Descendants are despawned before their parents, so the
+// EntityDespawned events of children are emitted before those of their parents.
+// When called during a query, the entities are despawned once the query has finished.
+//
+//	e.DespawnRecursive(ship) // Also despawns the ship's turrets, and their muzzle flashes.
+func (e *Engine) DespawnRecursive(entity ecsEntity) {
+	checkEntity(entity, "DespawnRecursive")
+	if e.deferIfIterating(func() { e.DespawnRecursive(entity) }) {
+		return
+	}
+
+	children := e.childrenByParent()
+	visited := make(map[any]bool)
+
+	var despawn func(entity any)
+	despawn = func(entity any) {
+		// Guard against cycles in malformed hierarchies.
+		if visited[entity] {
+			return
+		}
+		visited[entity] = true
+
+		for _, child := range children[entity] {
+			despawn(child)
+		}
+		if ent, ok := entity.(ecsEntity); ok {
+			e.despawn(ent)
+		}
+	}
+	despawn(entity)
+}
+
+// childrenByParent returns the children of every entity which has any.
+func (e *Engine) childrenByParent() map[any][]any {
+	children := make(map[any][]any)
+	EachWithEntity(e, func(child any, _ uint64, p Parent) {
+		children[p.Entity] = append(children[p.Entity], child)
+	})
+	return children
+}
+
+// Name is a component naming an entity. Together with Parent components, names give entities paths such as
+// "player/weapon/muzzle", which FindPath looks up.
+type Name string
+
+// FindPath returns the entity at the path, a slash-separated list of names leading from a root entity, which has no
+// Parent, down to the entity. Every entity along the path must have a Name. When several entities share a path, the
+// one whose Name was added first is returned.
+//
+//	muzzle, ok := e.FindPath("player/weapon/muzzle")
+//
+// The paths are indexed on the first call, and the index is rebuilt after Name or Parent components change.
+func (e *Engine) FindPath(path string) (any, bool) {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if e.paths == nil {
+		e.indexPaths()
+	}
+	entity, ok := e.paths[path]
+	return entity, ok
+}
+
+// PathOf returns the path of the entity, or false if the entity or one of its ancestors has no Name.
+func (e *Engine) PathOf(entity any) (string, bool) {
+	if !comparableEntity(entity) {
+		return "", false
+	}
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	return e.namesAndParents().pathOf(entity)
+}
+
+// invalidatePaths drops the path index if the type bit is that of Name or Parent. The caller must hold componentMtx.
+func (e *Engine) invalidatePaths(bit int) {
+	if e.paths != nil && (bit == e.pathBits[0] || bit == e.pathBits[1]) {
+		e.paths = nil
+	}
+}
+
+// indexPaths builds the path index. The caller must hold componentMtx for writing.
+func (e *Engine) indexPaths() {
+	e.pathBits = [2]int{typeBit[Name](e), typeBit[Parent](e)}
+	h := e.namesAndParents()
+
+	entities := make([]any, 0, len(h.names))
+	for entity := range h.names {
+		entities = append(entities, entity)
+	}
+	sort.Slice(entities, func(i, j int) bool { return h.nameIDs[entities[i]] < h.nameIDs[entities[j]] })
+
+	e.paths = make(map[string]any, len(entities))
+	for _, entity := range entities {
+		if path, ok := h.pathOf(entity); ok {
+			if _, taken := e.paths[path]; !taken {
+				e.paths[path] = entity
+			}
+		}
+	}
+}
+
+// hierarchy holds the first Name and Parent of every entity, along with the ids of the Name components.
+type hierarchy struct {
+	names   map[any]Name
+	nameIDs map[any]uint64
+	parents map[any]any
+}
+
+// namesAndParents collects the hierarchy of the engine. The caller must hold componentMtx.
+func (e *Engine) namesAndParents() hierarchy {
+	h := hierarchy{names: make(map[any]Name), nameIDs: make(map[any]uint64), parents: make(map[any]any)}
+	for id, link := range e.links {
+		switch c := e.components[id].(type) {
+		case Name:
+			if first, ok := h.nameIDs[link.entity]; !ok || id < first {
+				h.names[link.entity] = c
+				h.nameIDs[link.entity] = id
+			}
+		case Parent:
+			h.parents[link.entity] = c.Entity
+		}
+	}
+	return h
+}
+
+// pathOf returns the path of the entity within the hierarchy.
+func (h hierarchy) pathOf(entity any) (string, bool) {
+	var names []string
+	visited := make(map[any]bool)
+	for {
+		name, ok := h.names[entity]
+		if !ok || visited[entity] {
+			return "", false
+		}
+		visited[entity] = true
+		names = append(names, string(name))
+
+		parent, ok := h.parents[entity]
+		if !ok {
+			break
+		}
+		entity = parent
+	}
+
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/"), true
+}
@@ -0,0 +1,106 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DespawnRecursive(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	spawn := func(name string, parent any) *testEntity {
+		entity := &testEntity{name: name}
+		e.AddComponents(entity, velocity{})
+		if parent != nil {
+			e.AddComponents(entity, tinyecs.Parent{Entity: parent})
+		}
+		e.AddEntity(entity)
+		return entity
+	}
+	ship := spawn("ship", nil)
+	turret := spawn("turret", ship)
+	spawn("flash", turret)
+	hull := spawn("hull", ship)
+	other := spawn("other", nil)
+	e.EndFrame()
+
+	assert.ElementsMatch(t, []any{turret, hull}, e.Children(ship))
+
+	tinyecs.Each(e, func(id uint64, v velocity) {
+		e.DespawnRecursive(ship)
+	})
+	e.EndFrame()
+
+	var despawned []string
+	tinyecs.ReadEvents(e, func(d tinyecs.EntityDespawned) {
+		despawned = append(despawned, d.Entity.(*testEntity).name)
+	})
+	assert.Len(t, despawned, 4)
+	assert.Equal(t, "ship", despawned[3])
+	assert.Less(t, indexOf(despawned, "flash"), indexOf(despawned, "turret"))
+
+	assert.Equal(t, []any{other}, entitiesOf(e))
+	assert.Len(t, e.GetComponents(), 1)
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func entitiesOf(e *tinyecs.Engine) []any {
+	var entities []any
+	for _, entity := range e.GetEntities() {
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+func Test_FindPath(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	spawn := func(name string, parent any) *testEntity {
+		entity := &testEntity{name: name}
+		e.AddComponents(entity, tinyecs.Name(name))
+		if parent != nil {
+			e.AddComponents(entity, tinyecs.Parent{Entity: parent})
+		}
+		return entity
+	}
+	player := spawn("player", nil)
+	weapon := spawn("weapon", player)
+	muzzle := spawn("muzzle", weapon)
+	spawn("muzzle", weapon)
+
+	found, ok := e.FindPath("player/weapon/muzzle")
+	assert.True(t, ok)
+	assert.Same(t, muzzle, found)
+	_, ok = e.FindPath("weapon")
+	assert.False(t, ok)
+
+	path, ok := e.PathOf(muzzle)
+	assert.True(t, ok)
+	assert.Equal(t, "player/weapon/muzzle", path)
+
+	// Renaming and reparenting update the index.
+	_, id, _ := tinyecs.Get[tinyecs.Name](e, weapon)
+	tinyecs.Set(e, id, tinyecs.Name("gun"))
+	_, ok = e.FindPath("player/weapon/muzzle")
+	assert.False(t, ok)
+	found, _ = e.FindPath("player/gun/muzzle")
+	assert.Same(t, muzzle, found)
+
+	_, id, _ = tinyecs.Get[tinyecs.Parent](e, muzzle)
+	tinyecs.Set(e, id, tinyecs.Parent{Entity: player})
+	found, _ = e.FindPath("player/muzzle")
+	assert.Same(t, muzzle, found)
+
+	e.DespawnRecursive(player)
+	_, ok = e.FindPath("player")
+	assert.False(t, ok)
+}
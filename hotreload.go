@@ -0,0 +1,102 @@
+package tinyecs
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// SceneWatcher spawns a scene and respawns it whenever the scene file, or any scene it includes, changes.
+// It is meant for development, so that level layouts and prefabs can be edited while the game is running.
+//
+//	w, err := e.WatchScene("levels/one.yaml", s)
+//	// ...
+//	e.AddSystem(tinyecs.StagePreUpdate, "scene reload", w.System(time.Second))
+type SceneWatcher struct {
+	// OnError is called by the system returned by System when reloading fails. The previous entities are kept.
+	OnError func(err error)
+
+	engine     *Engine
+	serializer *Serializer
+	path       string
+
+	// modified holds the modification time of every file making up the scene.
+	modified map[string]time.Time
+	// entities holds the entities spawned from the scene.
+	entities []ecsEntity
+}
+
+// WatchScene loads the scene file at the path like LoadScene, and returns a SceneWatcher which reloads it.
+func (e *Engine) WatchScene(path string, s *Serializer) (*SceneWatcher, error) {
+	w := &SceneWatcher{engine: e, serializer: s, path: path}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Reload despawns the entities spawned from the scene along with their components, and spawns the scene again.
+// If the scene fails to load, the previous entities are kept.
+func (w *SceneWatcher) Reload() error {
+	var r sceneReader
+	scene, err := r.read(w.path, nil)
+	w.modified = modTimes(r.files)
+	if err != nil {
+		return err
+	}
+
+	// Decode the whole scene first, so that a scene which fails to decode leaves the running one untouched.
+	spawns, err := w.serializer.decodeScene(scene, w.engine)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	for _, entity := range w.entities {
+		w.engine.despawn(entity)
+	}
+	w.entities = addSpawns(spawns, w.engine)
+	return nil
+}
+
+// Poll reloads the scene if any of its files changed since it was last loaded, and reports whether it did.
+func (w *SceneWatcher) Poll() (bool, error) {
+	for path, t := range w.modified {
+		if !modTime(path).Equal(t) {
+			return true, w.Reload()
+		}
+	}
+	return false, nil
+}
+
+// System returns a system which polls the scene files at most once per interval.
+func (w *SceneWatcher) System(interval time.Duration) System {
+	var last time.Time
+	return func(engine *Engine) {
+		if time.Since(last) < interval {
+			return
+		}
+		last = time.Now()
+
+		if _, err := w.Poll(); err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	}
+}
+
+// modTimes returns the modification time of every file.
+func modTimes(files []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(files))
+	for _, path := range files {
+		times[path] = modTime(path)
+	}
+	return times
+}
+
+// modTime returns the modification time of the file, or the zero time if it can not be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,79 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_SceneWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	prefabs := filepath.Join(dir, "prefabs.yaml")
+	level := filepath.Join(dir, "level.yaml")
+	assert.NoError(t, os.WriteFile(prefabs, []byte(`
+prefabs:
+  crate: {type: entity, value: {name: Crate}, components: [position: {x: 1}]}
+`), 0o644))
+	assert.NoError(t, os.WriteFile(level, []byte(`
+include: [prefabs.yaml]
+entities:
+  - prefab: crate
+`), 0o644))
+
+	e := tinyecs.NewEngine()
+	other := &savedEntity{Name: "not from the scene"}
+	e.AddComponents(other, position{X: 100})
+	e.AddEntity(other)
+
+	w, err := e.WatchScene(level, newTestSerializer(nil))
+	assert.NoError(t, err)
+	assert.Len(t, e.GetEntities(), 2)
+
+	reloaded, err := w.Poll()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// Change an included file.
+	assert.NoError(t, os.WriteFile(prefabs, []byte(`
+prefabs:
+  crate: {type: entity, value: {name: Crate}, components: [position: {x: 2}]}
+`), 0o644))
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(prefabs, later, later))
+
+	reloaded, err = w.Poll()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+
+	assert.Len(t, e.GetEntities(), 2)
+	assert.Len(t, e.GetComponents(), 2)
+	var xs []float64
+	tinyecs.Each(e, func(id uint64, p position) { xs = append(xs, p.X) })
+	assert.ElementsMatch(t, []float64{100, 2}, xs)
+}
+
+func Test_SceneWatcherKeepsEntitiesOnError(t *testing.T) {
+	path := writeScene(t, `entities: [{type: entity, value: {name: Crate}}]`)
+
+	e := tinyecs.NewEngine()
+	w, err := e.WatchScene(path, newTestSerializer(nil))
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`entities: [{type: unknown}]`), 0o644))
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(path, later, later))
+
+	var errs []error
+	w.OnError = func(err error) { errs = append(errs, err) }
+	e.AddSystem(tinyecs.StagePreUpdate, "scene reload", w.System(0))
+	e.Update(0)
+	e.Update(0)
+
+	// The error is only reported once, until the file changes again.
+	assert.Len(t, errs, 1)
+	assert.Len(t, e.GetEntities(), 1)
+	assert.Equal(t, "Crate", e.GetEntities()[0].(*savedEntity).Name)
+}
@@ -0,0 +1,72 @@
+package tinyecs
+
+// Key identifies a key or button, using the key codes of the input library, such as an sdl.Keycode.
+type Key int32
+
+// Input is the state of the keyboard and mouse, fed by the event pump of a Loop and read by systems through
+// Engine.Input. Presses and releases are kept until the end of the frame, so every system sees them.
+type Input struct {
+	// MouseX and MouseY are the position of the mouse.
+	MouseX, MouseY float64
+
+	down     map[Key]bool
+	pressed  map[Key]bool
+	released map[Key]bool
+}
+
+// Press records that the key was pressed.
+func (in *Input) Press(key Key) {
+	in.init()
+	if !in.down[key] {
+		in.pressed[key] = true
+	}
+	in.down[key] = true
+}
+
+// Release records that the key was released.
+func (in *Input) Release(key Key) {
+	in.init()
+	if in.down[key] {
+		in.released[key] = true
+	}
+	delete(in.down, key)
+}
+
+// Down returns whether the key is held down.
+func (in *Input) Down(key Key) bool {
+	return in.down[key]
+}
+
+// Pressed returns whether the key was pressed during the current frame.
+func (in *Input) Pressed(key Key) bool {
+	return in.pressed[key]
+}
+
+// Released returns whether the key was released during the current frame.
+func (in *Input) Released(key Key) bool {
+	return in.released[key]
+}
+
+// init allocates the maps of the input.
+func (in *Input) init() {
+	if in.down == nil {
+		in.down = make(map[Key]bool)
+		in.pressed = make(map[Key]bool)
+		in.released = make(map[Key]bool)
+	}
+}
+
+// endFrame forgets the presses and releases of the frame.
+func (in *Input) endFrame() {
+	for key := range in.pressed {
+		delete(in.pressed, key)
+	}
+	for key := range in.released {
+		delete(in.released, key)
+	}
+}
+
+// Input returns the input state of the engine.
+func (e *Engine) Input() *Input {
+	return &e.input
+}
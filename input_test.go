@@ -0,0 +1,46 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const keySpace tinyecs.Key = ' '
+
+func TestLoop_Input(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	// Each frame's events, as the event pump would deliver them.
+	events := []func(in *tinyecs.Input){
+		func(in *tinyecs.Input) { in.Press(keySpace) },
+		func(in *tinyecs.Input) {},
+		func(in *tinyecs.Input) { in.Release(keySpace) },
+	}
+	type state struct{ down, pressed, released bool }
+	var states []state
+	e.AddSystem(tinyecs.StageUpdate, "input", func(e *tinyecs.Engine) {
+		in := e.Input()
+		states = append(states, state{in.Down(keySpace), in.Pressed(keySpace), in.Released(keySpace)})
+	})
+
+	var presented int
+	start := time.Now()
+	tinyecs.Loop{
+		FPS: 100,
+		Poll: func(in *tinyecs.Input) bool {
+			if len(events) == 0 {
+				return false
+			}
+			events[0](in)
+			events = events[1:]
+			return true
+		},
+		Present: func() { presented++ },
+	}.Run(e)
+
+	assert.Equal(t, []state{{true, true, false}, {true, false, false}, {false, false, true}}, states)
+	assert.Equal(t, 3, presented)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
@@ -0,0 +1,60 @@
+package tinyecs
+
+// Layer is a component holding the set of layers an entity is on, one bit per layer.
+// Entities without a Layer component are on DefaultLayer.
+//
+//	const (
+//		LayerWorld = tinyecs.DefaultLayer
+//		LayerUI    = tinyecs.Layer(1 << 1)
+//		LayerGhost = tinyecs.Layer(1 << 2)
+//	)
+//	e.AddComponents(button, LayerUI)
+type Layer uint64
+
+// DefaultLayer is the layer of entities without a Layer component.
+const DefaultLayer Layer = 1
+
+// AllLayers contains every layer.
+const AllLayers Layer = ^Layer(0)
+
+// Layers returns the layer set containing the numbered layers, from 0 to 63.
+func Layers(layers ...int) Layer {
+	var l Layer
+	for _, n := range layers {
+		if n < 0 || n >= 64 {
+			panic("tinyecs: layer out of range")
+		}
+		l |= 1 << n
+	}
+	return l
+}
+
+// Matches returns whether the layer set shares a layer with include and none with exclude.
+func (l Layer) Matches(include, exclude Layer) bool {
+	return l&include != 0 && l&exclude == 0
+}
+
+// LayerOf returns the layers of the entity, or DefaultLayer if it has no Layer component.
+func LayerOf(engine *Engine, entity any) Layer {
+	if l, _, ok := Get[Layer](engine, entity); ok {
+		return l
+	}
+	return DefaultLayer
+}
+
+// EachInLayers is like Each, but only calls f with the matching entities which are on a layer of include and on none
+// of exclude, and returns the number of those entities.
+//
+//	pickable.EachInLayers(tinyecs.AllLayers, LayerGhost, func(entity any) {
+//		// Hit test the entity.
+//	})
+func (q *Query) EachInLayers(include, exclude Layer, f func(entity any)) uint64 {
+	var counter uint64
+	q.Each(func(entity any) {
+		if LayerOf(q.engine, entity).Matches(include, exclude) {
+			counter++
+			f(entity)
+		}
+	})
+	return counter
+}
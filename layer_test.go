@@ -0,0 +1,38 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQuery_EachInLayers(t *testing.T) {
+	e := tinyecs.NewEngine()
+	ui := tinyecs.Layers(1)
+	ghost := tinyecs.Layers(2)
+
+	world := &testEntity{name: "world"}
+	e.AddComponents(world, position{})
+	button := &testEntity{name: "button"}
+	e.AddComponents(button, position{}, ui)
+	spirit := &testEntity{name: "spirit"}
+	e.AddComponents(spirit, position{}, tinyecs.DefaultLayer|ghost)
+
+	each := func(include, exclude tinyecs.Layer) []string {
+		var names []string
+		n := e.Query(tinyecs.MaskOf[position](e), tinyecs.Mask{}).EachInLayers(include, exclude, func(entity any) {
+			names = append(names, entity.(*testEntity).name)
+		})
+		assert.Len(t, names, int(n))
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"world", "spirit"}, each(tinyecs.DefaultLayer, 0))
+	assert.ElementsMatch(t, []string{"world"}, each(tinyecs.DefaultLayer, ghost))
+	assert.ElementsMatch(t, []string{"button"}, each(ui, 0))
+	assert.ElementsMatch(t, []string{"world", "button"}, each(tinyecs.AllLayers, ghost))
+
+	assert.Equal(t, tinyecs.DefaultLayer, tinyecs.LayerOf(e, world))
+	assert.Equal(t, tinyecs.Layer(0b101), tinyecs.Layers(0, 2))
+	assert.Panics(t, func() { tinyecs.Layers(64) })
+}
@@ -0,0 +1,36 @@
+package tinyecs
+
+// EntitySpawned is the event emitted when an entity is added to the engine.
+type EntitySpawned struct {
+	Entity any
+}
+
+// EntityDespawned is the event emitted when an entity is removed from the engine.
+type EntityDespawned struct {
+	Entity any
+	// Components holds the components linked to the entity at the time it was removed, by id.
+	Components map[uint64]any
+}
+
+// componentsOf returns the components linked to the entity, by id.
+func (e *Engine) componentsOf(entity any) map[uint64]any {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	components := make(map[uint64]any)
+	for id, link := range e.links {
+		if link.entity == entity {
+			components[id] = e.components[id]
+		}
+	}
+	return components
+}
+
+// despawn removes the entity from the engine along with all of its components.
+func (e *Engine) despawn(entity ecsEntity) {
+	e.RemoveEntity(entity)
+
+	for id := range e.componentsOf(entity) {
+		e.deleteComponent(id)
+	}
+}
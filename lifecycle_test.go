@@ -0,0 +1,39 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_EntityLifecycleEvents(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{name: "a1"}
+	e.AddComponents(entity, velocity{v: 1}, floater{f: 2})
+	e.AddEntity(entity)
+	e.EndFrame()
+
+	var spawned []any
+	tinyecs.ReadEvents[tinyecs.EntitySpawned](e, func(event tinyecs.EntitySpawned) {
+		spawned = append(spawned, event.Entity)
+	})
+	assert.Equal(t, []any{entity}, spawned)
+
+	e.RemoveEntity(entity)
+	e.EndFrame()
+
+	var despawned []tinyecs.EntityDespawned
+	tinyecs.ReadEvents[tinyecs.EntityDespawned](e, func(event tinyecs.EntityDespawned) {
+		despawned = append(despawned, event)
+	})
+	assert.Len(t, despawned, 1)
+	assert.Equal(t, entity, despawned[0].Entity)
+
+	var components []any
+	for _, component := range despawned[0].Components {
+		components = append(components, component)
+	}
+	assert.ElementsMatch(t, []any{velocity{v: 1}, floater{f: 2}}, components)
+	assert.Zero(t, tinyecs.Events[tinyecs.EntitySpawned](e).Len())
+}
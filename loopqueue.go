@@ -0,0 +1,29 @@
+package tinyecs
+
+// loopQueue hands functions from other goroutines over to the goroutine running the engine's frames,
+// which runs them from the system returned by drain.
+type loopQueue chan func()
+
+// run queues f and waits until it has run.
+func (q loopQueue) run(f func()) {
+	done := make(chan struct{})
+	q <- func() {
+		f()
+		close(done)
+	}
+	<-done
+}
+
+// drain returns a system running every queued function.
+func (q loopQueue) drain() System {
+	return func(engine *Engine) {
+		for {
+			select {
+			case f := <-q:
+				f()
+			default:
+				return
+			}
+		}
+	}
+}
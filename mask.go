@@ -0,0 +1,251 @@
+package tinyecs
+
+import (
+	"math/bits"
+	"reflect"
+	"sort"
+)
+
+// componentMask is a bitset where every bit represents a component type known to the engine.
+type componentMask []uint64
+
+// has returns whether the bit is set.
+func (m componentMask) has(bit int) bool {
+	word := bit / 64
+	return word < len(m) && m[word]&(1<<(uint(bit)%64)) != 0
+}
+
+// set sets the bit, growing the mask if required.
+func (m *componentMask) set(bit int) {
+	word := bit / 64
+	for len(*m) <= word {
+		*m = append(*m, 0)
+	}
+	(*m)[word] |= 1 << (uint(bit) % 64)
+}
+
+// unset clears the bit.
+func (m componentMask) unset(bit int) {
+	word := bit / 64
+	if word < len(m) {
+		m[word] &^= 1 << (uint(bit) % 64)
+	}
+}
+
+// containsAll returns whether every bit set in other is also set in m.
+func (m componentMask) containsAll(other componentMask) bool {
+	for i, word := range other {
+		if word == 0 {
+			continue
+		}
+		if i >= len(m) || m[i]&word != word {
+			return false
+		}
+	}
+	return true
+}
+
+// intersects returns whether m and other have at least one bit in common.
+func (m componentMask) intersects(other componentMask) bool {
+	n := len(m)
+	if len(other) < n {
+		n = len(other)
+	}
+	for i := 0; i < n; i++ {
+		if m[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of bits set.
+func (m componentMask) count() int {
+	var n int
+	for _, word := range m {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// entityMask tracks which component types an entity has.
+// ids holds the ids of the entity's components per type bit, since an entity may hold more than one component of the
+// same type.
+type entityMask struct {
+	bits componentMask
+	ids  map[int][]uint64
+}
+
+// add registers the component with the id and type bit.
+func (m *entityMask) add(bit int, id uint64) {
+	m.ids[bit] = append(m.ids[bit], id)
+	m.bits.set(bit)
+}
+
+// remove unregisters the component with the id and type bit, and returns whether the entity has no components left.
+func (m *entityMask) remove(bit int, id uint64) bool {
+	ids := m.ids[bit]
+	for i, other := range ids {
+		if other == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		delete(m.ids, bit)
+		m.bits.unset(bit)
+	} else {
+		m.ids[bit] = ids
+	}
+	return len(m.ids) == 0
+}
+
+// componentType returns the mask bit of the type t, assigning the next free bit on first use.
+// The caller must hold componentMtx.
+func (e *Engine) componentType(t reflect.Type) int {
+	bit, ok := e.componentTypes[t]
+	if !ok {
+		bit = len(e.componentTypes)
+		e.componentTypes[t] = bit
+		e.typesByBit = append(e.typesByBit, t)
+	}
+	return bit
+}
+
+// maskOf returns the entityMask of the entity, creating it if it does not exist yet.
+// The caller must hold componentMtx.
+func (e *Engine) maskOf(entity any) *entityMask {
+	m, ok := e.masks[entity]
+	if !ok {
+		m = &entityMask{ids: make(map[int][]uint64)}
+		e.masks[entity] = m
+	}
+	return m
+}
+
+// Mask is a set of component types which can be matched against the components of an entity.
+// The zero value is an empty set which every entity matches.
+type Mask struct {
+	bits componentMask
+}
+
+// MaskOf returns a Mask containing the component type T.
+// Masks are only meaningful for the engine they were created with.
+//
+//	movable := tinyecs.MaskOf[Position](e).Or(tinyecs.MaskOf[Velocity](e))
+func MaskOf[T any](engine *Engine) Mask {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	var m Mask
+	m.bits.set(typeBit[T](engine))
+	return m
+}
+
+// Or returns a Mask containing the component types of both masks.
+func (m Mask) Or(other Mask) Mask {
+	out := Mask{bits: make(componentMask, len(m.bits), len(m.bits)+len(other.bits))}
+	copy(out.bits, m.bits)
+	for i, word := range other.bits {
+		if i < len(out.bits) {
+			out.bits[i] |= word
+		} else {
+			out.bits = append(out.bits, word)
+		}
+	}
+	return out
+}
+
+// Len returns the number of component types in the mask.
+func (m Mask) Len() int {
+	return m.bits.count()
+}
+
+// Matches returns whether the entity has every component type in with, and none of the component types in without.
+//
+//	if e.Matches(entity, movable, tinyecs.MaskOf[Frozen](e)) {
+//		// Move the entity.
+//	}
+func (e *Engine) Matches(entity any, with Mask, without Mask) bool {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	var have componentMask
+	if m, ok := e.masks[entity]; ok {
+		have = m.bits
+	}
+	return have.containsAll(with.bits) && !have.intersects(without.bits)
+}
+
+// Has returns whether the entity has at least one component of type T.
+func Has[T any](engine *Engine, entity any) bool {
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	bit, ok := lookupTypeBit[T](engine)
+	if !ok {
+		return false
+	}
+	m, ok := engine.masks[entity]
+	return ok && m.bits.has(bit) && !engine.disabled[entity]
+}
+
+// Get returns the first component of type T linked to the entity along with its id,
+// or false if the entity has no component of type T.
+//
+//	if pos, id, ok := tinyecs.Get[Position](e, player); ok {
+//		tinyecs.Set(e, id, Position{X: pos.X + 1})
+//	}
+func Get[T any](engine *Engine, entity any) (component T, id uint64, ok bool) {
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	return get[T](engine, entity)
+}
+
+// get is Get for callers which already hold componentMtx, or which run within a query.
+func get[T any](engine *Engine, entity any) (component T, id uint64, ok bool) {
+	m, ok := engine.masks[entity]
+	if !ok || len(engine.disabled) > 0 && engine.disabled[entity] {
+		return component, 0, false
+	}
+
+	// Interface types can match components of any type, so every component of the entity has to be checked.
+	if isInterface[T]() {
+		var found bool
+		for _, ids := range m.ids {
+			for _, other := range ids {
+				if c, match := engine.components[other].(T); match && (!found || other < id) {
+					component, id, found = c, other, true
+				}
+			}
+		}
+		return component, id, found
+	}
+
+	bit, ok := lookupTypeBit[T](engine)
+	if !ok || len(m.ids[bit]) == 0 {
+		return component, 0, false
+	}
+	id = m.ids[bit][0]
+	return engine.components[id].(T), id, true
+}
+
+// ComponentIDs returns the ids of the components linked to the entity, in ascending order.
+func (e *Engine) ComponentIDs(entity any) []uint64 {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	m, ok := e.masks[entity]
+	if !ok {
+		return nil
+	}
+
+	var ids []uint64
+	for _, bitIDs := range m.ids {
+		ids = append(ids, bitIDs...)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
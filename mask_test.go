@@ -0,0 +1,120 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_HasComponent(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	e.AddComponents(
+		entity,
+
+		floater{f: 1.0},
+		floater{f: 2.0},
+	)
+	e.AddEntity(entity)
+
+	assert.True(t, tinyecs.Has[floater](e, entity))
+	assert.False(t, tinyecs.Has[velocity](e, entity))
+
+	// The bit must only be cleared once the last floater is gone.
+	e.DeleteComponent(floater{f: 1.0})
+	assert.True(t, tinyecs.Has[floater](e, entity))
+
+	e.DeleteComponent(floater{f: 2.0})
+	assert.False(t, tinyecs.Has[floater](e, entity))
+}
+
+func TestEngine_Matches(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	moving := &testEntity{name: "moving"}
+	e.AddComponents(moving, floater{}, velocity{v: 1})
+
+	floating := &testEntity{name: "floating"}
+	e.AddComponents(floating, floater{})
+
+	with := tinyecs.MaskOf[floater](e)
+	without := tinyecs.MaskOf[velocity](e)
+
+	assert.Equal(t, 2, with.Or(without).Len())
+
+	assert.True(t, e.Matches(moving, with.Or(without), tinyecs.Mask{}))
+	assert.False(t, e.Matches(floating, with.Or(without), tinyecs.Mask{}))
+
+	assert.False(t, e.Matches(moving, with, without))
+	assert.True(t, e.Matches(floating, with, without))
+
+	assert.True(t, e.Matches(&testEntity{}, tinyecs.Mask{}, without))
+	assert.False(t, e.Matches(&testEntity{}, with, tinyecs.Mask{}))
+}
+
+func Test_Get(t *testing.T) {
+	e := tinyecs.NewEngine()
+	entity := &testEntity{}
+	e.AddComponents(entity, velocity{v: 3}, floater{f: 1}, floater{f: 2})
+
+	f, id, ok := tinyecs.Get[floater](e, entity)
+	assert.True(t, ok)
+	assert.Equal(t, floater{f: 1}, f)
+	assert.Equal(t, uint64(1), id)
+
+	v, id, ok := tinyecs.Get[velocity](e, entity)
+	assert.True(t, ok)
+	assert.Equal(t, velocity{v: 3}, v)
+	assert.Equal(t, uint64(0), id)
+
+	e.DeleteComponent(floater{f: 1})
+	f, id, ok = tinyecs.Get[floater](e, entity)
+	assert.True(t, ok)
+	assert.Equal(t, floater{f: 2}, f)
+	assert.Equal(t, uint64(2), id)
+
+	_, _, ok = tinyecs.Get[playerData](e, entity)
+	assert.False(t, ok)
+	_, _, ok = tinyecs.Get[floater](e, &testEntity{})
+	assert.False(t, ok)
+
+	// Interface types match the component with the lowest id.
+	c, id, ok := tinyecs.Get[any](e, entity)
+	assert.True(t, ok)
+	assert.Equal(t, velocity{v: 3}, c)
+	assert.Equal(t, uint64(0), id)
+}
+
+func TestEngine_ComponentIDs(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	a, b := &testEntity{}, &testEntity{}
+	e.AddComponents(a, floater{}, velocity{})
+	e.AddComponents(b, floater{})
+	e.AddComponents(a, floater{})
+
+	assert.Equal(t, []uint64{0, 1, 3}, e.ComponentIDs(a))
+	assert.Equal(t, []uint64{2}, e.ComponentIDs(b))
+	assert.Nil(t, e.ComponentIDs(&testEntity{}))
+}
+
+func Test_UncomparableEntity(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	// Entities are indexed by value, so value entities holding slices are rejected with a clear message, while a
+	// pointer to them works.
+	assert.PanicsWithValue(t,
+		"tinyecs: AddComponents called with an entity of type tinyecs_test.uncomparableEntity, which is not comparable; pass a pointer to it instead",
+		func() { e.AddComponents(uncomparableEntity{tags: []string{"a"}}, velocity{}) })
+	assert.Panics(t, func() { e.AddEntity(uncomparableEntity{}) })
+	assert.Panics(t, func() { e.AddToGroup(uncomparableEntity{}, "enemies") })
+	assert.False(t, e.InGroup(uncomparableEntity{}, "enemies"))
+	assert.False(t, e.Despawning(uncomparableEntity{}))
+	assert.Empty(t, e.GetComponents())
+
+	entity := &uncomparableEntity{tags: []string{"a"}}
+	e.AddComponents(entity, velocity{v: 1})
+	e.AddEntity(entity)
+	assert.True(t, tinyecs.Has[velocity](e, entity))
+}
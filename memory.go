@@ -0,0 +1,210 @@
+package tinyecs
+
+import (
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// mapEntryOverhead is a rough estimate of the per-entry bookkeeping cost of a Go map, on top of the key and value.
+const mapEntryOverhead = 8
+
+// ComponentMemory is the estimated memory used by the components of a single type.
+type ComponentMemory struct {
+	// Type is the name of the component type.
+	Type string
+	// Count is the number of components of the type.
+	Count int
+	// Bytes is the estimated size of the components in the engine's component map, including the map entries.
+	Bytes uint64
+	// StorageBytes is the estimated size of the dense storage of the type, or zero if it has not been built.
+	StorageBytes uint64
+}
+
+// MemoryStats is an estimate of the memory used by an engine.
+// Sizes are shallow: memory referenced by components, such as the contents of strings, slices and maps, is not counted.
+type MemoryStats struct {
+	// Components holds the memory per component type, sorted with the largest types first.
+	Components []ComponentMemory
+	// Links is the estimated size of the entity-component links.
+	Links uint64
+	// Masks is the estimated size of the per-entity component masks.
+	Masks uint64
+	// Entities is the estimated size of the engine's entity list.
+	Entities uint64
+	// Total is the sum of all of the above.
+	Total uint64
+}
+
+// MemoryStats returns an estimate of the memory used by the engine, broken down per component type and per index.
+// It walks all components, so it is meant for diagnostics rather than for calling every frame.
+func (e *Engine) MemoryStats() MemoryStats {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	var stats MemoryStats
+
+	// Count the components of each type, including the boxed value held in the map.
+	perType := make(map[reflect.Type]*ComponentMemory)
+	for id, component := range e.components {
+		t := reflect.TypeOf(component)
+		cm, ok := perType[t]
+		if !ok {
+			cm = &ComponentMemory{Type: typeName(t)}
+			perType[t] = cm
+		}
+
+		cm.Count++
+		cm.Bytes += uint64(unsafe.Sizeof(id)+unsafe.Sizeof(component)+mapEntryOverhead) + boxedSize(t)
+	}
+
+	for t, bit := range e.componentTypes {
+		s, ok := e.storages[bit]
+		if !ok {
+			continue
+		}
+
+		cm, ok := perType[t]
+		if !ok {
+			cm = &ComponentMemory{Type: typeName(t)}
+			perType[t] = cm
+		}
+		cm.StorageBytes = storageSize(s, t)
+	}
+
+	for _, cm := range perType {
+		stats.Components = append(stats.Components, *cm)
+		stats.Total += cm.Bytes + cm.StorageBytes
+	}
+	sort.Slice(stats.Components, func(i, j int) bool {
+		a, b := stats.Components[i], stats.Components[j]
+		if a.Bytes+a.StorageBytes != b.Bytes+b.StorageBytes {
+			return a.Bytes+a.StorageBytes > b.Bytes+b.StorageBytes
+		}
+		return a.Type < b.Type
+	})
+
+	var link entityComponentLink
+	var boxed any
+	stats.Links = uint64(len(e.links)) * uint64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(link)+unsafe.Sizeof(boxed)+mapEntryOverhead)
+
+	var entity any
+	var m entityMask
+	for _, em := range e.masks {
+		stats.Masks += uint64(unsafe.Sizeof(entity)+unsafe.Sizeof(em)+unsafe.Sizeof(m)+mapEntryOverhead) +
+			uint64(cap(em.bits))*8 +
+			uint64(len(em.ids))*uint64(unsafe.Sizeof(int(0))+unsafe.Sizeof([]uint64(nil))+mapEntryOverhead)
+		for _, ids := range em.ids {
+			stats.Masks += uint64(cap(ids)) * 8
+		}
+	}
+
+	var ent ecsEntity
+	stats.Entities = uint64(cap(e.entities)) * uint64(unsafe.Sizeof(ent))
+
+	stats.Total += stats.Links + stats.Masks + stats.Entities
+	return stats
+}
+
+// Compact reallocates the engine's internal maps and slices to fit their current contents.
+// Go maps never shrink on their own, so after despawning many entities the engine keeps its high-water memory use
+// until Compact is called. This copies every index, so it is best called between levels rather than every frame.
+// Compact covers the indexes of components and entities, along with groups, double buffers, the change logs of
+// EachAdded and EachRemoved, and the entities waiting for DespawnDeferred, GC or a Pool.
+func (e *Engine) Compact() {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	e.components = compactMap(e.components)
+	e.links = compactMap(e.links)
+	e.versions = compactMap(e.versions)
+
+	masks := make(map[any]*entityMask, len(e.masks))
+	for entity, m := range e.masks {
+		ids := make(map[int][]uint64, len(m.ids))
+		for bit, componentIDs := range m.ids {
+			ids[bit] = append(make([]uint64, 0, len(componentIDs)), componentIDs...)
+		}
+		m.ids = ids
+		masks[entity] = m
+	}
+	e.masks = masks
+
+	for _, s := range e.storages {
+		s.compact()
+	}
+
+	e.entities = append(make([]ecsEntity, 0, len(e.entities)), e.entities...)
+
+	for _, g := range e.groups {
+		g.members = append(make([]any, 0, len(g.members)), g.members...)
+		index := make(map[any]int, len(g.index))
+		for entity, i := range g.index {
+			index[entity] = i
+		}
+		g.index = index
+	}
+	e.groups = compactMap(e.groups)
+	e.backBuffer = compactMap(e.backBuffer)
+	for _, l := range e.componentLogs {
+		l.added = append(make([]loggedComponent, 0, len(l.added)), l.added...)
+		l.removed = append(make([]loggedComponent, 0, len(l.removed)), l.removed...)
+	}
+	e.paths = compactMap(e.paths)
+	e.removed = compactSet(e.removed)
+	e.despawned = compactSet(e.despawned)
+	e.despawnQueue = append([]ecsEntity(nil), e.despawnQueue...)
+	e.disabled = compactSet(e.disabled)
+}
+
+// compactMap returns a copy of the map sized to fit its contents, or nil if it is nil.
+// Maps keyed by entities go through compactSet, as any only satisfies comparable from Go 1.20.
+func compactMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// compactSet is compactMap for sets of entities.
+func compactSet(m map[any]bool) map[any]bool {
+	if m == nil {
+		return nil
+	}
+	c := make(map[any]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// typeName returns a readable name for the component type.
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.String()
+}
+
+// boxedSize estimates the heap size of a value of type t stored in an interface.
+// Pointer-shaped values are stored in the interface itself and cost nothing extra.
+func boxedSize(t reflect.Type) uint64 {
+	if t == nil {
+		return 0
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return 0
+	}
+	return uint64(t.Size())
+}
+
+// storageSize estimates the size of a dense storage holding components of type t, based on its capacity.
+func storageSize(s componentStorage, t reflect.Type) uint64 {
+	capacity, indexed := s.capacity()
+	return uint64(capacity)*(uint64(t.Size())+8) + uint64(indexed)*uint64(16+mapEntryOverhead)
+}
@@ -0,0 +1,106 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestEngine_MemoryStats(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	for i := 0; i < 100; i++ {
+		e.AddComponents(entity, playerData{name: "p"})
+	}
+	e.AddComponents(entity, velocity{})
+	e.AddEntity(entity)
+
+	stats := e.MemoryStats()
+	assert.Len(t, stats.Components, 2)
+
+	// playerData dominates both by count and by size.
+	assert.Equal(t, "tinyecs_test.playerData", stats.Components[0].Type)
+	assert.Equal(t, 100, stats.Components[0].Count)
+	assert.Equal(t, "tinyecs_test.velocity", stats.Components[1].Type)
+	assert.Zero(t, stats.Components[0].StorageBytes)
+
+	assert.NotZero(t, stats.Links)
+	assert.NotZero(t, stats.Masks)
+	assert.NotZero(t, stats.Entities)
+
+	// Building the dense storage shows up on the type.
+	tinyecs.EachChunk[playerData](e, 0, func([]playerData, []uint64) {})
+	after := e.MemoryStats()
+	assert.NotZero(t, after.Components[0].StorageBytes)
+	assert.Greater(t, after.Total, stats.Total)
+}
+
+func TestEngine_Compact(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var entities []*testEntity
+	for i := 0; i < 1000; i++ {
+		entity := &testEntity{name: strconv.Itoa(i)}
+		e.AddComponents(entity, floater{f: float64(i)})
+		e.AddEntity(entity)
+		entities = append(entities, entity)
+	}
+	tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {})
+
+	for i, entity := range entities {
+		if i%100 != 0 {
+			e.RemoveEntity(entity)
+		}
+	}
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		if int(obj.f)%100 != 0 {
+			e.DeleteComponent(obj)
+		}
+	})
+
+	before := e.MemoryStats()
+	e.Compact()
+	after := e.MemoryStats()
+
+	assert.Less(t, after.Total, before.Total)
+	assert.Less(t, after.Components[0].StorageBytes, before.Components[0].StorageBytes)
+
+	// Everything left must still be reachable.
+	assert.Len(t, e.GetComponents(), 10)
+	assert.Len(t, e.GetEntities(), 10)
+	assert.Equal(t, uint64(10), tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {}))
+	assert.True(t, tinyecs.Has[floater](e, entities[0]))
+}
+
+func TestEngine_CompactKeepsGroupsAndDespawns(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var entities []*testEntity
+	for i := 0; i < 100; i++ {
+		entity := &testEntity{name: strconv.Itoa(i)}
+		e.AddComponents(entity, floater{f: float64(i)})
+		e.AddEntity(entity)
+		e.AddToGroup(entity, "crowd")
+		entities = append(entities, entity)
+	}
+	for _, entity := range entities[2:] {
+		e.RemoveFromGroup(entity, "crowd")
+	}
+	e.DespawnDeferred(entities[1])
+
+	e.Compact()
+
+	// The despawning entity stays in the group, hidden from queries.
+	assert.Equal(t, uint64(1), e.EachInGroup("crowd", func(any) {}))
+	assert.True(t, e.InGroup(entities[0], "crowd"))
+	assert.True(t, e.InGroup(entities[1], "crowd"))
+	assert.True(t, e.Despawning(entities[1]))
+	assert.Equal(t, uint64(99), tinyecs.Each[floater](e, func(uint64, floater) {}))
+
+	e.EndFrame()
+	assert.False(t, e.InGroup(entities[1], "crowd"))
+	assert.False(t, tinyecs.Has[floater](e, entities[1]))
+	assert.Len(t, e.GetEntities(), 99)
+}
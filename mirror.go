@@ -0,0 +1,220 @@
+package tinyecs
+
+import (
+	"sort"
+	"sync"
+)
+
+// Mirror keeps a secondary engine, the target, in sync with another engine, the source, so that another goroutine,
+// such as a renderer or an AI planner, can own a copy of the world and query it freely while the simulation runs.
+// Every change to the source's entities and non-transient components is recorded in a feed, and the changes of a
+// frame are published as a batch when the frame ends. The goroutine owning the target applies the published batches
+// using Sync, typically from a system of the target:
+//
+//	mirror := tinyecs.NewMirror(sim, tinyecs.WithLocking(tinyecs.NoLocking))
+//	render := mirror.Target()
+//	render.AddSystem(tinyecs.StagePreUpdate, "sync", mirror.System())
+//	go func() {
+//		for {
+//			render.Update(dt)
+//		}
+//	}()
+//
+// Components keep their ids in the target. Entities and components are shared by value, so pointer components and
+// entity fields must not be modified once added to the source, only replaced using Set. Entities are compared by
+// identity, as in the source.
+type Mirror struct {
+	source *Engine
+	target *Engine
+
+	// pending holds the changes of the source's current frame, and sets the index of the latest change to the value of
+	// each component in it, which later changes to the value replace. Both belong to the source.
+	pending []mirrorDelta
+	sets    map[uint64]int
+
+	// batches holds the published batches which have not been applied to the target yet.
+	batches [][]mirrorDelta
+	mtx     sync.Mutex
+}
+
+// mirrorOp is the kind of a change recorded by a Mirror.
+type mirrorOp int
+
+const (
+	mirrorAdd mirrorOp = iota
+	mirrorSet
+	mirrorDelete
+	mirrorSpawn
+	mirrorDespawn
+)
+
+// mirrorDelta is a change recorded by a Mirror. Component changes hold the id of the component.
+type mirrorDelta struct {
+	op        mirrorOp
+	id        uint64
+	entity    any
+	component any
+}
+
+// NewMirror returns a mirror of the source engine, whose target is a new engine configured by the options.
+// The current entities and non-transient components of the source are applied by the first call to Sync.
+// NewMirror must be called from the goroutine running the source.
+func NewMirror(source *Engine, opts ...Option) *Mirror {
+	m := &Mirror{source: source, target: NewEngine(opts...), sets: make(map[uint64]int)}
+
+	source.componentMtx.Lock()
+	defer source.componentMtx.Unlock()
+
+	ids := make([]uint64, 0, len(source.links))
+	for id, link := range source.links {
+		if !source.transient.has(link.componentType) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	batch := make([]mirrorDelta, 0, len(ids)+len(source.entities))
+	for _, id := range ids {
+		batch = append(batch, mirrorDelta{op: mirrorAdd, id: id, entity: source.links[id].entity, component: source.components[id]})
+	}
+	for _, entity := range source.entities {
+		batch = append(batch, mirrorDelta{op: mirrorSpawn, entity: entity})
+	}
+	m.batches = append(m.batches, batch)
+
+	source.mirrors = append(source.mirrors, m)
+	return m
+}
+
+// Target returns the engine kept in sync with the source. It belongs to the goroutine calling Sync.
+func (m *Mirror) Target() *Engine {
+	return m.target
+}
+
+// Sync applies the batches published by the source to the target, in order, and returns the number of frames applied.
+// It must be called from the goroutine owning the target, and not during a query of the target.
+func (m *Mirror) Sync() int {
+	m.mtx.Lock()
+	batches := m.batches
+	m.batches = nil
+	m.mtx.Unlock()
+
+	if m.target.iterating > 0 {
+		panic("tinyecs: Mirror.Sync called during a query")
+	}
+	for _, batch := range batches {
+		for _, d := range batch {
+			m.apply(d)
+		}
+	}
+	return len(batches)
+}
+
+// System returns a system of the target calling Sync.
+func (m *Mirror) System() System {
+	return func(*Engine) {
+		m.Sync()
+	}
+}
+
+// Close stops recording the changes of the source. The target keeps its entities and components.
+// Close must be called from the goroutine running the source.
+func (m *Mirror) Close() {
+	e := m.source
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	for i, mirror := range e.mirrors {
+		if mirror == m {
+			e.mirrors = append(e.mirrors[:i], e.mirrors[i+1:]...)
+			break
+		}
+	}
+	m.pending, m.sets = nil, make(map[uint64]int)
+}
+
+// apply applies the change to the target.
+func (m *Mirror) apply(d mirrorDelta) {
+	e := m.target
+	switch d.op {
+	case mirrorAdd, mirrorSet:
+		e.componentMtx.Lock()
+		if _, ok := e.components[d.id]; ok && d.op == mirrorSet {
+			e.setNow(d.id, d.component)
+		} else {
+			e.insertComponent(d.entity, d.id, d.component)
+			if d.id >= e.nextComponentID {
+				e.nextComponentID = d.id + 1
+			}
+		}
+		e.componentMtx.Unlock()
+	case mirrorDelete:
+		e.deleteComponent(d.id)
+	case mirrorSpawn:
+		e.AddEntity(d.entity.(ecsEntity))
+	case mirrorDespawn:
+		e.removeEntity(d.entity.(ecsEntity))
+	}
+}
+
+// record adds the change to the pending batch, replacing the previous value of the component if it was set during
+// the frame.
+func (m *Mirror) record(d mirrorDelta) {
+	switch d.op {
+	case mirrorSet:
+		if i, ok := m.sets[d.id]; ok {
+			m.pending[i] = d
+			return
+		}
+		m.sets[d.id] = len(m.pending)
+	case mirrorAdd, mirrorDelete:
+		delete(m.sets, d.id)
+	}
+	m.pending = append(m.pending, d)
+}
+
+// publish hands the pending batch over to the target, if it holds any change.
+func (m *Mirror) publish() {
+	if len(m.pending) == 0 {
+		return
+	}
+
+	m.mtx.Lock()
+	m.batches = append(m.batches, m.pending)
+	m.mtx.Unlock()
+
+	m.pending = nil
+	for id := range m.sets {
+		delete(m.sets, id)
+	}
+}
+
+// mirrorComponent records a change to the component with the id and type bit in every mirror of the engine.
+// Changes to transient components are skipped, and a component replaced by a transient one is deleted from the
+// mirrors. The caller must hold componentMtx.
+func (e *Engine) mirrorComponent(op mirrorOp, bit int, id uint64, entity any, component any) {
+	if len(e.mirrors) == 0 {
+		return
+	}
+	if e.transient.has(bit) {
+		if op != mirrorSet {
+			return
+		}
+		op, entity, component = mirrorDelete, nil, nil
+	}
+	e.mirror(mirrorDelta{op: op, id: id, entity: entity, component: component})
+}
+
+// mirror records the change in every mirror of the engine.
+func (e *Engine) mirror(d mirrorDelta) {
+	for _, m := range e.mirrors {
+		m.record(d)
+	}
+}
+
+// publishMirrors publishes the changes of the frame to every mirror of the engine. The caller must hold componentMtx.
+func (e *Engine) publishMirrors() {
+	for _, m := range e.mirrors {
+		m.publish()
+	}
+}
@@ -0,0 +1,102 @@
+package tinyecs_test
+
+import (
+	"testing"
+
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MirrorSync(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.MarkTransient[floater](e)
+	player := &testEntity{name: "player"}
+	e.AddComponents(player, velocity{v: 1}, floater{f: 2})
+	e.AddEntity(player)
+
+	m := tinyecs.NewMirror(e)
+	target := m.Target()
+	assert.Empty(t, target.GetEntities())
+
+	// The current world is applied by the first Sync, without transient components.
+	assert.Equal(t, 1, m.Sync())
+	assert.Equal(t, []any{player}, entitiesOf(target))
+	v, id, ok := tinyecs.Get[velocity](target, player)
+	assert.True(t, ok)
+	assert.Equal(t, velocity{v: 1}, v)
+	_, want, _ := tinyecs.Get[velocity](e, player)
+	assert.Equal(t, want, id)
+	assert.False(t, tinyecs.Has[floater](target, player))
+
+	// Changes are only published when the frame ends, and sets within a frame are coalesced.
+	tinyecs.Set(e, id, velocity{v: 2})
+	tinyecs.Set(e, id, velocity{v: 3})
+	enemy := &testEntity{name: "enemy"}
+	e.AddComponents(enemy, playerData{name: "enemy"}, floater{})
+	e.AddEntity(enemy)
+	assert.Equal(t, 0, m.Sync())
+	v, _, _ = tinyecs.Get[velocity](target, player)
+	assert.Equal(t, velocity{v: 1}, v)
+
+	e.EndFrame()
+	assert.Equal(t, 1, m.Sync())
+	v, _, _ = tinyecs.Get[velocity](target, player)
+	assert.Equal(t, velocity{v: 3}, v)
+	data, _, _ := tinyecs.Get[playerData](target, enemy)
+	assert.Equal(t, "enemy", data.name)
+	assert.Len(t, target.GetEntities(), 2)
+	assert.Len(t, target.GetComponents(), 2)
+
+	// Removals are mirrored too, and frames are applied in order.
+	e.RemoveEntity(enemy)
+	e.DeleteComponent(data)
+	e.EndFrame()
+	tinyecs.Set(e, id, floater{f: 4})
+	e.EndFrame()
+	assert.Equal(t, 2, m.Sync())
+	assert.Equal(t, []any{player}, entitiesOf(target))
+	assert.Empty(t, target.GetComponents())
+
+	m.Close()
+	e.AddComponents(player, playerData{})
+	e.EndFrame()
+	assert.Equal(t, 0, m.Sync())
+}
+
+func Test_MirrorAcrossGoroutines(t *testing.T) {
+	e := tinyecs.NewEngine()
+	player := &testEntity{}
+	e.AddComponents(player, velocity{})
+	e.AddEntity(player)
+
+	m := tinyecs.NewMirror(e)
+	target := m.Target()
+	seen := make(chan float64, 1)
+	target.AddSystem(tinyecs.StagePreUpdate, "sync", m.System())
+	target.AddSystem(tinyecs.StageUpdate, "read", func(target *tinyecs.Engine) {
+		v, _, _ := tinyecs.Get[velocity](target, player)
+		seen <- v.v
+	})
+
+	e.AddSystem(tinyecs.StageUpdate, "accelerate", func(e *tinyecs.Engine) {
+		tinyecs.EachWithEntity(e, func(entity any, id uint64, v velocity) {
+			tinyecs.Set(e, id, velocity{v: v.v + 1})
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		last := 0.0
+		for last < 100 {
+			target.Update(0)
+			v := <-seen
+			assert.GreaterOrEqual(t, v, last)
+			last = v
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		e.Update(0)
+	}
+	<-done
+}
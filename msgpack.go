@@ -0,0 +1,786 @@
+package tinyecs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MsgpackCodec encodes values as MessagePack. It is a middle ground between JSONCodec, which is easy to debug but slow,
+// and a custom binary format.
+//
+// Structs are encoded as maps keyed by field name, which can be changed using a `msgpack:"name"` tag, and skipped using
+// `msgpack:"-"`. Like encoding/json, only exported fields are encoded and embedded structs are flattened.
+var MsgpackCodec Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var enc msgpackEncoder
+	if err := enc.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+
+	dec := msgpackDecoder{data: data}
+	if err := dec.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if dec.pos != len(dec.data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(dec.data)-dec.pos)
+	}
+	return nil
+}
+
+var (
+	rawValueType  = reflect.TypeOf(RawValue(nil))
+	errMsgpackEOF = errors.New("msgpack: unexpected end of data")
+	msgpackFields sync.Map // map[reflect.Type][]msgpackField
+)
+
+// msgpackField is an encoded struct field. index is the field's index path, which is longer than one for fields of
+// embedded structs. Only embedded structs which are not pointers are flattened, so the path never crosses a pointer.
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// fieldsOf returns the encoded fields of the struct type t.
+func fieldsOf(t reflect.Type) []msgpackField {
+	if fields, ok := msgpackFields.Load(t); ok {
+		return fields.([]msgpackField)
+	}
+
+	var fields []msgpackField
+	seen := make(map[string]bool)
+
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("msgpack")
+			if tag == "-" {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(tag, ",")
+			path := append(append([]int(nil), index...), i)
+
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				collect(f.Type, path)
+				continue
+			}
+			if !f.IsExported() || f.Type == unknownFieldsType {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			fields = append(fields, msgpackField{name: name, index: path, omitEmpty: opts == "omitempty"})
+		}
+	}
+	collect(t, nil)
+
+	msgpackFields.Store(t, fields)
+	return fields
+}
+
+// msgpackEncoder appends the MessagePack encoding of values to buf.
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *msgpackEncoder) uint16(prefix byte, n uint16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], n)
+	e.buf = append(append(e.buf, prefix), b[:]...)
+}
+
+func (e *msgpackEncoder) uint32(prefix byte, n uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], n)
+	e.buf = append(append(e.buf, prefix), b[:]...)
+}
+
+func (e *msgpackEncoder) uint64(prefix byte, n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	e.buf = append(append(e.buf, prefix), b[:]...)
+}
+
+func (e *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.byte(byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.uint16(0xd1, uint16(n))
+	case n >= math.MinInt32:
+		e.uint32(0xd2, uint32(n))
+	default:
+		e.uint64(0xd3, uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.byte(byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xcd, uint16(n))
+	case n <= math.MaxUint32:
+		e.uint32(0xce, uint32(n))
+	default:
+		e.uint64(0xcf, n)
+	}
+}
+
+func (e *msgpackEncoder) string(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		e.byte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xda, uint16(n))
+	default:
+		e.uint32(0xdb, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) bytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xc5, uint16(n))
+	default:
+		e.uint32(0xc6, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+func (e *msgpackEncoder) arrayHeader(n int) {
+	switch {
+	case n <= 15:
+		e.byte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xdc, uint16(n))
+	default:
+		e.uint32(0xdd, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) mapHeader(n int) {
+	switch {
+	case n <= 15:
+		e.byte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xde, uint16(n))
+	default:
+		e.uint32(0xdf, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.byte(0xc0)
+		return nil
+	}
+
+	if v.Type() == rawValueType {
+		if v.Len() == 0 {
+			e.byte(0xc0)
+		} else {
+			e.buf = append(e.buf, v.Bytes()...)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.byte(0xc3)
+		} else {
+			e.byte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(v.Uint())
+	case reflect.Float32:
+		e.uint32(0xca, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.uint64(0xcb, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.bytes(v.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		e.arrayHeader(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		e.mapHeader(v.Len())
+		for _, key := range sortedKeys(v) {
+			if err := e.encode(key); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := fieldsOf(v.Type())
+
+		var values []reflect.Value
+		var names []string
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			values = append(values, fv)
+			names = append(names, f.name)
+		}
+
+		var unknown reflect.Value
+		var unknownKeys []reflect.Value
+		if index := unknownFieldsOf(v.Type()); index != nil {
+			unknown = v.FieldByIndex(index)
+			unknownKeys = sortedKeys(unknown)
+		}
+
+		e.mapHeader(len(values) + len(unknownKeys))
+		for i, fv := range values {
+			e.string(names[i])
+			if err := e.encode(fv); err != nil {
+				return fmt.Errorf("field %s: %w", names[i], err)
+			}
+		}
+		for _, key := range unknownKeys {
+			e.string(key.String())
+			if err := e.encode(unknown.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// msgpackDecoder decodes MessagePack values from data.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errMsgpackEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) readByte() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readUint reads a big endian unsigned integer of n bytes.
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// msgpackKind is the kind of a decoded MessagePack header.
+type msgpackKind int
+
+const (
+	kindNil msgpackKind = iota
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+	kindBinary
+	kindArray
+	kindMap
+	kindExt
+)
+
+// header is a decoded MessagePack header. For scalars it holds the value, and for strings, binaries, arrays, maps and
+// extensions it holds the length.
+type header struct {
+	kind msgpackKind
+	b    bool
+	i    int64
+	u    uint64
+	f    float64
+	n    int
+}
+
+func (d *msgpackDecoder) header() (header, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return header{}, err
+	}
+
+	length := func(size int) (int, error) {
+		n, err := d.readUint(size)
+		return int(n), err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return header{kind: kindUint, u: uint64(c)}, nil
+	case c >= 0xe0:
+		return header{kind: kindInt, i: int64(int8(c))}, nil
+	case c&0xe0 == 0xa0:
+		return header{kind: kindString, n: int(c & 0x1f)}, nil
+	case c&0xf0 == 0x90:
+		return header{kind: kindArray, n: int(c & 0x0f)}, nil
+	case c&0xf0 == 0x80:
+		return header{kind: kindMap, n: int(c & 0x0f)}, nil
+	}
+
+	h := header{}
+	switch c {
+	case 0xc0:
+		h.kind = kindNil
+	case 0xc2, 0xc3:
+		h.kind, h.b = kindBool, c == 0xc3
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		h.kind = kindUint
+		h.u, err = d.readUint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		var u uint64
+		u, err = d.readUint(size)
+		h.kind = kindInt
+		// Sign extend.
+		shift := 64 - 8*size
+		h.i = int64(u<<shift) >> shift
+	case 0xca:
+		var u uint64
+		u, err = d.readUint(4)
+		h.kind, h.f = kindFloat, float64(math.Float32frombits(uint32(u)))
+	case 0xcb:
+		var u uint64
+		u, err = d.readUint(8)
+		h.kind, h.f = kindFloat, math.Float64frombits(u)
+	case 0xd9, 0xda, 0xdb:
+		h.kind = kindString
+		h.n, err = length(1 << (c - 0xd9))
+	case 0xc4, 0xc5, 0xc6:
+		h.kind = kindBinary
+		h.n, err = length(1 << (c - 0xc4))
+	case 0xdc, 0xdd:
+		h.kind = kindArray
+		h.n, err = length(2 << (c - 0xdc))
+	case 0xde, 0xdf:
+		h.kind = kindMap
+		h.n, err = length(2 << (c - 0xde))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// Fixed size extensions, which have a type byte followed by 1 to 16 bytes of data.
+		h.kind, h.n = kindExt, 1+(1<<(c-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		h.kind = kindExt
+		h.n, err = length(1 << (c - 0xc7))
+		h.n++
+	default:
+		return header{}, fmt.Errorf("msgpack: invalid byte 0x%x", c)
+	}
+	if err != nil {
+		return h, err
+	}
+	return h, d.checkCount(h)
+}
+
+// checkCount rejects arrays and maps with more elements than there are bytes left, as every element takes at least
+// one byte, so a corrupt header cannot make the decoder allocate for elements which are not there.
+func (d *msgpackDecoder) checkCount(h header) error {
+	n := h.n
+	if h.kind == kindMap {
+		n *= 2
+	}
+	if (h.kind == kindArray || h.kind == kindMap) && (n < 0 || n > len(d.data)-d.pos) {
+		return fmt.Errorf("msgpack: %s of %d elements is longer than the %d bytes left", kindName(h.kind), h.n, len(d.data)-d.pos)
+	}
+	return nil
+}
+
+// skip skips the next value.
+func (d *msgpackDecoder) skip() error {
+	h, err := d.header()
+	if err != nil {
+		return err
+	}
+
+	switch h.kind {
+	case kindString, kindBinary, kindExt:
+		_, err = d.read(h.n)
+		return err
+	case kindArray:
+		for i := 0; i < h.n; i++ {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+	case kindMap:
+		for i := 0; i < 2*h.n; i++ {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decode(v reflect.Value) error {
+	if v.Type() == rawValueType {
+		start := d.pos
+		if err := d.skip(); err != nil {
+			return err
+		}
+		v.SetBytes(append([]byte(nil), d.data[start:d.pos]...))
+		return nil
+	}
+
+	start := d.pos
+	h, err := d.header()
+	if err != nil {
+		return err
+	}
+
+	if h.kind == kindNil {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("msgpack: cannot decode %s into %s", kindName(h.kind), v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.pos = start
+		return d.decode(v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch()
+		}
+		d.pos = start
+		value, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case reflect.Bool:
+		if h.kind != kindBool {
+			return mismatch()
+		}
+		v.SetBool(h.b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch h.kind {
+		case kindInt:
+			n = h.i
+		case kindUint:
+			if h.u > math.MaxInt64 {
+				return fmt.Errorf("msgpack: %d overflows %s", h.u, v.Type())
+			}
+			n = int64(h.u)
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch h.kind {
+		case kindUint:
+			n = h.u
+		case kindInt:
+			if h.i < 0 {
+				return fmt.Errorf("msgpack: %d overflows %s", h.i, v.Type())
+			}
+			n = uint64(h.i)
+		default:
+			return mismatch()
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch h.kind {
+		case kindFloat:
+			v.SetFloat(h.f)
+		case kindInt:
+			v.SetFloat(float64(h.i))
+		case kindUint:
+			v.SetFloat(float64(h.u))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		if h.kind != kindString && h.kind != kindBinary {
+			return mismatch()
+		}
+		b, err := d.read(h.n)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && (h.kind == kindBinary || h.kind == kindString) {
+			b, err := d.read(h.n)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		if h.kind != kindArray {
+			return mismatch()
+		}
+		// Grow the slice as elements are read rather than trusting the length in the header.
+		s := reflect.MakeSlice(v.Type(), 0, 0)
+		for i := 0; i < h.n; i++ {
+			s = reflect.Append(s, reflect.Zero(v.Type().Elem()))
+			if err := d.decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		if h.kind != kindArray {
+			return mismatch()
+		}
+		for i := 0; i < h.n; i++ {
+			if i >= v.Len() {
+				if err := d.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if h.kind != kindMap {
+			return mismatch()
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for i := 0; i < h.n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key); err != nil {
+				return err
+			}
+			if key.Kind() == reflect.Interface && !key.IsNil() && !key.Elem().Type().Comparable() {
+				return fmt.Errorf("msgpack: cannot use %s as a map key", key.Elem().Type())
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(value); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		if h.kind != kindMap {
+			return mismatch()
+		}
+		fields := fieldsOf(v.Type())
+		unknown := unknownFieldsOf(v.Type())
+		for i := 0; i < h.n; i++ {
+			var name string
+			if err := d.decode(reflect.ValueOf(&name).Elem()); err != nil {
+				return err
+			}
+
+			f, ok := findField(fields, name)
+			if !ok {
+				start := d.pos
+				if err := d.skip(); err != nil {
+					return err
+				}
+				if unknown != nil {
+					keepUnknown(v.FieldByIndex(unknown), name, d.data[start:d.pos])
+				}
+				continue
+			}
+			if err := d.decode(v.FieldByIndex(f.index)); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// decodeAny decodes the next value into its natural Go type: nil, bool, int64, uint64, float64, string, []byte, []any,
+// or map[string]any (map[any]any for maps with keys which are not strings).
+func (d *msgpackDecoder) decodeAny() (any, error) {
+	start := d.pos
+	h, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+
+	switch h.kind {
+	case kindNil:
+		return nil, nil
+	case kindBool:
+		return h.b, nil
+	case kindInt:
+		return h.i, nil
+	case kindUint:
+		return h.u, nil
+	case kindFloat:
+		return h.f, nil
+	case kindString:
+		b, err := d.read(h.n)
+		return string(b), err
+	case kindBinary:
+		b, err := d.read(h.n)
+		return append([]byte(nil), b...), err
+	case kindArray:
+		values := []any{}
+		for i := 0; i < h.n; i++ {
+			value, err := d.decodeAny()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case kindMap:
+		values := make(map[any]any)
+		stringKeys := true
+		for i := 0; i < h.n; i++ {
+			key, err := d.decodeAny()
+			if err != nil {
+				return nil, err
+			}
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return nil, fmt.Errorf("msgpack: cannot use %T as a map key", key)
+			}
+			if _, ok := key.(string); !ok {
+				stringKeys = false
+			}
+			if values[key], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		if !stringKeys {
+			return values, nil
+		}
+		m := make(map[string]any, len(values))
+		for k, v := range values {
+			m[k.(string)] = v
+		}
+		return m, nil
+	default:
+		d.pos = start
+		return nil, fmt.Errorf("msgpack: cannot decode %s into interface", kindName(h.kind))
+	}
+}
+
+// findField returns the field with the name, falling back to a case-insensitive match like encoding/json.
+func findField(fields []msgpackField, name string) (msgpackField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return msgpackField{}, false
+}
+
+func kindName(k msgpackKind) string {
+	return [...]string{"nil", "bool", "int", "uint", "float", "string", "binary", "array", "map", "extension"}[k]
+}
@@ -0,0 +1,109 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"strings"
+	"testing"
+)
+
+type msgpackInner struct {
+	Z int
+}
+
+type msgpackValue struct {
+	msgpackInner
+
+	Bool    bool
+	Int     int
+	Int8    int8
+	Int64   int64
+	Uint16  uint16
+	Uint64  uint64
+	Float32 float32
+	Float64 float64
+	String  string
+	Bytes   []byte
+	Array   [2]int
+	Slice   []position
+	Map     map[string]int
+	Ptr     *position
+	NilPtr  *position
+	Any     any
+	Renamed string `msgpack:"renamed"`
+	Skipped string `msgpack:"-"`
+	Empty   string `msgpack:",omitempty"`
+
+	hidden int
+}
+
+func Test_MsgpackRoundTrip(t *testing.T) {
+	in := msgpackValue{
+		msgpackInner: msgpackInner{Z: -7},
+
+		Bool:    true,
+		Int:     -100000,
+		Int8:    -100,
+		Int64:   math.MinInt64,
+		Uint16:  65535,
+		Uint64:  math.MaxUint64,
+		Float32: 1.5,
+		Float64: math.Pi,
+		String:  strings.Repeat("x", 300),
+		Bytes:   []byte{1, 2, 3},
+		Array:   [2]int{4, 5},
+		Slice:   []position{{X: 1}, {Y: 2}},
+		Map:     map[string]int{"a": 1, "b": -1},
+		Ptr:     &position{X: 3},
+		Any:     map[string]any{"k": []any{"v", int64(-2), uint64(3), 1.25, nil, true}},
+		Renamed: "r",
+		Skipped: "s",
+
+		hidden: 1,
+	}
+
+	data, err := tinyecs.MsgpackCodec.Marshal(in)
+	assert.NoError(t, err)
+
+	var out msgpackValue
+	assert.NoError(t, tinyecs.MsgpackCodec.Unmarshal(data, &out))
+
+	in.Skipped = ""
+	in.hidden = 0
+	assert.Equal(t, in, out)
+}
+
+func Test_MsgpackErrors(t *testing.T) {
+	data, err := tinyecs.MsgpackCodec.Marshal(map[string]any{"n": 300})
+	assert.NoError(t, err)
+
+	var small struct{ N int8 }
+	assert.ErrorContains(t, tinyecs.MsgpackCodec.Unmarshal(data, &small), "overflows")
+
+	var wrong struct{ N string }
+	assert.ErrorContains(t, tinyecs.MsgpackCodec.Unmarshal(data, &wrong), "cannot decode uint into string")
+
+	assert.Error(t, tinyecs.MsgpackCodec.Unmarshal(data[:len(data)-1], &small))
+	assert.Error(t, tinyecs.MsgpackCodec.Unmarshal(data, small))
+
+	_, err = tinyecs.MsgpackCodec.Marshal(func() {})
+	assert.Error(t, err)
+}
+
+func Test_MsgpackCorruptHeaders(t *testing.T) {
+	// An array header claiming 2^31 elements, with no elements following it.
+	var ints []int64
+	assert.EqualError(t, tinyecs.MsgpackCodec.Unmarshal([]byte{0xdd, 0x7f, 0xff, 0xff, 0xff}, &ints),
+		"msgpack: array of 2147483647 elements is longer than the 0 bytes left")
+
+	var values any
+	assert.ErrorContains(t, tinyecs.MsgpackCodec.Unmarshal([]byte{0xdf, 0x7f, 0xff, 0xff, 0xff, 0xc0}, &values), "longer than")
+
+	// A map with a binary key.
+	assert.EqualError(t, tinyecs.MsgpackCodec.Unmarshal([]byte{0x81, 0xc4, 0x01, 0x00, 0xc0}, &values),
+		"msgpack: cannot use []uint8 as a map key")
+	var m map[any]int
+	assert.EqualError(t, tinyecs.MsgpackCodec.Unmarshal([]byte{0x81, 0x91, 0x01, 0x02}, &m),
+		"msgpack: cannot use []interface {} as a map key")
+}
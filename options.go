@@ -0,0 +1,192 @@
+package tinyecs
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Option configures an engine created by NewEngine.
+type Option func(e *Engine)
+
+// WithCapacity preallocates room for the number of entities and components, avoiding rehashing while a world is
+// being populated.
+func WithCapacity(entities, components int) Option {
+	return func(e *Engine) {
+		e.entities = make([]ecsEntity, 0, entities)
+		e.components = make(map[uint64]any, components)
+		e.versions = make(map[uint64]uint64, components)
+		e.links = make(map[uint64]entityComponentLink, components)
+		e.masks = make(map[any]*entityMask, entities)
+	}
+}
+
+// Deterministic makes queries which scan all components, such as Each for interface types, visit them in order of
+// their ids rather than in map order, so that simulations replay identically at the cost of sorting on every scan.
+// Queries over the dense storage of a type are always deterministic.
+func Deterministic() Option {
+	return func(e *Engine) {
+		e.deterministic = true
+	}
+}
+
+// RequireRegisteredTypes makes AddComponentsE and SetE reject components whose types have not been registered using
+// RegisterType, catching components which would be silently left out of save games.
+func RequireRegisteredTypes() Option {
+	return func(e *Engine) {
+		e.requireRegistered = true
+	}
+}
+
+// WithSeed seeds the engine's random number generator, returned by Rand.
+func WithSeed(seed int64) Option {
+	return func(e *Engine) {
+		e.Reseed(seed)
+	}
+}
+
+// Locking is the strategy an engine uses to guard its components.
+type Locking int
+
+const (
+	// MutexLocking guards the components with a read-write mutex, so that systems on several goroutines can safely
+	// share the engine. It is the default.
+	MutexLocking Locking = iota
+	// NoLocking skips locking altogether, which is faster for games which only touch the engine from one goroutine.
+	NoLocking
+)
+
+// WithLocking sets the locking strategy of the engine.
+func WithLocking(l Locking) Option {
+	return func(e *Engine) {
+		e.componentMtx.unlocked = l == NoLocking
+	}
+}
+
+// Logger receives the diagnostics of an engine. It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// WithLogger makes the engine report misuse which is otherwise silently ignored, such as removing an entity which was
+// never added, to the logger.
+//
+//	e := tinyecs.NewEngine(tinyecs.WithLogger(log.Default()))
+func WithLogger(l Logger) Option {
+	return func(e *Engine) {
+		e.logger = l
+	}
+}
+
+// Rand returns the engine's random number generator. Systems drawing their random numbers from it, rather than from
+// the global generator, behave identically whenever the engine is seeded identically, as replays require.
+// It is seeded with 1 unless the engine was created using WithSeed. Like the engine's systems, it must not be used
+// from several goroutines at once.
+func (e *Engine) Rand() *rand.Rand {
+	if e.rng == nil {
+		e.Reseed(1)
+	}
+	return e.rng
+}
+
+// Reseed seeds the engine's random number generator with the seed.
+func (e *Engine) Reseed(seed int64) {
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// logf logs the message if the engine has a logger.
+func (e *Engine) logf(format string, v ...any) {
+	if e.logger != nil {
+		e.logger.Printf("tinyecs: "+format, v...)
+	}
+}
+
+// engineMutex is the read-write mutex guarding the components, which does nothing when unlocked is set.
+type engineMutex struct {
+	sync.RWMutex
+	unlocked bool
+}
+
+func (m *engineMutex) Lock() {
+	if !m.unlocked {
+		m.RWMutex.Lock()
+	}
+}
+
+func (m *engineMutex) Unlock() {
+	if !m.unlocked {
+		m.RWMutex.Unlock()
+	}
+}
+
+func (m *engineMutex) RLock() {
+	if !m.unlocked {
+		m.RWMutex.RLock()
+	}
+}
+
+func (m *engineMutex) RUnlock() {
+	if !m.unlocked {
+		m.RWMutex.RUnlock()
+	}
+}
+
+// rangeLinks calls f with every linked component, in order of their ids if the engine is deterministic.
+// Components of entities despawned using DespawnDeferred are skipped.
+func (e *Engine) rangeLinks(f func(id uint64, link entityComponentLink)) {
+	if !e.deterministic {
+		for id, link := range e.links {
+			if !e.hidden(link.entity) {
+				f(id, link)
+			}
+		}
+		return
+	}
+
+	ids := make([]uint64, 0, len(e.links))
+	for id := range e.links {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if link := e.links[id]; !e.hidden(link.entity) {
+			f(id, link)
+		}
+	}
+}
+
+// rangeEntities calls f with every entity which has components, in order of their lowest component ids if the engine
+// is deterministic.
+func (e *Engine) rangeEntities(f func(entity any)) {
+	if !e.deterministic {
+		for entity := range e.masks {
+			f(entity)
+		}
+		return
+	}
+
+	for _, entity := range e.entitiesByFirstID() {
+		f(entity)
+	}
+}
+
+// entitiesByFirstID returns every entity which has components, ordered by their lowest component ids.
+func (e *Engine) entitiesByFirstID() []any {
+	first := make(map[any]uint64, len(e.masks))
+	entities := make([]any, 0, len(e.masks))
+	for entity, m := range e.masks {
+		lowest := uint64(math.MaxUint64)
+		for _, ids := range m.ids {
+			for _, id := range ids {
+				if id < lowest {
+					lowest = id
+				}
+			}
+		}
+		first[entity] = lowest
+		entities = append(entities, entity)
+	}
+	sort.Slice(entities, func(i, j int) bool { return first[entities[i]] < first[entities[j]] })
+	return entities
+}
@@ -0,0 +1,70 @@
+package tinyecs_test
+
+import (
+	"fmt"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func Test_NewEngineOptions(t *testing.T) {
+	logger := &recordingLogger{}
+	e := tinyecs.NewEngine(
+		tinyecs.WithCapacity(16, 64),
+		tinyecs.WithLocking(tinyecs.NoLocking),
+		tinyecs.WithLogger(logger),
+	)
+
+	entity := &testEntity{name: "a"}
+	e.AddComponents(entity, velocity{v: 1})
+	e.AddEntity(entity)
+	assert.Equal(t, uint64(1), tinyecs.Each(e, func(id uint64, v velocity) {}))
+
+	assert.Empty(t, logger.lines)
+	e.RemoveEntity(&testEntity{name: "b"})
+	e.EnableSystem("missing", false)
+	tinyecs.Set(e, 1234, velocity{})
+	assert.Len(t, logger.lines, 3)
+	assert.Contains(t, logger.lines[1], `tinyecs: EnableSystem called with unknown system "missing"`)
+}
+
+func Test_Deterministic(t *testing.T) {
+	e := tinyecs.NewEngine(tinyecs.Deterministic())
+
+	var want []uint64
+	for i := 0; i < 50; i++ {
+		entity := &testEntity{}
+		e.AddComponents(entity, velocity{v: float64(i)}, playerData{})
+		_, id, _ := tinyecs.Get[velocity](e, entity)
+		want = append(want, id)
+	}
+
+	for i := 0; i < 5; i++ {
+		var got []uint64
+		tinyecs.Each(e, func(id uint64, n any) {
+			if _, ok := n.(velocity); ok {
+				got = append(got, id)
+			}
+		})
+		assert.Equal(t, want, got)
+	}
+}
+
+func Test_WithSeed(t *testing.T) {
+	a := tinyecs.NewEngine(tinyecs.WithSeed(7))
+	b := tinyecs.NewEngine(tinyecs.WithSeed(7))
+	first := a.Rand().Int63()
+	assert.Equal(t, first, b.Rand().Int63())
+
+	a.Reseed(7)
+	assert.Equal(t, first, a.Rand().Int63())
+	assert.Equal(t, tinyecs.NewEngine().Rand().Int63(), tinyecs.NewEngine(tinyecs.WithSeed(1)).Rand().Int63())
+}
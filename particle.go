@@ -0,0 +1,123 @@
+package tinyecs
+
+// Particles is a buffer of particles stored as a structure of arrays: the values of particle i are X[i], Y[i] and so
+// on. Particles are not entities, so a single emitter can own thousands of them without growing the engine's indexes.
+type Particles struct {
+	X, Y   []float64
+	VX, VY []float64
+	// Age and Lifetime are in seconds. Particles are removed once their age reaches their lifetime.
+	Age, Lifetime []float64
+
+	// pending is the fraction of a particle which is due to be emitted.
+	pending float64
+}
+
+// Len returns the number of live particles.
+func (p *Particles) Len() int {
+	return len(p.X)
+}
+
+// Spawn adds a particle, and returns its index.
+func (p *Particles) Spawn(x, y, vx, vy, lifetime float64) int {
+	p.X = append(p.X, x)
+	p.Y = append(p.Y, y)
+	p.VX = append(p.VX, vx)
+	p.VY = append(p.VY, vy)
+	p.Age = append(p.Age, 0)
+	p.Lifetime = append(p.Lifetime, lifetime)
+	return len(p.X) - 1
+}
+
+// Clear removes every particle, keeping the allocated buffers.
+func (p *Particles) Clear() {
+	p.X, p.Y = p.X[:0], p.Y[:0]
+	p.VX, p.VY = p.VX[:0], p.VY[:0]
+	p.Age, p.Lifetime = p.Age[:0], p.Lifetime[:0]
+}
+
+// Update advances every particle by dt seconds with the acceleration (ax, ay), and removes the expired ones by moving
+// the last particle into their place, so the order of particles is not preserved.
+func (p *Particles) Update(dt, ax, ay float64) {
+	for i := 0; i < len(p.X); {
+		p.Age[i] += dt
+		if p.Age[i] >= p.Lifetime[i] {
+			p.remove(i)
+			continue
+		}
+
+		p.VX[i] += ax * dt
+		p.VY[i] += ay * dt
+		p.X[i] += p.VX[i] * dt
+		p.Y[i] += p.VY[i] * dt
+		i++
+	}
+}
+
+// remove removes particle i by moving the last particle into its place.
+func (p *Particles) remove(i int) {
+	last := len(p.X) - 1
+	p.X[i], p.X = p.X[last], p.X[:last]
+	p.Y[i], p.Y = p.Y[last], p.Y[:last]
+	p.VX[i], p.VX = p.VX[last], p.VX[:last]
+	p.VY[i], p.VY = p.VY[last], p.VY[:last]
+	p.Age[i], p.Age = p.Age[last], p.Age[:last]
+	p.Lifetime[i], p.Lifetime = p.Lifetime[last], p.Lifetime[:last]
+}
+
+// Emitter is a component which emits particles and owns their buffer. The buffer is shared by copies of the
+// component, so systems can update it from Each without calling Set.
+//
+//	e.AddComponents(torch, tinyecs.Emitter{
+//		Rate: 30, Lifetime: 0.5, AY: -20, Max: 100,
+//		Init: func(p *tinyecs.Particles, i int) { p.VX[i] = rand.Float64() - 0.5 },
+//		Particles: &tinyecs.Particles{},
+//	})
+//	e.AddSystem(tinyecs.StageUpdate, "particles", tinyecs.UpdateParticles)
+type Emitter struct {
+	// X and Y are where particles are spawned.
+	X, Y float64
+	// Rate is the number of particles emitted per second, and Lifetime how long each of them lives, in seconds.
+	Rate     float64
+	Lifetime float64
+	// AX and AY are the acceleration of the particles, such as gravity.
+	AX, AY float64
+	// Max is the maximum number of live particles, or zero for no limit.
+	Max int
+	// Init is called with the index of every particle emitted, to set its initial velocity or lifetime. It is optional.
+	Init func(p *Particles, i int)
+
+	Particles *Particles
+}
+
+// Update advances the particles of the emitter by dt seconds, and emits new particles at its rate.
+// When the emitter has no buffer, it does nothing.
+func (em Emitter) Update(dt float64) {
+	p := em.Particles
+	if p == nil {
+		return
+	}
+
+	p.Update(dt, em.AX, em.AY)
+
+	p.pending += em.Rate * dt
+	for ; p.pending >= 1; p.pending-- {
+		if em.Max > 0 && p.Len() >= em.Max {
+			// Don't save up particles while the buffer is full, or they would all be emitted at once.
+			p.pending = 0
+			break
+		}
+
+		i := p.Spawn(em.X, em.Y, 0, 0, em.Lifetime)
+		if em.Init != nil {
+			em.Init(p, i)
+		}
+	}
+}
+
+// UpdateParticles is a system which updates every Emitter component by the frame's DeltaTime.
+func UpdateParticles(engine *Engine) {
+	dt := engine.DeltaTime().Seconds()
+	Each(engine, func(id uint64, em Emitter) {
+		em.Update(dt)
+	})
+}
@@ -0,0 +1,42 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUpdateParticles(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddSystem(tinyecs.StageUpdate, "particles", tinyecs.UpdateParticles)
+
+	particles := &tinyecs.Particles{}
+	e.AddComponents(&testEntity{}, tinyecs.Emitter{
+		X: 1, Rate: 10, Lifetime: 0.35, AY: -10, Max: 3,
+		Init:      func(p *tinyecs.Particles, i int) { p.VX[i] = 2 },
+		Particles: particles,
+	})
+
+	e.Update(100 * time.Millisecond)
+	assert.Equal(t, 1, particles.Len())
+	assert.Equal(t, 1.0, particles.X[0])
+	assert.Equal(t, 2.0, particles.VX[0])
+
+	e.Update(100 * time.Millisecond)
+	assert.Equal(t, 2, particles.Len())
+	assert.InDelta(t, 1.2, particles.X[0], 1e-9)
+	assert.InDelta(t, -1.0, particles.VY[0], 1e-9)
+
+	// The emitter stops at Max, and expired particles are removed.
+	for i := 0; i < 3; i++ {
+		e.Update(100 * time.Millisecond)
+	}
+	assert.Equal(t, 3, particles.Len())
+	for _, age := range particles.Age {
+		assert.Less(t, age, 0.35)
+	}
+
+	particles.Clear()
+	assert.Zero(t, particles.Len())
+}
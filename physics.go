@@ -0,0 +1,74 @@
+package tinyecs
+
+import "time"
+
+// PhysicsClock runs physics at a fixed rate, independently of the frame rate. It accumulates the time passed to
+// Update and runs the physics step once for every whole step which fits, carrying the remainder over to the next frame.
+// Once the physics has run, Alpha tells how far the frame is between the previous and the current physics state,
+// which render systems use to interpolate positions.
+//
+//	clock := tinyecs.NewPhysicsClock(60, 4)
+//	e.AddSystem(tinyecs.StageUpdate, "physics", clock.System(func(e *tinyecs.Engine) {
+//		// Integrate using e.DeltaTime(), which is the fixed step.
+//	}))
+//	e.AddSystem(tinyecs.StagePostUpdate, "interpolation", func(e *tinyecs.Engine) {
+//		alpha := clock.Alpha()
+//		// Draw at previous + (current - previous) * alpha.
+//	})
+type PhysicsClock struct {
+	// Step is the fixed time step of the physics.
+	Step time.Duration
+	// MaxSubsteps is the maximum number of steps run in a single frame. Time beyond it is dropped, so that a slow
+	// frame does not cause ever more steps to be run in the following frames. Zero means no limit.
+	MaxSubsteps int
+
+	accumulator time.Duration
+	steps       int
+}
+
+// NewPhysicsClock returns a clock which steps hz times per second, at most maxSubsteps times per frame.
+func NewPhysicsClock(hz float64, maxSubsteps int) *PhysicsClock {
+	if hz <= 0 {
+		panic("tinyecs: NewPhysicsClock called with a non-positive rate")
+	}
+	return &PhysicsClock{Step: time.Duration(float64(time.Second) / hz), MaxSubsteps: maxSubsteps}
+}
+
+// Advance adds dt to the clock, and returns the number of steps to run.
+func (c *PhysicsClock) Advance(dt time.Duration) int {
+	c.accumulator += dt
+
+	c.steps = int(c.accumulator / c.Step)
+	if c.MaxSubsteps > 0 && c.steps > c.MaxSubsteps {
+		c.steps = c.MaxSubsteps
+		// Drop the time which could not be simulated, keeping the fraction of a step for interpolation.
+		c.accumulator = c.accumulator % c.Step
+	} else {
+		c.accumulator -= time.Duration(c.steps) * c.Step
+	}
+	return c.steps
+}
+
+// Steps returns the number of steps run by the last call to Advance.
+func (c *PhysicsClock) Steps() int {
+	return c.steps
+}
+
+// Alpha returns the fraction of a step which has accumulated but not been run yet, from 0 up to but excluding 1.
+func (c *PhysicsClock) Alpha() float64 {
+	return float64(c.accumulator) / float64(c.Step)
+}
+
+// System returns a system which advances the clock by the frame's DeltaTime, and runs step once per physics step.
+// While step runs, DeltaTime returns the fixed step.
+func (c *PhysicsClock) System(step System) System {
+	return func(engine *Engine) {
+		dt := engine.DeltaTime()
+		defer func() { engine.delta = dt }()
+
+		engine.delta = c.Step
+		for i := c.Advance(dt); i > 0; i-- {
+			step(engine)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPhysicsClock_System(t *testing.T) {
+	e := tinyecs.NewEngine()
+	clock := tinyecs.NewPhysicsClock(100, 3)
+
+	var steps []time.Duration
+	e.AddSystem(tinyecs.StageUpdate, "physics", clock.System(func(e *tinyecs.Engine) {
+		steps = append(steps, e.DeltaTime())
+	}))
+	var frameDelta time.Duration
+	e.AddSystem(tinyecs.StagePostUpdate, "render", func(e *tinyecs.Engine) {
+		frameDelta = e.DeltaTime()
+	})
+
+	e.Update(25 * time.Millisecond)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}, steps)
+	assert.Equal(t, 25*time.Millisecond, frameDelta)
+	assert.InDelta(t, 0.5, clock.Alpha(), 1e-9)
+
+	steps = nil
+	e.Update(5 * time.Millisecond)
+	assert.Len(t, steps, 1)
+	assert.Zero(t, clock.Alpha())
+
+	// Slow frames run at most MaxSubsteps steps, and the rest of the time is dropped.
+	steps = nil
+	e.Update(time.Second + 2*time.Millisecond)
+	assert.Len(t, steps, 3)
+	assert.Equal(t, 3, clock.Steps())
+	assert.InDelta(t, 0.2, clock.Alpha(), 1e-9)
+
+	assert.Panics(t, func() { tinyecs.NewPhysicsClock(0, 1) })
+}
@@ -0,0 +1,49 @@
+package tinyecs
+
+import "context"
+
+// Match is a component produced by Stream, along with its id and entity.
+type Match[T any] struct {
+	Entity    any
+	ID        uint64
+	Component T
+}
+
+// Stream returns a channel producing every component of type T, which is closed once all of them have been sent.
+// The components are snapshotted before Stream returns, so the results are stable even if the engine changes while
+// they are being received, and can be fanned out to worker goroutines:
+//
+//	matches := tinyecs.Stream[Position](e)
+//	for i := 0; i < workers; i++ {
+//		go func() {
+//			for m := range matches {
+//				process(m.Entity, m.Component)
+//			}
+//		}()
+//	}
+//
+// The channel must be drained; use StreamContext to be able to stop early.
+func Stream[T any](engine *Engine) <-chan Match[T] {
+	return StreamContext[T](context.Background(), engine)
+}
+
+// StreamContext is like Stream, but stops sending and closes the channel once ctx is done.
+func StreamContext[T any](ctx context.Context, engine *Engine) <-chan Match[T] {
+	var matches []Match[T]
+	EachWithEntity(engine, func(entity any, id uint64, component T) {
+		matches = append(matches, Match[T]{Entity: entity, ID: id, Component: component})
+	})
+
+	ch := make(chan Match[T])
+	go func() {
+		defer close(ch)
+		for _, m := range matches {
+			select {
+			case ch <- m:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
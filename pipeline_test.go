@@ -0,0 +1,61 @@
+package tinyecs_test
+
+import (
+	"context"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Stream(t *testing.T) {
+	e := tinyecs.NewEngine()
+	for i := 0; i < 100; i++ {
+		e.AddComponents(&testEntity{}, velocity{v: float64(i)})
+	}
+
+	matches := tinyecs.Stream[velocity](e)
+	// Changes made after Stream returns are not seen.
+	e.AddComponents(&testEntity{}, velocity{v: 1000})
+
+	var mtx sync.Mutex
+	var sum float64
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range matches {
+				v, _, _ := tinyecs.Get[velocity](e, m.Entity)
+				assert.Equal(t, v, m.Component)
+
+				mtx.Lock()
+				sum += m.Component.v
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, float64(99*100/2), sum)
+}
+
+func Test_StreamContext(t *testing.T) {
+	e := tinyecs.NewEngine()
+	for i := 0; i < 10; i++ {
+		e.AddComponents(&testEntity{}, velocity{})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	matches := tinyecs.StreamContext[velocity](ctx, e)
+	<-matches
+	cancel()
+	// Give the producer time to see the context is done, rather than racing it for the next send.
+	time.Sleep(10 * time.Millisecond)
+
+	n := 0
+	for range matches {
+		n++
+	}
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,21 @@
+// Package pixelecs runs a tinyecs engine in a window of the Pixel 2D library (github.com/faiface/pixel): a Game holds
+// the window and camera resources, feeds the window's input into the engine's Input, and draws the Sprite components
+// of entities at their tinyecs.Transform in a single batch.
+//
+//	func run() {
+//		sheet, _ := loadPicture("sheet.png")
+//		game, err := pixelecs.NewGame(pixelgl.WindowConfig{Title: "game", Bounds: pixel.R(0, 0, 1024, 768)}, sheet)
+//		if err != nil {
+//			panic(err)
+//		}
+//		e.AddComponents(player, tinyecs.Transform{X: 100}, pixelecs.Sprite{Sprite: pixel.NewSprite(sheet, frame)})
+//		e.AddSystem(tinyecs.StagePostUpdate, "draw", game.DrawSprites)
+//		game.Run(e)
+//	}
+//
+//	func main() {
+//		pixelgl.Run(run)
+//	}
+//
+// Pixel requires cgo and OpenGL, so the package is only built with the pixel build tag: go build -tags pixel.
+package pixelecs
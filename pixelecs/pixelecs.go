@@ -0,0 +1,137 @@
+//go:build pixel
+
+package pixelecs
+
+import (
+	"image/color"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/kaiaverkvist/tinyecs"
+)
+
+// Sprite is a component drawing a sprite of the game's sheet at the entity's tinyecs.Transform.
+type Sprite struct {
+	Sprite *pixel.Sprite
+}
+
+// Camera is the view of the world shown in the window.
+type Camera struct {
+	// X and Y are the position in the world shown at the center of the window.
+	X, Y float64
+	// Zoom scales the world, where zero means no scaling.
+	Zoom float64
+	// Rotation rotates the view, in radians.
+	Rotation float64
+}
+
+// Matrix returns the matrix transforming world positions into positions of a window with the bounds.
+func (c Camera) Matrix(bounds pixel.Rect) pixel.Matrix {
+	zoom := c.Zoom
+	if zoom == 0 {
+		zoom = 1
+	}
+	return pixel.IM.
+		Moved(pixel.V(-c.X, -c.Y)).
+		Rotated(pixel.ZV, -c.Rotation).
+		Scaled(pixel.ZV, zoom).
+		Moved(bounds.Center())
+}
+
+// Unproject returns the world position shown at the position of a window with the bounds, such as that of the mouse.
+func (c Camera) Unproject(bounds pixel.Rect, v pixel.Vec) pixel.Vec {
+	return c.Matrix(bounds).Unproject(v)
+}
+
+// TransformMatrix returns the matrix placing a sprite at the transform.
+func TransformMatrix(t tinyecs.Transform) pixel.Matrix {
+	scale := pixel.V(t.ScaleX, t.ScaleY)
+	if scale.X == 0 {
+		scale.X = 1
+	}
+	if scale.Y == 0 {
+		scale.Y = 1
+	}
+	return pixel.IM.ScaledXY(pixel.ZV, scale).Rotated(pixel.ZV, t.Rotation).Moved(pixel.V(t.X, t.Y))
+}
+
+// Game holds the window and camera resources of a game drawn using Pixel.
+type Game struct {
+	Window *pixelgl.Window
+	Camera Camera
+	// Background is the color the window is cleared with every frame.
+	Background color.Color
+	// FPS is the maximum number of frames per second, or zero for no limit. VSync in the window config also limits it.
+	FPS float64
+
+	batch *pixel.Batch
+}
+
+// NewGame opens a window, and prepares a batch drawing the sprites of the sheet, which every Sprite must come from.
+// It must be called from the function passed to pixelgl.Run.
+func NewGame(cfg pixelgl.WindowConfig, sheet pixel.Picture) (*Game, error) {
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Game{
+		Window:     win,
+		Background: color.Black,
+		batch:      pixel.NewBatch(&pixel.TrianglesData{}, sheet),
+	}, nil
+}
+
+// Run runs the engine until the window is closed, using a tinyecs.Loop: every frame it feeds the input of the window
+// into the engine's Input, calls Update, which runs the systems of every stage, and then updates the window.
+func (g *Game) Run(engine *tinyecs.Engine) {
+	tinyecs.Loop{
+		FPS:     g.FPS,
+		Poll:    g.PollInput,
+		Present: g.Window.Update,
+	}.Run(engine)
+}
+
+// PollInput feeds the buttons pressed and released since the window was last updated, and the mouse position in the
+// window, into the input, and returns false once the window is closed. Keys are pixelgl.Button values.
+func (g *Game) PollInput(in *tinyecs.Input) bool {
+	for b := pixelgl.Button(0); b <= pixelgl.KeyLast; b++ {
+		if g.Window.JustPressed(b) {
+			in.Press(tinyecs.Key(b))
+		}
+		if g.Window.JustReleased(b) {
+			in.Release(tinyecs.Key(b))
+		}
+	}
+
+	mouse := g.Window.MousePosition()
+	in.MouseX, in.MouseY = mouse.X, mouse.Y
+	return !g.Window.Closed()
+}
+
+// DrawSprites is a system clearing the window and drawing every entity with a Sprite and a Transform through the
+// camera, in a single draw call. Entities with a tinyecs.ZIndex are drawn in draw order, after the ones without.
+func (g *Game) DrawSprites(engine *tinyecs.Engine) {
+	g.Window.Clear(g.Background)
+	g.batch.Clear()
+	g.batch.SetMatrix(g.Camera.Matrix(g.Window.Bounds()))
+
+	draw := func(entity any) {
+		sprite, _, ok := tinyecs.Get[Sprite](engine, entity)
+		if !ok || sprite.Sprite == nil {
+			return
+		}
+		if t, _, ok := tinyecs.Get[tinyecs.Transform](engine, entity); ok {
+			sprite.Sprite.Draw(g.batch, TransformMatrix(t))
+		}
+	}
+	tinyecs.EachEntity(engine, func(entity any, _ Sprite) {
+		if !tinyecs.Has[tinyecs.ZIndex](engine, entity) {
+			draw(entity)
+		}
+	})
+	engine.EachInDrawOrder(func(entity any, _ tinyecs.ZIndex) {
+		draw(entity)
+	})
+
+	g.batch.Draw(g.Window)
+}
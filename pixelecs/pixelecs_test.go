@@ -0,0 +1,35 @@
+//go:build pixel
+
+package pixelecs_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/kaiaverkvist/tinyecs/pixelecs"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Camera(t *testing.T) {
+	bounds := pixel.R(0, 0, 800, 600)
+	camera := pixelecs.Camera{X: 100, Y: 50, Zoom: 2}
+
+	m := camera.Matrix(bounds)
+	assert.Equal(t, pixel.V(400, 300), m.Project(pixel.V(100, 50)))
+	assert.Equal(t, pixel.V(420, 300), m.Project(pixel.V(110, 50)))
+	world := camera.Unproject(bounds, pixel.V(420, 300))
+	assert.InDelta(t, 110, world.X, 1e-9)
+	assert.InDelta(t, 50, world.Y, 1e-9)
+
+	camera = pixelecs.Camera{Rotation: math.Pi / 2}
+	v := camera.Matrix(bounds).Project(pixel.V(0, 10))
+	assert.InDelta(t, 410, v.X, 1e-9)
+	assert.InDelta(t, 300, v.Y, 1e-9)
+}
+
+func Test_TransformMatrix(t *testing.T) {
+	m := pixelecs.TransformMatrix(tinyecs.Transform{X: 5, Y: 6, ScaleX: 2})
+	assert.Equal(t, pixel.V(7, 7), m.Project(pixel.V(1, 1)))
+}
@@ -0,0 +1,313 @@
+package tinyecs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Plugin is a system compiled to WebAssembly, which runs sandboxed in its own wazero runtime and interacts with the
+// world through the host functions of the "tinyecs" module. Components are addressed by id, types by the names they
+// are registered under on the serializer or engine, and values are exchanged as JSON:
+//
+//	query(name_ptr, name_len, ids_ptr, ids_cap u32) u32
+//		Writes the ids of up to ids_cap components of the type to ids_ptr, as little-endian u64s, and returns the
+//		number of components of the type, which may be larger.
+//	get(id u64, buf_ptr, buf_cap u32) i32
+//		Writes the component to buf_ptr and returns its length, or -1 if there is no component with the id.
+//		Nothing is written if the length exceeds buf_cap, so the plugin can retry with a larger buffer.
+//	set(id u64, ptr, len u32) i32
+//		Decodes the fields at ptr on top of the component, and returns 0, or -1 on failure.
+//	spawn(ptr, len u32) i64
+//		Spawns the entity described at ptr as {"type": ..., "value": ..., "components": {name: value}}, and returns
+//		the id of its first component, -2 if it has none, or -1 on failure.
+//	despawn(id u64)
+//		Despawns the entity owning the component with the id, along with all of its components.
+//	delta() f64
+//		Returns the duration of the frame in seconds.
+//	log(ptr, len u32)
+//		Logs the message to the engine's logger.
+//
+// A plugin exports its memory as "memory" and a function "update", which is called every time its system runs.
+// An "init" function, if exported, is called when the plugin is loaded.
+type Plugin struct {
+	name       string
+	engine     *Engine
+	serializer *Serializer
+
+	runtime wazero.Runtime
+	update  api.Function
+
+	timeout     time.Duration
+	memoryPages uint32
+}
+
+const (
+	// DefaultPluginTimeout is how long a plugin's init or update function may run before it is stopped.
+	DefaultPluginTimeout = 100 * time.Millisecond
+	// DefaultPluginMemoryPages is the number of 64 KiB pages a plugin's memory may grow to, 64 MiB.
+	DefaultPluginMemoryPages = 1024
+)
+
+// PluginOption configures a plugin loaded by LoadPlugin.
+type PluginOption func(p *Plugin)
+
+// WithPluginTimeout sets how long each call to the plugin's init or update function may run. A call running longer
+// is stopped with an error, and the plugin is closed.
+func WithPluginTimeout(d time.Duration) PluginOption {
+	return func(p *Plugin) {
+		p.timeout = d
+	}
+}
+
+// WithPluginMemoryLimit sets the number of 64 KiB pages the plugin's memory may grow to. Plugins declaring a larger
+// memory fail to load, and growing past the limit fails inside the plugin.
+func WithPluginMemoryLimit(pages uint32) PluginOption {
+	return func(p *Plugin) {
+		p.memoryPages = pages
+	}
+}
+
+// LoadPlugin compiles and instantiates the WebAssembly module, whose types are named using the serializer.
+// Its calls are limited to DefaultPluginTimeout and its memory to DefaultPluginMemoryPages, unless configured
+// otherwise using the options.
+//
+//	p, err := s.LoadPlugin(ctx, e, "mod", wasm)
+//	if err != nil {
+//		return err
+//	}
+//	defer p.Close(ctx)
+//	e.AddSystem(tinyecs.StageUpdate, "mod", p.System())
+func (s *Serializer) LoadPlugin(ctx context.Context, engine *Engine, name string, wasm []byte, opts ...PluginOption) (*Plugin, error) {
+	p := &Plugin{
+		name:        name,
+		engine:      engine,
+		serializer:  s,
+		timeout:     DefaultPluginTimeout,
+		memoryPages: DefaultPluginMemoryPages,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(p.memoryPages))
+
+	_, err := p.runtime.NewHostModuleBuilder("tinyecs").
+		NewFunctionBuilder().WithFunc(p.query).Export("query").
+		NewFunctionBuilder().WithFunc(p.get).Export("get").
+		NewFunctionBuilder().WithFunc(p.set).Export("set").
+		NewFunctionBuilder().WithFunc(p.spawn).Export("spawn").
+		NewFunctionBuilder().WithFunc(p.despawn).Export("despawn").
+		NewFunctionBuilder().WithFunc(p.delta).Export("delta").
+		NewFunctionBuilder().WithFunc(p.log).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		p.runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+
+	module, err := p.runtime.InstantiateWithConfig(ctx, wasm, wazero.NewModuleConfig().WithName(name))
+	if err != nil {
+		p.runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	if p.update = module.ExportedFunction("update"); p.update == nil {
+		p.runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s does not export an update function", name)
+	}
+	if init := module.ExportedFunction("init"); init != nil {
+		if err := p.call(ctx, init); err != nil {
+			p.runtime.Close(ctx)
+			return nil, fmt.Errorf("plugin %s: init: %w", name, err)
+		}
+	}
+	return p, nil
+}
+
+// Update calls the plugin's update function, returning an error if it traps or runs longer than the timeout.
+func (p *Plugin) Update(ctx context.Context) error {
+	return p.call(ctx, p.update)
+}
+
+// call calls the function, stopping it once the timeout has passed.
+func (p *Plugin) call(ctx context.Context, f api.Function) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	_, err := f.Call(ctx)
+	return err
+}
+
+// System returns a system calling the plugin's update function. Failures, such as traps and timeouts, are logged to
+// the engine's logger.
+func (p *Plugin) System() System {
+	return func(engine *Engine) {
+		if err := p.Update(engine.Context()); err != nil {
+			engine.logf("plugin %s: update: %v", p.name, err)
+		}
+	}
+}
+
+// Close releases the plugin's runtime. Its system must not run afterwards.
+func (p *Plugin) Close(ctx context.Context) error {
+	return p.runtime.Close(ctx)
+}
+
+func (p *Plugin) query(_ context.Context, m api.Module, namePtr, nameLen, idsPtr, idsCap uint32) uint32 {
+	name, ok := m.Memory().Read(namePtr, nameLen)
+	if !ok {
+		return 0
+	}
+
+	e := p.engine
+	t, err := p.serializer.typeOf(e, string(name))
+	if err != nil {
+		return 0
+	}
+
+	var ids []uint64
+	e.componentMtx.RLock()
+	if bit, ok := e.componentTypes[t]; ok {
+		for id, link := range e.links {
+			if link.componentType == bit && !e.hidden(link.entity) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	e.componentMtx.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for i := 0; i < len(ids) && uint32(i) < idsCap; i++ {
+		m.Memory().WriteUint64Le(idsPtr+uint32(i)*8, ids[i])
+	}
+	return uint32(len(ids))
+}
+
+func (p *Plugin) get(_ context.Context, m api.Module, id uint64, bufPtr, bufCap uint32) int32 {
+	e := p.engine
+	e.componentMtx.RLock()
+	component, ok := e.components[id]
+	e.componentMtx.RUnlock()
+	if !ok {
+		return -1
+	}
+
+	data, err := JSONCodec.Marshal(component)
+	if err != nil {
+		return -1
+	}
+	if uint32(len(data)) <= bufCap && !m.Memory().Write(bufPtr, data) {
+		return -1
+	}
+	return int32(len(data))
+}
+
+func (p *Plugin) set(_ context.Context, m api.Module, id uint64, ptr, length uint32) int32 {
+	data, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		return -1
+	}
+
+	e := p.engine
+	e.componentMtx.RLock()
+	component, ok := e.components[id]
+	e.componentMtx.RUnlock()
+	if !ok {
+		return -1
+	}
+
+	v := reflect.New(reflect.TypeOf(component))
+	v.Elem().Set(reflect.ValueOf(component))
+	if err := JSONCodec.Unmarshal(data, v.Interface()); err != nil {
+		e.logf("plugin %s: set %d: %v", p.name, id, err)
+		return -1
+	}
+	Set(e, id, v.Elem().Interface())
+	return 0
+}
+
+func (p *Plugin) spawn(_ context.Context, m api.Module, ptr, length uint32) int64 {
+	data, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		return -1
+	}
+
+	var desc pluginSpawn
+	if err := json.Unmarshal(data, &desc); err != nil {
+		p.engine.logf("plugin %s: spawn: %v", p.name, err)
+		return -1
+	}
+	sp, err := p.decodeSpawn(desc)
+	if err != nil {
+		p.engine.logf("plugin %s: spawn: %v", p.name, err)
+		return -1
+	}
+
+	addSpawns([]sceneSpawn{sp}, p.engine)
+	if ids := p.engine.ComponentIDs(sp.entity); len(ids) > 0 {
+		return int64(ids[0])
+	}
+	return -2
+}
+
+// pluginSpawn is an entity described by a plugin to spawn.
+type pluginSpawn struct {
+	Type       string                     `json:"type"`
+	Value      json.RawMessage            `json:"value"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// decodeSpawn decodes an entity described by a plugin, adding its components in the order of their type names.
+func (p *Plugin) decodeSpawn(desc pluginSpawn) (sceneSpawn, error) {
+	e := p.engine
+	entity, err := p.serializer.decodeJSON(e, desc.Type, desc.Value)
+	if err != nil {
+		return sceneSpawn{}, err
+	}
+	ecsEnt, ok := entity.(ecsEntity)
+	if !ok {
+		return sceneSpawn{}, fmt.Errorf("type %q does not embed tinyecs.Entity", desc.Type)
+	}
+
+	sp := sceneSpawn{entity: ecsEnt}
+	names := make([]string, 0, len(desc.Components))
+	for name := range desc.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c, err := p.serializer.decodeJSON(e, name, desc.Components[name])
+		if err != nil {
+			return sceneSpawn{}, err
+		}
+		sp.components = append(sp.components, c)
+	}
+	return sp, nil
+}
+
+func (p *Plugin) despawn(_ context.Context, id uint64) {
+	e := p.engine
+	e.componentMtx.RLock()
+	link, ok := e.links[id]
+	e.componentMtx.RUnlock()
+
+	if ent, isEnt := link.entity.(ecsEntity); ok && isEnt {
+		e.despawn(ent)
+	}
+}
+
+func (p *Plugin) delta(context.Context) float64 {
+	return p.engine.DeltaTime().Seconds()
+}
+
+func (p *Plugin) log(_ context.Context, m api.Module, ptr, length uint32) {
+	if msg, ok := m.Memory().Read(ptr, length); ok {
+		p.engine.logf("plugin %s: %s", p.name, msg)
+	}
+}
@@ -0,0 +1,187 @@
+package tinyecs_test
+
+import (
+	"context"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// wasmVec encodes the items as a WebAssembly vector: their count followed by their concatenation.
+func wasmVec(items ...[]byte) []byte {
+	out := wasmUint(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// wasmBytes encodes the bytes as a WebAssembly byte vector or name.
+func wasmBytes(b []byte) []byte {
+	return append(wasmUint(uint64(len(b))), b...)
+}
+
+// wasmUint encodes v as an unsigned LEB128.
+func wasmUint(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if v == 0 {
+			return out
+		}
+	}
+}
+
+// wasmI32 encodes an i32.const instruction.
+func wasmI32(v int64) []byte {
+	out := []byte{0x41}
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func wasmSection(id byte, content []byte) []byte {
+	return append([]byte{id}, wasmBytes(content)...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// testPlugin returns a plugin whose update function doubles the X of the first position component, logs the
+// component, spawns an entity and sets its position through the returned id, and logs "empty" if spawning an entity
+// without components returns -2.
+func testPlugin() []byte {
+	const (
+		i32 = 0x7f
+		i64 = 0x7e
+	)
+	spawn := []byte(`{"type": "entity", "value": {"Name": "spawned"}, "components": {"position": {"X": 1}}}`)
+	empty := []byte(`{"type": "entity", "value": {"Name": "empty"}}`)
+
+	types := wasmVec(
+		[]byte{0x60, 4, i32, i32, i32, i32, 1, i32}, // query
+		[]byte{0x60, 3, i64, i32, i32, 1, i32},      // get, set
+		[]byte{0x60, 2, i32, i32, 0},                // log
+		[]byte{0x60, 0, 0},                          // update
+		[]byte{0x60, 2, i32, i32, 1, i64},           // spawn
+	)
+	imp := func(name string, typ byte) []byte {
+		return concat(wasmBytes([]byte("tinyecs")), wasmBytes([]byte(name)), []byte{0x00, typ})
+	}
+	imports := wasmVec(imp("query", 0), imp("get", 1), imp("set", 1), imp("log", 2), imp("spawn", 4))
+
+	loadID := concat(wasmI32(64), []byte{0x29, 3, 0})
+	body := concat(
+		[]byte{0}, // No locals.
+		wasmI32(0), wasmI32(8), wasmI32(64), wasmI32(4), []byte{0x10, 0, 0x1a},
+		loadID, wasmI32(16), wasmI32(7), []byte{0x10, 2, 0x1a},
+		wasmI32(128), loadID, wasmI32(128), wasmI32(64), []byte{0x10, 1}, []byte{0x10, 3},
+		wasmI32(256), wasmI32(int64(len(spawn))), []byte{0x10, 4}, wasmI32(16), wasmI32(7), []byte{0x10, 2, 0x1a},
+		wasmI32(512), wasmI32(int64(len(empty))), []byte{0x10, 4}, []byte{0x42, 0x7e, 0x51, 0x04, 0x40},
+		wasmI32(600), wasmI32(5), []byte{0x10, 3, 0x0b},
+		[]byte{0x0b},
+	)
+	data := func(offset int64, b []byte) []byte {
+		return concat([]byte{0x00}, wasmI32(offset), []byte{0x0b}, wasmBytes(b))
+	}
+
+	return concat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		wasmSection(1, types),
+		wasmSection(2, imports),
+		wasmSection(3, wasmVec([]byte{3})),
+		wasmSection(5, wasmVec([]byte{0x00, 1})),
+		wasmSection(7, wasmVec(
+			concat(wasmBytes([]byte("update")), []byte{0x00, 5}),
+			concat(wasmBytes([]byte("memory")), []byte{0x02, 0}),
+		)),
+		wasmSection(10, wasmVec(wasmBytes(body))),
+		wasmSection(11, wasmVec(data(0, []byte("position")), data(16, []byte(`{"X":5}`)), data(256, spawn),
+			data(512, empty), data(600, []byte("empty")))),
+	)
+}
+
+func Test_Plugin(t *testing.T) {
+	ctx := context.Background()
+	logger := &recordingLogger{}
+	e := tinyecs.NewEngine(tinyecs.WithLogger(logger))
+	s := newTestSerializer(nil)
+
+	entity := &testEntity{}
+	e.AddComponents(entity, position{X: 1, Y: 2})
+
+	p, err := s.LoadPlugin(ctx, e, "mod", testPlugin())
+	assert.NoError(t, err)
+	defer p.Close(ctx)
+
+	e.AddSystem(tinyecs.StageUpdate, "mod", p.System())
+	e.Update(0)
+
+	pos, _, _ := tinyecs.Get[position](e, entity)
+	assert.Equal(t, position{X: 5, Y: 2}, pos)
+	assert.Equal(t, []string{`tinyecs: plugin mod: {"X":5,"Y":2}`, "tinyecs: plugin mod: empty"}, logger.lines)
+
+	var spawned []string
+	tinyecs.EachEntity(e, func(entity *savedEntity, p position) {
+		spawned = append(spawned, entity.Name)
+		assert.Equal(t, position{X: 5}, p)
+	})
+	assert.Equal(t, []string{"spawned"}, spawned)
+}
+
+func Test_PluginWithoutUpdate(t *testing.T) {
+	ctx := context.Background()
+	_, err := newTestSerializer(nil).LoadPlugin(ctx, tinyecs.NewEngine(), "empty", []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00})
+	assert.EqualError(t, err, "plugin empty does not export an update function")
+}
+
+// loopPlugin returns a plugin whose update function loops forever, with a memory of the pages.
+func loopPlugin(pages byte) []byte {
+	body := []byte{0, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b} // loop br 0 end
+	return concat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		wasmSection(1, wasmVec([]byte{0x60, 0, 0})),
+		wasmSection(3, wasmVec([]byte{0})),
+		wasmSection(5, wasmVec([]byte{0x00, pages})),
+		wasmSection(7, wasmVec(concat(wasmBytes([]byte("update")), []byte{0x00, 0}))),
+		wasmSection(10, wasmVec(wasmBytes(body))),
+	)
+}
+
+func Test_PluginTimeout(t *testing.T) {
+	ctx := context.Background()
+	logger := &recordingLogger{}
+	e := tinyecs.NewEngine(tinyecs.WithLogger(logger))
+
+	p, err := newTestSerializer(nil).LoadPlugin(ctx, e, "loop", loopPlugin(1), tinyecs.WithPluginTimeout(10*time.Millisecond))
+	assert.NoError(t, err)
+	defer p.Close(ctx)
+
+	assert.ErrorContains(t, p.Update(ctx), "deadline exceeded")
+
+	e.AddSystem(tinyecs.StageUpdate, "loop", p.System())
+	e.Update(0)
+	assert.Len(t, logger.lines, 1)
+}
+
+func Test_PluginMemoryLimit(t *testing.T) {
+	ctx := context.Background()
+	_, err := newTestSerializer(nil).LoadPlugin(ctx, tinyecs.NewEngine(), "big", loopPlugin(2), tinyecs.WithPluginMemoryLimit(1))
+	assert.ErrorContains(t, err, "over limit of 1 pages")
+}
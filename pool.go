@@ -0,0 +1,154 @@
+package tinyecs
+
+// Prefab creates an entity along with its components in their initial state.
+// The entity must embed Entity, and should be a pointer so that every entity created is distinct.
+type Prefab func() (entity any, components []any)
+
+// pooledEntity is an entity of a pool along with its initial components, and the ids of those once it has been
+// spawned, matched by index.
+type pooledEntity struct {
+	entity     ecsEntity
+	components []any
+	ids        []uint64
+}
+
+// Pool recycles the entities of a prefab, so that frequently spawned entities such as bullets and pickups are
+// created once rather than allocated every time. Released entities are removed from the engine and hidden from
+// queries and Get, but keep their components, so Spawn only resets their values using Set and shows them again.
+//
+//	bullets := e.NewPool(func() (any, []any) {
+//		return &Bullet{}, []any{Position{}, Velocity{X: 10}}
+//	}, 100)
+//	bullet := bullets.Spawn()
+//	// ...
+//	bullets.Release(bullet)
+//
+// Components are reset to the initial values, so pointer components are shared between spawns and are not reset.
+// Components added to an entity after it was spawned are deleted when it is released.
+type Pool struct {
+	engine *Engine
+	prefab Prefab
+
+	free   []*pooledEntity
+	active map[any]*pooledEntity
+}
+
+// NewPool returns a pool of entities created by the prefab, with size entities created up front.
+// Spawning more than size entities at once creates more.
+func (e *Engine) NewPool(prefab Prefab, size int) *Pool {
+	p := &Pool{engine: e, prefab: prefab, active: make(map[any]*pooledEntity, size)}
+	p.free = make([]*pooledEntity, 0, size)
+	for i := 0; i < size; i++ {
+		p.free = append(p.free, p.create())
+	}
+	return p
+}
+
+// create creates an entity using the prefab.
+func (p *Pool) create() *pooledEntity {
+	entity, components := p.prefab()
+	ecsEnt, ok := entity.(ecsEntity)
+	if !ok {
+		panic("tinyecs: prefab entity does not embed tinyecs.Entity")
+	}
+	return &pooledEntity{entity: ecsEnt, components: components}
+}
+
+// Spawn adds a free entity of the pool to the engine with its components set to their initial values, and returns it.
+// When no entity is free, a new one is created by the prefab.
+func (p *Pool) Spawn() any {
+	var pe *pooledEntity
+	if n := len(p.free); n > 0 {
+		pe = p.free[n-1]
+		p.free = p.free[:n-1]
+	} else {
+		pe = p.create()
+	}
+	p.active[pe.entity] = pe
+
+	e := p.engine
+	if pe.ids == nil {
+		// The components are added on the first spawn only, once no query is running.
+		if !e.deferIfIterating(func() { p.add(pe) }) {
+			p.add(pe)
+		}
+	} else {
+		e.componentMtx.Lock()
+		for i, id := range pe.ids {
+			if _, ok := e.components[id]; ok {
+				e.set(id, pe.components[i])
+			} else {
+				// The component was deleted while the entity was active, so it has to be added again.
+				pe.ids[i] = e.nextComponentID
+				e.insertComponent(pe.entity, pe.ids[i], pe.components[i])
+				e.nextComponentID++
+			}
+		}
+		delete(e.disabled, pe.entity)
+		e.componentMtx.Unlock()
+	}
+	e.AddEntity(pe.entity)
+	return pe.entity
+}
+
+// add adds the initial components of the pooled entity, recording their ids.
+func (p *Pool) add(pe *pooledEntity) {
+	pe.ids = make([]uint64, len(pe.components))
+	for i, component := range pe.components {
+		pe.ids[i] = p.engine.addComponent(pe.entity, component)
+	}
+}
+
+// Release removes the entity from the engine and hides its components, and returns it to the pool.
+// It panics if the entity was not spawned by the pool, or was already released.
+func (p *Pool) Release(entity any) {
+	pe, ok := p.active[entity]
+	if !ok {
+		panic("tinyecs: Release called with an entity which was not spawned by the pool")
+	}
+	delete(p.active, entity)
+
+	e := p.engine
+	e.RemoveEntity(pe.entity)
+	e.componentMtx.Lock()
+	e.disabled[pe.entity] = true
+	extra := e.countComponents(pe.entity) > len(pe.ids)
+	e.componentMtx.Unlock()
+
+	if extra {
+		initial := make(map[uint64]bool, len(pe.ids))
+		for _, id := range pe.ids {
+			initial[id] = true
+		}
+		for _, id := range e.ComponentIDs(pe.entity) {
+			if !initial[id] {
+				e.deleteComponentID(id)
+			}
+		}
+	}
+	p.free = append(p.free, pe)
+}
+
+// countComponents returns the number of components linked to the entity. The caller must hold componentMtx.
+func (e *Engine) countComponents(entity any) int {
+	m, ok := e.masks[entity]
+	if !ok {
+		return 0
+	}
+
+	n := 0
+	for _, ids := range m.ids {
+		n += len(ids)
+	}
+	return n
+}
+
+// Active returns the number of spawned entities which have not been released.
+func (p *Pool) Active() int {
+	return len(p.active)
+}
+
+// Free returns the number of entities ready to be spawned without creating new ones.
+func (p *Pool) Free() int {
+	return len(p.free)
+}
@@ -0,0 +1,72 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	created := 0
+	bullets := e.NewPool(func() (any, []any) {
+		created++
+		return &testEntity{name: "bullet"}, []any{velocity{v: 10}}
+	}, 2)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 2, bullets.Free())
+
+	a := bullets.Spawn()
+	b := bullets.Spawn()
+	c := bullets.Spawn()
+	assert.Equal(t, 3, created)
+	assert.Equal(t, 3, bullets.Active())
+	assert.Len(t, e.GetEntities(), 3)
+
+	_, id, _ := tinyecs.Get[velocity](e, a)
+	tinyecs.Set(e, id, velocity{v: 1})
+
+	// Released entities are invisible to queries.
+	bullets.Release(a)
+	assert.False(t, tinyecs.Has[velocity](e, a))
+	assert.Equal(t, uint64(2), tinyecs.Each(e, func(id uint64, v velocity) {}))
+	assert.Len(t, e.GetEntities(), 2)
+
+	// They are recycled with their initial components.
+	assert.Same(t, a, bullets.Spawn())
+	v, _, _ := tinyecs.Get[velocity](e, a)
+	assert.Equal(t, velocity{v: 10}, v)
+	assert.Equal(t, 3, created)
+
+	bullets.Release(b)
+	assert.Panics(t, func() { bullets.Release(b) })
+	assert.NotSame(t, b, c)
+
+	assert.Panics(t, func() {
+		e.NewPool(func() (any, []any) { return velocity{}, nil }, 1)
+	})
+}
+
+func TestPoolRecyclesComponents(t *testing.T) {
+	e := tinyecs.NewEngine()
+	bullets := e.NewPool(func() (any, []any) {
+		return &testEntity{name: "bullet"}, []any{velocity{v: 10}, floater{f: 1}, tinyecs.Transform{}, tinyecs.Name("bullet")}
+	}, 1)
+
+	bullet := bullets.Spawn()
+	ids := e.ComponentIDs(bullet)
+	e.AddComponents(bullet.(*testEntity), playerData{})
+	bullets.Release(bullet)
+
+	// The initial components keep their ids, and those added while spawned are gone.
+	assert.Same(t, bullet, bullets.Spawn())
+	assert.Equal(t, ids, e.ComponentIDs(bullet))
+	bullets.Release(bullet)
+
+	// Recycling only allocates the component map of the EntityDespawned event, however many components there are.
+	allocs := testing.AllocsPerRun(100, func() {
+		bullets.Release(bullets.Spawn())
+	})
+	assert.LessOrEqual(t, allocs, 2.0)
+}
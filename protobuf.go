@@ -0,0 +1,772 @@
+package tinyecs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ProtobufCodec encodes values using the protobuf wire format, so that worlds can be read by services which are not
+// written in Go. GenerateProto writes the matching .proto schema.
+//
+// Structs map to messages, with fields numbered in declaration order starting at 1. Since reordering fields then
+// changes their numbers, fields can be pinned using a `proto:"N"` tag, and skipped using `proto:"-"`. Values which are
+// not structs are wrapped in a message with a single field named value. Interfaces, channels and functions are not
+// supported, and neither are arrays, since repeated fields have no fixed length.
+var ProtobufCodec Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	errProtobufEOF = errors.New("protobuf: unexpected end of data")
+	protoFields    sync.Map // map[reflect.Type][]protoField
+)
+
+// protoField is a message field. index is the field's index path, which is longer than one for embedded structs.
+type protoField struct {
+	name   string
+	number int
+	index  []int
+}
+
+// protoFieldsOf returns the message fields of the struct type t, sorted by field number.
+func protoFieldsOf(t reflect.Type) ([]protoField, error) {
+	if fields, ok := protoFields.Load(t); ok {
+		return fields.([]protoField), nil
+	}
+
+	var fields []protoField
+	next := 1
+	var collect func(t reflect.Type, index []int) error
+	collect = func(t reflect.Type, index []int) error {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("proto")
+			if tag == "-" {
+				continue
+			}
+
+			path := append(append([]int(nil), index...), i)
+			if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+				if err := collect(f.Type, path); err != nil {
+					return err
+				}
+				continue
+			}
+			if !f.IsExported() || f.Type == unknownFieldsType {
+				continue
+			}
+
+			number := next
+			if tag != "" {
+				n, err := strconv.Atoi(tag)
+				if err != nil || n <= 0 {
+					return fmt.Errorf("protobuf: invalid field number %q on %s.%s", tag, t, f.Name)
+				}
+				number = n
+			}
+			next = number + 1
+
+			fields = append(fields, protoField{name: snakeCase(f.Name), number: number, index: path})
+		}
+		return nil
+	}
+	if err := collect(t, nil); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].number < fields[j].number })
+	for i := 1; i < len(fields); i++ {
+		if fields[i].number == fields[i-1].number {
+			return nil, fmt.Errorf("protobuf: duplicate field number %d in %s", fields[i].number, t)
+		}
+	}
+
+	protoFields.Store(t, fields)
+	return fields, nil
+}
+
+// snakeCase converts a Go field name to the protobuf naming convention.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// messageType returns the struct type of a message, dereferencing pointers. ok is false for values which have to be
+// wrapped in a message.
+func messageType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("protobuf: cannot marshal nil")
+	}
+
+	var enc protoEncoder
+	if rv.Kind() != reflect.Struct {
+		err := enc.field(1, rv)
+		return enc.buf, err
+	}
+	err := enc.message(rv)
+	return enc.buf, err
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("protobuf: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+
+	rv = rv.Elem()
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	dec := protoDecoder{data: data}
+	if rv.Kind() != reflect.Struct {
+		return dec.wrapped(rv)
+	}
+	return dec.message(rv)
+}
+
+// protoEncoder appends the protobuf encoding of messages to buf.
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) varint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutUvarint(b[:], n)]...)
+}
+
+func (e *protoEncoder) tag(number int, wire int) {
+	e.varint(uint64(number)<<3 | uint64(wire))
+}
+
+func (e *protoEncoder) bytes(number int, b []byte) {
+	e.tag(number, wireBytes)
+	e.varint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// nested encodes a length-delimited field using f.
+func (e *protoEncoder) nested(number int, f func(e *protoEncoder) error) error {
+	var inner protoEncoder
+	if err := f(&inner); err != nil {
+		return err
+	}
+	e.bytes(number, inner.buf)
+	return nil
+}
+
+func (e *protoEncoder) message(v reflect.Value) error {
+	fields, err := protoFieldsOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := e.field(f.number, v.FieldByIndex(f.index)); err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+	}
+
+	// Unknown fields are kept fully encoded, including their tags.
+	if index := unknownFieldsOf(v.Type()); index != nil {
+		unknown := v.FieldByIndex(index)
+		for _, key := range sortedKeys(unknown) {
+			e.buf = append(e.buf, unknown.MapIndex(key).Bytes()...)
+		}
+	}
+	return nil
+}
+
+// field encodes a field. As in proto3, zero scalars are not written.
+func (e *protoEncoder) field(number int, v reflect.Value) error {
+	if v.Type() == rawValueType {
+		if v.Len() > 0 {
+			e.bytes(number, v.Bytes())
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() > 0 {
+				e.bytes(number, v.Bytes())
+			}
+			return nil
+		}
+		if v.Len() == 0 {
+			return nil
+		}
+		if isPackable(v.Type().Elem()) {
+			return e.nested(number, func(inner *protoEncoder) error {
+				for i := 0; i < v.Len(); i++ {
+					inner.scalar(v.Index(i))
+				}
+				return nil
+			})
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.element(number, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			err := e.nested(number, func(inner *protoEncoder) error {
+				if err := inner.element(1, key); err != nil {
+					return err
+				}
+				return inner.element(2, v.MapIndex(key))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if v.IsZero() {
+		return nil
+	}
+	return e.element(number, v)
+}
+
+// element encodes a single value, including zero values, as is required for repeated fields and map entries.
+func (e *protoEncoder) element(number int, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return e.element(number, v.Elem())
+	case reflect.Struct:
+		return e.nested(number, func(inner *protoEncoder) error {
+			return inner.message(v)
+		})
+	case reflect.String:
+		e.bytes(number, []byte(v.String()))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.bytes(number, v.Bytes())
+			return nil
+		}
+	}
+
+	if !isPackable(v.Type()) {
+		return fmt.Errorf("protobuf: unsupported type %s", v.Type())
+	}
+	e.tag(number, wireTypeOf(v.Type()))
+	e.scalar(v)
+	return nil
+}
+
+// scalar encodes a numeric or boolean value without a tag.
+func (e *protoEncoder) scalar(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.varint(1)
+		} else {
+			e.varint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.varint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.varint(v.Uint())
+	case reflect.Float32:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v.Float())))
+		e.buf = append(e.buf, b[:]...)
+	case reflect.Float64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.Float()))
+		e.buf = append(e.buf, b[:]...)
+	}
+}
+
+// isPackable returns whether values of type t are numeric or boolean, which allows packing them in repeated fields.
+func isPackable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func wireTypeOf(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Float32:
+		return wireFixed32
+	case reflect.Float64:
+		return wireFixed64
+	}
+	return wireVarint
+}
+
+// protoDecoder decodes protobuf messages from data.
+type protoDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *protoDecoder) varint() (uint64, error) {
+	n, size := binary.Uvarint(d.data[d.pos:])
+	if size <= 0 {
+		return 0, errProtobufEOF
+	}
+	d.pos += size
+	return n, nil
+}
+
+func (d *protoDecoder) read(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errProtobufEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// value reads the value of a field with the wire type. Varints are returned in n, everything else in b.
+func (d *protoDecoder) value(wire int) (n uint64, b []byte, err error) {
+	switch wire {
+	case wireVarint:
+		n, err = d.varint()
+	case wireFixed64:
+		b, err = d.read(8)
+	case wireFixed32:
+		b, err = d.read(4)
+	case wireBytes:
+		var size uint64
+		if size, err = d.varint(); err == nil {
+			b, err = d.read(int(size))
+		}
+	default:
+		err = fmt.Errorf("protobuf: unsupported wire type %d", wire)
+	}
+	return n, b, err
+}
+
+// fields calls f with every field in the data.
+func (d *protoDecoder) fields(f func(number int, wire int, n uint64, b []byte) error) error {
+	for d.pos < len(d.data) {
+		key, err := d.varint()
+		if err != nil {
+			return err
+		}
+		wire := int(key & 7)
+		n, b, err := d.value(wire)
+		if err != nil {
+			return err
+		}
+		if err := f(int(key>>3), wire, n, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *protoDecoder) message(v reflect.Value) error {
+	fields, err := protoFieldsOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	unknown := unknownFieldsOf(v.Type())
+	return d.fields(func(number int, wire int, n uint64, b []byte) error {
+		i := sort.Search(len(fields), func(i int) bool { return fields[i].number >= number })
+		if i == len(fields) || fields[i].number != number {
+			// Unknown fields are skipped as in proto3, unless the struct keeps them.
+			if unknown != nil {
+				keepUnknownProto(v.FieldByIndex(unknown), number, wire, n, b)
+			}
+			return nil
+		}
+		if err := decodeField(v.FieldByIndex(fields[i].index), wire, n, b); err != nil {
+			return fmt.Errorf("field %s: %w", fields[i].name, err)
+		}
+		return nil
+	})
+}
+
+// keepUnknownProto appends the re-encoded field to the UnknownFields value, under its field number.
+// Fields which occur more than once, such as repeated fields, are kept together in order.
+func keepUnknownProto(v reflect.Value, number int, wire int, n uint64, b []byte) {
+	key := strconv.Itoa(number)
+
+	var enc protoEncoder
+	if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+		enc.buf = existing.Bytes()
+	}
+	enc.tag(number, wire)
+	switch wire {
+	case wireVarint:
+		enc.varint(n)
+	case wireBytes:
+		enc.varint(uint64(len(b)))
+		enc.buf = append(enc.buf, b...)
+	default:
+		enc.buf = append(enc.buf, b...)
+	}
+	keepUnknown(v, key, enc.buf)
+}
+
+// wrapped decodes a value which is not a struct, from the value field of its wrapper message.
+func (d *protoDecoder) wrapped(v reflect.Value) error {
+	return d.fields(func(number int, wire int, n uint64, b []byte) error {
+		if number != 1 {
+			return nil
+		}
+		return decodeField(v, wire, n, b)
+	})
+}
+
+// decodeField decodes a field value into v. Repeated fields and maps are appended to.
+func decodeField(v reflect.Value, wire int, n uint64, b []byte) error {
+	if v.Type() == rawValueType {
+		v.SetBytes(append([]byte(nil), b...))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+
+		elem := v.Type().Elem()
+		if wire == wireBytes && isPackable(elem) {
+			packed := protoDecoder{data: b}
+			for packed.pos < len(packed.data) {
+				n, b, err := packed.value(wireTypeOf(elem))
+				if err != nil {
+					return err
+				}
+				item := reflect.New(elem).Elem()
+				if err := decodeScalar(item, n, b); err != nil {
+					return err
+				}
+				v.Set(reflect.Append(v, item))
+			}
+			return nil
+		}
+
+		item := reflect.New(elem).Elem()
+		if err := decodeElement(item, wire, n, b); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, item))
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		value := reflect.New(v.Type().Elem()).Elem()
+		entry := protoDecoder{data: b}
+		err := entry.fields(func(number int, wire int, n uint64, b []byte) error {
+			switch number {
+			case 1:
+				return decodeElement(key, wire, n, b)
+			case 2:
+				return decodeElement(value, wire, n, b)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		v.SetMapIndex(key, value)
+		return nil
+	}
+
+	return decodeElement(v, wire, n, b)
+}
+
+// decodeElement decodes a single value into v.
+func decodeElement(v reflect.Value, wire int, n uint64, b []byte) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeElement(v.Elem(), wire, n, b)
+	case reflect.Struct:
+		if wire != wireBytes {
+			return fmt.Errorf("protobuf: cannot decode wire type %d into %s", wire, v.Type())
+		}
+		inner := protoDecoder{data: b}
+		return inner.message(v)
+	case reflect.String:
+		if wire != wireBytes {
+			return fmt.Errorf("protobuf: cannot decode wire type %d into %s", wire, v.Type())
+		}
+		v.SetString(string(b))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+	}
+
+	if !isPackable(v.Type()) {
+		return fmt.Errorf("protobuf: unsupported type %s", v.Type())
+	}
+	if wire != wireTypeOf(v.Type()) {
+		return fmt.Errorf("protobuf: cannot decode wire type %d into %s", wire, v.Type())
+	}
+	return decodeScalar(v, n, b)
+}
+
+// decodeScalar decodes a numeric or boolean value, from n for varints or from b for fixed size values.
+func decodeScalar(v reflect.Value, n uint64, b []byte) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(n != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := int64(n)
+		if v.OverflowInt(i) {
+			return fmt.Errorf("protobuf: %d overflows %s", i, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.OverflowUint(n) {
+			return fmt.Errorf("protobuf: %d overflows %s", n, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	}
+	return nil
+}
+
+// GenerateProto returns a .proto schema describing the snapshots written by the serializer with ProtobufCodec, in the
+// protobuf package pkg. It contains a message for the snapshot itself, and one for every registered type, named after
+// the name it was registered under. Readers use the type of each entity and component to pick the message to decode
+// its value with.
+func GenerateProto(s *Serializer, pkg string) (string, error) {
+	g := protoGenerator{names: make(map[reflect.Type]string)}
+
+	var names []string
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t, _ := messageType(s.types[name])
+		g.names[t] = messageName(name)
+	}
+
+	if err := g.generate(reflect.TypeOf(Snapshot{})); err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if err := g.generate(s.types[name]); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n")
+	if pkg != "" {
+		fmt.Fprintf(&b, "\npackage %s;\n", pkg)
+	}
+	for _, m := range g.messages {
+		b.WriteString("\n")
+		b.WriteString(m)
+	}
+	return b.String(), nil
+}
+
+// protoGenerator generates message definitions, each type once.
+type protoGenerator struct {
+	names    map[reflect.Type]string
+	done     map[reflect.Type]bool
+	messages []string
+}
+
+// messageName converts a registered or Go type name to a message name.
+func messageName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (g *protoGenerator) nameOf(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := messageName(t.Name())
+	g.names[t] = name
+	return name
+}
+
+// generate adds the message for the type t, and the messages of the structs it uses.
+func (g *protoGenerator) generate(t reflect.Type) error {
+	st, isStruct := messageType(t)
+	if g.done == nil {
+		g.done = make(map[reflect.Type]bool)
+	}
+	if g.done[st] {
+		return nil
+	}
+	g.done[st] = true
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "message %s {\n", g.nameOf(st))
+
+	if !isStruct {
+		typ, err := g.fieldType(st)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "  %s value = 1;\n}\n", typ)
+		g.messages = append(g.messages, b.String())
+		return nil
+	}
+
+	fields, err := protoFieldsOf(st)
+	if err != nil {
+		return err
+	}
+
+	// Reserve the position of this message before generating the ones it uses.
+	i := len(g.messages)
+	g.messages = append(g.messages, "")
+
+	for _, f := range fields {
+		typ, err := g.fieldType(st.FieldByIndex(f.index).Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", st, f.name, err)
+		}
+		fmt.Fprintf(&b, "  %s %s = %d;\n", typ, f.name, f.number)
+	}
+	b.WriteString("}\n")
+
+	g.messages[i] = b.String()
+	return nil
+}
+
+// fieldType returns the protobuf type of a field of Go type t.
+func (g *protoGenerator) fieldType(t reflect.Type) (string, error) {
+	if t == rawValueType {
+		return "bytes", nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool", nil
+	case reflect.Int, reflect.Int64:
+		return "int64", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int32", nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "uint64", nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Pointer:
+		return g.fieldType(t.Elem())
+	case reflect.Struct:
+		if err := g.generate(t); err != nil {
+			return "", err
+		}
+		return g.nameOf(t), nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		if k := t.Elem().Kind(); k == reflect.Map || (k == reflect.Slice && t.Elem().Elem().Kind() != reflect.Uint8) {
+			return "", fmt.Errorf("protobuf: nested repeated type %s is not supported", t)
+		}
+		elem, err := g.fieldType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "repeated " + elem, nil
+	case reflect.Map:
+		key, err := g.fieldType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case "double", "float", "bytes":
+			return "", fmt.Errorf("protobuf: map key type %s is not supported", t.Key())
+		}
+		if k := t.Elem().Kind(); k == reflect.Map || (k == reflect.Slice && t.Elem().Elem().Kind() != reflect.Uint8) {
+			return "", fmt.Errorf("protobuf: map value type %s is not supported", t.Elem())
+		}
+		value, err := g.fieldType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map<%s, %s>", key, value), nil
+	}
+	return "", fmt.Errorf("protobuf: unsupported type %s", t)
+}
@@ -0,0 +1,136 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+type protoValue struct {
+	A       int
+	Neg     int32
+	Big     uint64
+	F32     float32
+	F64     float64
+	Flag    bool
+	Name    string
+	Data    []byte
+	Packed  []int
+	Points  []position
+	Lookup  map[string]int
+	Nested  *position
+	Pinned  string `proto:"20"`
+	Skipped string `proto:"-"`
+}
+
+type health int
+
+func Test_ProtobufWireFormat(t *testing.T) {
+	// The encoding example from the protobuf documentation: field 1 set to 150.
+	data, err := tinyecs.ProtobufCodec.Marshal(struct{ A int }{A: 150})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x08, 0x96, 0x01}, data)
+}
+
+func Test_ProtobufRoundTrip(t *testing.T) {
+	in := protoValue{
+		A:      150,
+		Neg:    -3,
+		Big:    math.MaxUint64,
+		F32:    1.5,
+		F64:    -2.25,
+		Flag:   true,
+		Name:   "name",
+		Data:   []byte{0, 1},
+		Packed: []int{1, -1, 0, 300},
+		Points: []position{{X: 1}, {}, {Y: 3}},
+		Lookup: map[string]int{"a": 1, "zero": 0},
+		Nested: &position{},
+		Pinned: "pinned",
+	}
+
+	data, err := tinyecs.ProtobufCodec.Marshal(&in)
+	assert.NoError(t, err)
+
+	var out protoValue
+	assert.NoError(t, tinyecs.ProtobufCodec.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	// Values which are not structs are wrapped.
+	data, err = tinyecs.ProtobufCodec.Marshal(health(-20))
+	assert.NoError(t, err)
+
+	var h health
+	assert.NoError(t, tinyecs.ProtobufCodec.Unmarshal(data, &h))
+	assert.Equal(t, health(-20), h)
+}
+
+func Test_ProtobufUnsupported(t *testing.T) {
+	_, err := tinyecs.ProtobufCodec.Marshal(struct{ V any }{V: 1})
+	assert.Error(t, err)
+
+	_, err = tinyecs.ProtobufCodec.Marshal(struct {
+		A int `proto:"1"`
+		B int `proto:"1"`
+	}{})
+	assert.ErrorContains(t, err, "duplicate field number 1")
+}
+
+func Test_GenerateProto(t *testing.T) {
+	s := tinyecs.NewSerializer(tinyecs.ProtobufCodec)
+	tinyecs.Register[*savedEntity](s, "entity")
+	tinyecs.Register[inventory](s, "inventory")
+	tinyecs.Register[health](s, "health")
+	tinyecs.Register[struct {
+		Path   []position
+		Target *position `proto:"5"`
+	}](s, "path_finder")
+
+	schema, err := tinyecs.GenerateProto(s, "game.world")
+	assert.NoError(t, err)
+	assert.Equal(t, `syntax = "proto3";
+
+package game.world;
+
+message Snapshot {
+  repeated EntitySnapshot entities = 1;
+}
+
+message EntitySnapshot {
+  string type = 1;
+  bytes value = 2;
+  bool added = 3;
+  repeated ComponentSnapshot components = 4;
+}
+
+message ComponentSnapshot {
+  uint64 id = 1;
+  string type = 2;
+  bytes value = 3;
+}
+
+message Entity {
+  string name = 1;
+}
+
+message Health {
+  int64 value = 1;
+}
+
+message Inventory {
+  map<string, int64> items = 1;
+  repeated string slots = 2;
+}
+
+message PathFinder {
+  repeated Position path = 1;
+  Position target = 5;
+}
+
+message Position {
+  double x = 1;
+  double y = 2;
+}
+`, schema)
+}
@@ -0,0 +1,243 @@
+package tinyecs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Query is a compiled filter matching the entities which have every component type of a with mask, and none of the
+// component types of a without mask. Queries are cached on the engine, so calling Query every frame with the same
+// masks returns the same plan without recompiling it.
+//
+//	movable := e.Query(tinyecs.MaskOf[Position](e).Or(tinyecs.MaskOf[Velocity](e)), tinyecs.MaskOf[Frozen](e))
+//	movable.Each(func(entity any) {
+//		// Move the entity.
+//	})
+type Query struct {
+	engine  *Engine
+	with    componentMask
+	without componentMask
+
+	// bits holds the type bits of the with mask, whose storages can drive the iteration.
+	bits []int
+}
+
+// Query returns the query plan matching entities which have every component type in with, and none in without.
+func (e *Engine) Query(with, without Mask) *Query {
+	key := queryKey(with.bits, without.bits)
+
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if q, ok := e.queries[key]; ok {
+		return q
+	}
+
+	q := &Query{engine: e, with: with.bits, without: without.bits}
+	for bit := 0; bit < len(with.bits)*64; bit++ {
+		if with.bits.has(bit) {
+			q.bits = append(q.bits, bit)
+		}
+	}
+	e.queries[key] = q
+	return q
+}
+
+// queryKey returns the cache key of a query, which ignores trailing empty words of the masks.
+func queryKey(with, without componentMask) string {
+	var b []byte
+	for _, m := range []componentMask{with, without} {
+		n := len(m)
+		for n > 0 && m[n-1] == 0 {
+			n--
+		}
+		for _, word := range m[:n] {
+			b = strconv.AppendUint(b, word, 16)
+			b = append(b, ',')
+		}
+		b = append(b, '|')
+	}
+	return string(b)
+}
+
+// Matches returns whether the entity matches the query.
+func (q *Query) Matches(entity any) bool {
+	q.engine.componentMtx.RLock()
+	defer q.engine.componentMtx.RUnlock()
+
+	return q.matches(entity)
+}
+
+// matches is Matches for callers which already hold componentMtx.
+func (q *Query) matches(entity any) bool {
+	if q.engine.hidden(entity) {
+		return false
+	}
+
+	var have componentMask
+	if m, ok := q.engine.masks[entity]; ok {
+		have = m.bits
+	}
+	return have.containsAll(q.with) && !have.intersects(q.without)
+}
+
+// Each calls f with every entity matching the query, and returns the number of entities visited.
+// Structural changes made during the call are deferred, as with the other queries.
+// When one of the with component types has dense storage, the smallest such storage drives the iteration;
+// otherwise every entity with components is checked.
+func (q *Query) Each(f func(entity any)) uint64 {
+	engine := q.engine
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	var counter uint64
+	if s, bit, ok := q.driver(); ok {
+		for _, id := range s.componentIDs() {
+			entity := engine.links[id].entity
+			// Visit entities holding several components of the driving type only once.
+			if m := engine.masks[entity]; m.ids[bit][0] != id || !q.matches(entity) {
+				continue
+			}
+			counter++
+			f(entity)
+		}
+		engine.recordQuery(queryStatsKey{component: q}, uint64(len(s.componentIDs())), counter)
+		return counter
+	}
+
+	engine.rangeEntities(func(entity any) {
+		if q.matches(entity) {
+			counter++
+			f(entity)
+		}
+	})
+	engine.recordQuery(queryStatsKey{component: q}, uint64(len(engine.masks)), counter)
+	return counter
+}
+
+// driver returns the smallest dense storage of the query's with component types, if any of them has one.
+func (q *Query) driver() (componentStorage, int, bool) {
+	var driver componentStorage
+	var driverBit int
+	for _, bit := range q.bits {
+		if s, ok := q.engine.storages[bit]; ok && (driver == nil || s.len() < driver.len()) {
+			driver, driverBit = s, bit
+		}
+	}
+	return driver, driverBit, driver != nil
+}
+
+// QueryFilter is a query written in the query DSL, which names component types by the names they are registered
+// under. Names are separated by spaces or "+", and an entity matches if it has a component of every plain name and
+// none of the names prefixed with "!":
+//
+//	position+velocity !frozen
+type QueryFilter struct {
+	With    []string
+	Without []string
+}
+
+// ParseQueryFilter parses a query written in the query DSL. Empty names are ignored.
+func ParseQueryFilter(s string) QueryFilter {
+	var f QueryFilter
+	for _, field := range strings.Fields(s) {
+		for _, name := range strings.Split(field, "+") {
+			switch {
+			case strings.HasPrefix(name, "!") && len(name) > 1:
+				f.Without = append(f.Without, name[1:])
+			case name != "" && name != "!":
+				f.With = append(f.With, name)
+			}
+		}
+	}
+	return f
+}
+
+// String returns the filter in the query DSL.
+func (f QueryFilter) String() string {
+	parts := []string{strings.Join(f.With, "+")}
+	for _, name := range f.Without {
+		parts = append(parts, "!"+name)
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// MatchesTypes returns whether a set of component types, given by their names, has every type the filter requires
+// and none of those it excludes. It suits matching entities described by name only, such as by a DebugClient.
+func (f QueryFilter) MatchesTypes(names []string) bool {
+	has := make(map[string]bool, len(names))
+	for _, name := range names {
+		has[name] = true
+	}
+	for _, name := range f.With {
+		if !has[name] {
+			return false
+		}
+	}
+	for _, name := range f.Without {
+		if has[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseQuery compiles a query written in the query DSL, resolving type names registered using RegisterType.
+// Compiled queries are cached by their text, so calling ParseQuery every frame parses it only once.
+//
+//	movable, err := e.ParseQuery("position+velocity !frozen")
+func (e *Engine) ParseQuery(s string) (*Query, error) {
+	e.componentMtx.RLock()
+	q, ok := e.parsedQueries[s]
+	e.componentMtx.RUnlock()
+	if ok {
+		return q, nil
+	}
+
+	q, err := e.compileFilter(ParseQueryFilter(s), func(name string) (reflect.Type, error) {
+		e.componentMtx.RLock()
+		defer e.componentMtx.RUnlock()
+
+		if t, ok := e.registeredType(name); ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("type %q is not registered", name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.componentMtx.Lock()
+	e.parsedQueries[s] = q
+	e.componentMtx.Unlock()
+	return q, nil
+}
+
+// ParseQuery compiles a query written in the query DSL for the engine, resolving type names registered on the
+// serializer or the engine.
+func (s *Serializer) ParseQuery(engine *Engine, query string) (*Query, error) {
+	return engine.compileFilter(ParseQueryFilter(query), func(name string) (reflect.Type, error) {
+		return s.typeOf(engine, name)
+	})
+}
+
+// compileFilter returns the query plan of the filter, resolving its type names using resolve.
+func (e *Engine) compileFilter(f QueryFilter, resolve func(name string) (reflect.Type, error)) (*Query, error) {
+	masks := [2]Mask{}
+	for i, names := range [2][]string{f.With, f.Without} {
+		for _, name := range names {
+			t, err := resolve(name)
+			if err != nil {
+				return nil, err
+			}
+
+			e.componentMtx.Lock()
+			bit := e.componentType(t)
+			e.componentMtx.Unlock()
+			masks[i].bits.set(bit)
+		}
+	}
+	return e.Query(masks[0], masks[1]), nil
+}
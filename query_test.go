@@ -0,0 +1,95 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Query(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	moving := &testEntity{name: "moving"}
+	e.AddComponents(moving, floater{f: 1}, floater{f: 2}, velocity{v: 1})
+	floating := &testEntity{name: "floating"}
+	e.AddComponents(floating, floater{})
+	frozen := &testEntity{name: "frozen"}
+	e.AddComponents(frozen, floater{}, velocity{}, playerData{})
+
+	with := tinyecs.MaskOf[floater](e).Or(tinyecs.MaskOf[velocity](e))
+	without := tinyecs.MaskOf[playerData](e)
+	q := e.Query(with, without)
+	assert.Same(t, q, e.Query(with, without))
+	assert.NotSame(t, q, e.Query(with, tinyecs.Mask{}))
+
+	assert.True(t, q.Matches(moving))
+	assert.False(t, q.Matches(floating))
+	assert.False(t, q.Matches(frozen))
+
+	collect := func() []string {
+		var names []string
+		q.Each(func(entity any) {
+			names = append(names, entity.(*testEntity).name)
+		})
+		return names
+	}
+
+	// Without storage, every entity is scanned.
+	assert.Equal(t, []string{"moving"}, collect())
+
+	// With storage, the floaters drive the iteration, and moving is visited once despite having two.
+	tinyecs.Each(e, func(id uint64, f floater) {})
+	assert.Equal(t, []string{"moving"}, collect())
+
+	// Changes made during the query are applied afterwards.
+	assert.Equal(t, uint64(1), q.Each(func(entity any) {
+		e.AddComponents(floating, velocity{})
+	}))
+	assert.ElementsMatch(t, []string{"moving", "floating"}, collect())
+}
+
+func Test_ParseQueryFilter(t *testing.T) {
+	f := tinyecs.ParseQueryFilter("position+velocity !frozen ! +")
+	assert.Equal(t, tinyecs.QueryFilter{With: []string{"position", "velocity"}, Without: []string{"frozen"}}, f)
+	assert.Equal(t, "position+velocity !frozen", f.String())
+	assert.Equal(t, tinyecs.QueryFilter{}, tinyecs.ParseQueryFilter(""))
+
+	assert.True(t, f.MatchesTypes([]string{"velocity", "position", "name"}))
+	assert.False(t, f.MatchesTypes([]string{"position"}))
+	assert.False(t, f.MatchesTypes([]string{"position", "velocity", "frozen"}))
+	assert.True(t, tinyecs.QueryFilter{}.MatchesTypes(nil))
+}
+
+func Test_ParseQuery(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.RegisterType[floater](e, "floater")
+	tinyecs.RegisterType[velocity](e, "velocity")
+	tinyecs.RegisterType[playerData](e, "player")
+
+	e.AddComponents(&testEntity{name: "moving"}, floater{}, velocity{})
+	e.AddComponents(&testEntity{name: "frozen"}, floater{}, velocity{}, playerData{})
+
+	q, err := e.ParseQuery("floater+velocity !player")
+	assert.NoError(t, err)
+	assert.Same(t, e.Query(tinyecs.MaskOf[floater](e).Or(tinyecs.MaskOf[velocity](e)), tinyecs.MaskOf[playerData](e)), q)
+
+	again, err := e.ParseQuery("floater+velocity !player")
+	assert.NoError(t, err)
+	assert.Same(t, q, again)
+
+	var names []string
+	q.Each(func(entity any) { names = append(names, entity.(*testEntity).name) })
+	assert.Equal(t, []string{"moving"}, names)
+
+	_, err = e.ParseQuery("floater !ghost")
+	assert.EqualError(t, err, `type "ghost" is not registered`)
+
+	// Registering a name again recompiles the queries using it.
+	q, err = e.ParseQuery("velocity !player")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), q.Each(func(entity any) {}))
+	tinyecs.RegisterType[floater](e, "player")
+	q, err = e.ParseQuery("velocity !player")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), q.Each(func(entity any) {}))
+}
@@ -0,0 +1,117 @@
+package tinyecs
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// QueryStats counts how many components a query scanned and how many of them matched, over all of its runs.
+// A low hit ratio means most of the scanned components were thrown away, so the query would benefit from a narrower
+// iteration, such as a concrete component type instead of an interface, or a Query driven by a dense storage.
+type QueryStats struct {
+	// Query describes the query, such as "Each[game.Position]" or "Query[game.Position+game.Velocity !game.Frozen]".
+	Query string
+	// Runs is the number of times the query was run.
+	Runs uint64
+	// Scanned is the number of components (or entities, for a Query) the query looked at.
+	Scanned uint64
+	// Matched is the number of components (or entities) which were passed to the query's function.
+	Matched uint64
+}
+
+// HitRatio returns the fraction of scanned components which matched, or 1 if nothing was scanned.
+func (s QueryStats) HitRatio() float64 {
+	if s.Scanned == 0 {
+		return 1
+	}
+	return float64(s.Matched) / float64(s.Scanned)
+}
+
+// queryStatsKey identifies a query: the name of the query function, along with typeKey of its type parameters, or the
+// *Query for compiled queries.
+type queryStatsKey struct {
+	query     string
+	entity    any
+	component any
+}
+
+// QueryStats returns the statistics of every query run on the engine since it was created or since ResetQueryStats,
+// sorted with the queries which discarded the most components first.
+func (e *Engine) QueryStats() []QueryStats {
+	e.statsMtx.Lock()
+	defer e.statsMtx.Unlock()
+
+	stats := make([]QueryStats, 0, len(e.queryStats))
+	for key, s := range e.queryStats {
+		s.Query = e.queryName(key)
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Scanned-a.Matched != b.Scanned-b.Matched {
+			return a.Scanned-a.Matched > b.Scanned-b.Matched
+		}
+		return a.Query < b.Query
+	})
+	return stats
+}
+
+// ResetQueryStats clears the statistics of every query.
+func (e *Engine) ResetQueryStats() {
+	e.statsMtx.Lock()
+	defer e.statsMtx.Unlock()
+
+	e.queryStats = make(map[queryStatsKey]*QueryStats)
+}
+
+// recordQuery adds a run of the query to its statistics, and counts the matched components as touched.
+// It only allocates the first time a query is run.
+func (e *Engine) recordQuery(key queryStatsKey, scanned, matched uint64) {
+	e.touch(matched)
+
+	e.statsMtx.Lock()
+	defer e.statsMtx.Unlock()
+
+	s, ok := e.queryStats[key]
+	if !ok {
+		s = &QueryStats{}
+		e.queryStats[key] = s
+	}
+	s.Runs++
+	s.Scanned += scanned
+	s.Matched += matched
+}
+
+// queryName describes the query identified by the key.
+func (e *Engine) queryName(key queryStatsKey) string {
+	if q, ok := key.component.(*Query); ok {
+		e.componentMtx.RLock()
+		defer e.componentMtx.RUnlock()
+
+		return "Query[" + e.maskName(q.with, "") + e.maskName(q.without, " !") + "]"
+	}
+
+	var params []string
+	for _, k := range []any{key.entity, key.component} {
+		if k != nil {
+			params = append(params, typeName(reflect.TypeOf(k).Elem()))
+		}
+	}
+	return key.query + "[" + strings.Join(params, ", ") + "]"
+}
+
+// maskName joins the names of the types in the mask with "+", after the prefix. An empty mask has an empty name.
+// The caller must hold componentMtx.
+func (e *Engine) maskName(m componentMask, prefix string) string {
+	var names []string
+	for bit := 0; bit < len(m)*64 && bit < len(e.typesByBit); bit++ {
+		if m.has(bit) {
+			names = append(names, typeName(e.typesByBit[bit]))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return prefix + strings.Join(names, "+")
+}
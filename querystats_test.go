@@ -0,0 +1,60 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type namer interface {
+	Name() string
+}
+
+func (p playerData) Name() string { return p.name }
+
+func TestEngine_QueryStats(t *testing.T) {
+	e := tinyecs.NewEngine()
+	for i := 0; i < 3; i++ {
+		e.AddComponents(&testEntity{}, velocity{v: 1}, floater{})
+	}
+	e.AddComponents(&testEntity{}, playerData{name: "hero"})
+
+	tinyecs.Each(e, func(id uint64, v velocity) {})
+	tinyecs.Each(e, func(id uint64, v velocity) {})
+	tinyecs.Each(e, func(id uint64, n namer) {})
+	e.Query(tinyecs.MaskOf[velocity](e), tinyecs.MaskOf[playerData](e)).Each(func(entity any) {})
+
+	stats := e.QueryStats()
+	assert.Equal(t, []tinyecs.QueryStats{
+		{Query: "Each[tinyecs_test.namer]", Runs: 1, Scanned: 7, Matched: 1},
+		{Query: "Each[tinyecs_test.velocity]", Runs: 2, Scanned: 6, Matched: 6},
+		{Query: "Query[tinyecs_test.velocity !tinyecs_test.playerData]", Runs: 1, Scanned: 3, Matched: 3},
+	}, stats)
+	assert.InDelta(t, 1.0/7, stats[0].HitRatio(), 1e-9)
+	assert.Equal(t, 1.0, tinyecs.QueryStats{}.HitRatio())
+
+	e.ResetQueryStats()
+	assert.Empty(t, e.QueryStats())
+}
+
+func TestEngine_QueryStatsCountDespawned(t *testing.T) {
+	e := tinyecs.NewEngine()
+	doomed := &testEntity{}
+	e.AddComponents(doomed, velocity{})
+	e.AddComponents(&testEntity{}, velocity{})
+	e.DespawnDeferred(doomed)
+
+	// Components of despawned entities are scanned, but not matched.
+	tinyecs.Each(e, func(id uint64, v velocity) {})
+	tinyecs.EachWithEntity(e, func(entity any, id uint64, v velocity) {})
+	tinyecs.EachChunk(e, 0, func(vs []velocity, ids []uint64) {})
+	stats := e.QueryStats()
+	assert.ElementsMatch(t, []tinyecs.QueryStats{
+		{Query: "Each[tinyecs_test.velocity]", Runs: 1, Scanned: 2, Matched: 1},
+		{Query: "EachChunk[tinyecs_test.velocity]", Runs: 1, Scanned: 2, Matched: 1},
+		{Query: "EachWithEntity[tinyecs_test.velocity]", Runs: 1, Scanned: 2, Matched: 1},
+	}, stats)
+	for _, s := range stats {
+		assert.Equal(t, 0.5, s.HitRatio())
+	}
+}
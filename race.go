@@ -0,0 +1,102 @@
+package tinyecs
+
+import "fmt"
+
+// Race is a pair of systems of the same stage which both wrote components of the same type during a frame.
+// Such systems can not safely run in parallel, since the order of their writes would be undefined.
+type Race struct {
+	// Frame is the frame the race was first detected in.
+	Frame uint64
+	Stage Stage
+	// Type is the name of the component type, and Systems the systems writing it, in the order they wrote it.
+	Type    string
+	Systems [2]string
+}
+
+// String describes the race.
+func (r Race) String() string {
+	return fmt.Sprintf("systems %q and %q of stage %q both write %s", r.Systems[0], r.Systems[1], r.Stage, r.Type)
+}
+
+// DetectRaces makes the engine track which system writes every component type during each stage of a frame, and
+// record a Race whenever a second system of the same stage writes a type, so that systems can be checked before
+// running a stage's systems in parallel. Each race is recorded once, and logged if the engine has a logger.
+// Adding, deleting and setting components count as writes; writes made outside of systems are ignored.
+//
+//	e := tinyecs.NewEngine(tinyecs.DetectRaces(), tinyecs.WithLogger(log.Default()))
+func DetectRaces() Option {
+	return func(e *Engine) {
+		e.races = &raceDetector{owners: make(map[int]*scheduledSystem), seen: make(map[raceKey]bool)}
+	}
+}
+
+// Races returns the races detected so far, in the order they were detected.
+// It returns nil unless the engine was created with DetectRaces.
+func (e *Engine) Races() []Race {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	if e.races == nil {
+		return nil
+	}
+	return append([]Race(nil), e.races.races...)
+}
+
+// raceDetector tracks the systems writing each component type during a stage.
+type raceDetector struct {
+	stage Stage
+	// owners holds the first system to write each type bit during the stage.
+	owners map[int]*scheduledSystem
+	seen   map[raceKey]bool
+	races  []Race
+}
+
+// raceKey identifies a race, so that it is only recorded once.
+type raceKey struct {
+	stage   Stage
+	bit     int
+	systems [2]string
+}
+
+// beginStageWrites forgets the owners of the previous stage.
+func (e *Engine) beginStageWrites(stage Stage) {
+	if e.races == nil {
+		return
+	}
+
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	e.races.stage = stage
+	for bit := range e.races.owners {
+		delete(e.races.owners, bit)
+	}
+}
+
+// recordWrite records that the running system wrote a component of the type bit.
+// The caller must hold componentMtx.
+func (e *Engine) recordWrite(bit int) {
+	d := e.races
+	if d == nil || e.system == nil {
+		return
+	}
+
+	owner, ok := d.owners[bit]
+	if !ok {
+		d.owners[bit] = e.system
+		return
+	}
+	if owner == e.system {
+		return
+	}
+
+	key := raceKey{stage: d.stage, bit: bit, systems: [2]string{owner.name, e.system.name}}
+	if d.seen[key] {
+		return
+	}
+	d.seen[key] = true
+
+	race := Race{Frame: e.frame, Stage: d.stage, Type: e.typesByBit[bit].String(), Systems: key.systems}
+	d.races = append(d.races, race)
+	e.logf("race detected: %s", race)
+}
@@ -0,0 +1,46 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_DetectRaces(t *testing.T) {
+	logger := &recordingLogger{}
+	e := tinyecs.NewEngine(tinyecs.DetectRaces(), tinyecs.WithLogger(logger))
+
+	entity := &testEntity{}
+	e.AddComponents(entity, velocity{}, floater{})
+	_, vid, _ := tinyecs.Get[velocity](e, entity)
+	_, fid, _ := tinyecs.Get[floater](e, entity)
+
+	e.AddSystem(tinyecs.StageUpdate, "physics", func(engine *tinyecs.Engine) {
+		tinyecs.Set(engine, vid, velocity{v: 1})
+		tinyecs.Set(engine, fid, floater{f: 1})
+	})
+	e.AddSystem(tinyecs.StageUpdate, "ai", func(engine *tinyecs.Engine) {
+		tinyecs.Set(engine, vid, velocity{v: 2})
+	})
+	// Systems of different stages never overlap.
+	e.AddSystem(tinyecs.StagePostUpdate, "render", func(engine *tinyecs.Engine) {
+		tinyecs.Set(engine, fid, floater{f: 2})
+	})
+
+	// Writes outside of systems are not tracked.
+	tinyecs.Set(e, vid, velocity{v: 3})
+
+	e.Update(0)
+	e.Update(0)
+
+	races := e.Races()
+	assert.Equal(t, []tinyecs.Race{{
+		Frame:   0,
+		Stage:   tinyecs.StageUpdate,
+		Type:    "tinyecs_test.velocity",
+		Systems: [2]string{"physics", "ai"},
+	}}, races)
+	assert.Equal(t, []string{`tinyecs: race detected: systems "physics" and "ai" of stage "update" both write tinyecs_test.velocity`}, logger.lines)
+
+	assert.Nil(t, tinyecs.NewEngine().Races())
+}
@@ -0,0 +1,143 @@
+package tinyecs
+
+// componentLog records the components of a type which were added and removed, in the order it happened.
+type componentLog struct {
+	added   []loggedComponent
+	removed []loggedComponent
+}
+
+// loggedComponent is a component which was added or removed when the engine's change sequence was at seq.
+type loggedComponent struct {
+	seq       uint64
+	id        uint64
+	entity    any
+	component any
+}
+
+// EachAdded calls f with every component of type T added since the running system last ran, along with its entity and
+// id. Outside of systems, it reports the components added since the previous frame ended.
+// Components which have been deleted again, or replaced with another type, are skipped, as are those of entities
+// despawned using DespawnDeferred.
+//
+//	tinyecs.EachAdded(e, func(entity any, id uint64, enemy Enemy) {
+//		e.AddComponents(entity.(*Monster), Health{Max: enemy.MaxHealth})
+//	})
+//
+// The engine starts tracking T on the first call, so that call only reports components added after it.
+// T must be a concrete component type. Disabled systems miss the changes made while they are disabled.
+func EachAdded[T any](engine *Engine, f func(entity any, id uint64, component T)) uint64 {
+	added, _ := engine.logSince(typeBit[T](engine))
+
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	var counter uint64
+	for _, l := range added {
+		if c, ok := engine.components[l.id].(T); ok && !engine.hidden(l.entity) {
+			counter++
+			f(l.entity, l.id, c)
+		}
+	}
+	return counter
+}
+
+// EachRemoved calls f with every component of type T deleted since the running system last ran, along with the entity
+// it belonged to, its id and its value at the time. Outside of systems, it reports the components deleted since the
+// previous frame ended. Components replaced with another type using Set count as deleted.
+//
+//	tinyecs.EachRemoved(e, func(entity any, id uint64, body Body) {
+//		world.DestroyBody(body.handle)
+//	})
+//
+// As with EachAdded, tracking starts on the first call, and T must be a concrete component type.
+func EachRemoved[T any](engine *Engine, f func(entity any, id uint64, component T)) uint64 {
+	_, removed := engine.logSince(typeBit[T](engine))
+
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	var counter uint64
+	for _, l := range removed {
+		counter++
+		f(l.entity, l.id, l.component.(T))
+	}
+	return counter
+}
+
+// logSince returns the components of the type bit added and removed since the running system last ran, or since the
+// previous frame ended. The type is tracked from then on.
+func (e *Engine) logSince(bit int) (added, removed []loggedComponent) {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	l, ok := e.componentLogs[bit]
+	if !ok {
+		l = &componentLog{}
+		e.componentLogs[bit] = l
+	}
+
+	since := e.frameSeq
+	if e.system != nil {
+		since = e.system.seen
+	}
+	return loggedSince(l.added, since), loggedSince(l.removed, since)
+}
+
+// loggedSince returns the entries of the log recorded after seq.
+func loggedSince(log []loggedComponent, seq uint64) []loggedComponent {
+	for i := len(log); i > 0; i-- {
+		if log[i-1].seq <= seq {
+			return log[i:]
+		}
+	}
+	return log
+}
+
+// logAdded records the component as added, if its type is tracked. The caller must hold componentMtx.
+func (e *Engine) logAdded(bit int, id uint64, entity any, component any) {
+	if l, ok := e.componentLogs[bit]; ok {
+		e.changeSeq++
+		l.added = append(l.added, loggedComponent{seq: e.changeSeq, id: id, entity: entity, component: component})
+	}
+}
+
+// logRemoved records the component as removed, if its type is tracked. The caller must hold componentMtx.
+func (e *Engine) logRemoved(bit int, id uint64, entity any, component any) {
+	if l, ok := e.componentLogs[bit]; ok {
+		e.changeSeq++
+		l.removed = append(l.removed, loggedComponent{seq: e.changeSeq, id: id, entity: entity, component: component})
+	}
+}
+
+// trimLogs marks the end of a frame, and forgets the changes which every enabled system has seen.
+// The caller must hold componentMtx.
+func (e *Engine) trimLogs() {
+	e.frameSeq = e.changeSeq
+	if len(e.componentLogs) == 0 {
+		return
+	}
+
+	seen := e.frameSeq
+	for _, s := range e.stages {
+		for _, system := range s.systems {
+			if !system.disabled && system.seen < seen {
+				seen = system.seen
+			}
+		}
+	}
+
+	for _, l := range e.componentLogs {
+		l.added = trimLog(l.added, seen)
+		l.removed = trimLog(l.removed, seen)
+	}
+}
+
+// trimLog removes the entries recorded up to seq, reusing the log's backing array.
+func trimLog(log []loggedComponent, seq uint64) []loggedComponent {
+	kept := loggedSince(log, seq)
+	n := copy(log, kept)
+	for i := n; i < len(log); i++ {
+		log[i] = loggedComponent{}
+	}
+	return log[:n]
+}
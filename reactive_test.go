@@ -0,0 +1,58 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_EachAddedRemoved(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var added, removed []float64
+	e.AddSystem(tinyecs.StageUpdate, "reactive", func(engine *tinyecs.Engine) {
+		tinyecs.EachAdded(engine, func(entity any, id uint64, v velocity) {
+			added = append(added, v.v)
+		})
+		tinyecs.EachRemoved(engine, func(entity any, id uint64, v velocity) {
+			removed = append(removed, v.v)
+		})
+	})
+
+	// The first run starts tracking velocity.
+	e.Update(0)
+	assert.Empty(t, added)
+
+	entity := &testEntity{}
+	e.AddComponents(entity, velocity{v: 1}, velocity{v: 2})
+	e.Update(0)
+	assert.Equal(t, []float64{1, 2}, added)
+	assert.Empty(t, removed)
+
+	// Changes are only reported once.
+	e.Update(0)
+	assert.Equal(t, []float64{1, 2}, added)
+
+	_, id, _ := tinyecs.Get[velocity](e, entity)
+	e.DeleteComponent(velocity{v: 2})
+	tinyecs.Set(e, id, floater{f: 3})
+	e.AddComponents(entity, velocity{v: 4})
+	e.DeleteComponent(velocity{v: 4})
+	e.Update(0)
+	// The velocity deleted again before the system ran is not reported as added.
+	assert.Equal(t, []float64{1, 2}, added)
+	assert.ElementsMatch(t, []float64{1, 2, 4}, removed)
+}
+
+func Test_EachAddedOutsideSystems(t *testing.T) {
+	e := tinyecs.NewEngine()
+	count := func() uint64 {
+		return tinyecs.EachAdded(e, func(entity any, id uint64, v velocity) {})
+	}
+	assert.Equal(t, uint64(0), count())
+
+	e.AddComponents(&testEntity{}, velocity{v: 1})
+	assert.Equal(t, uint64(1), count())
+	e.EndFrame()
+	assert.Equal(t, uint64(0), count())
+}
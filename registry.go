@@ -0,0 +1,100 @@
+package tinyecs
+
+import "reflect"
+
+// TypeID is the small integer identifying a component type within an engine, which is also its bit in masks.
+// IDs are assigned in the order types are first seen, so they are only stable across runs when types are registered
+// up front using RegisterType.
+type TypeID int
+
+// RegisterType registers the type T under the name, and returns its TypeID.
+// Registering types is optional, but gives them stable IDs and lets every Serializer of the engine save and load them
+// without registering them on the serializer. Registering another type under the same name replaces it, leaving the
+// previous type unnamed.
+//
+//	tinyecs.RegisterType[Position](e, "position")
+func RegisterType[T any](engine *Engine, name string) TypeID {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	bit := typeBit[T](engine)
+	if old, ok := engine.typeNames[bit]; ok {
+		delete(engine.typesByName, old)
+	}
+	if other, ok := engine.typesByName[name]; ok && other != bit {
+		delete(engine.typeNames, other)
+	}
+	engine.typeNames[bit] = name
+	engine.typesByName[name] = bit
+	// Queries parsed before may have resolved the names to other types.
+	if len(engine.parsedQueries) > 0 {
+		engine.parsedQueries = make(map[string]*Query)
+	}
+	return TypeID(bit)
+}
+
+// TypeIDOf returns the TypeID of T, assigning it if T has not been seen by the engine yet.
+func TypeIDOf[T any](engine *Engine) TypeID {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	return TypeID(typeBit[T](engine))
+}
+
+// TypeName returns the name the type was registered under using RegisterType.
+func (e *Engine) TypeName(id TypeID) (string, bool) {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	name, ok := e.typeNames[int(id)]
+	return name, ok
+}
+
+// TypeIDByName returns the TypeID of the type registered under the name using RegisterType.
+func (e *Engine) TypeIDByName(name string) (TypeID, bool) {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	bit, ok := e.typesByName[name]
+	return TypeID(bit), ok
+}
+
+// typeKey returns a key identifying T, which can be looked up without reflection:
+// interfaces holding nil pointers of different types are never equal.
+func typeKey[T any]() any {
+	return (*T)(nil)
+}
+
+// typeBit returns the mask bit of T, assigning the next free bit on first use.
+// The caller must hold componentMtx for writing.
+func typeBit[T any](e *Engine) int {
+	key := typeKey[T]()
+	if bit, ok := e.typeKeys[key]; ok {
+		return bit
+	}
+
+	bit := e.componentType(reflect.TypeOf(key).Elem())
+	e.typeKeys[key] = bit
+	return bit
+}
+
+// lookupTypeBit returns the mask bit of T, or false if T has not been seen by the engine yet.
+// The caller must hold componentMtx.
+func lookupTypeBit[T any](e *Engine) (int, bool) {
+	if bit, ok := e.typeKeys[typeKey[T]()]; ok {
+		return bit, true
+	}
+	// T may have been seen through a component added as an interface value, without going through typeBit.
+	bit, ok := e.componentTypes[reflect.TypeOf(typeKey[T]()).Elem()]
+	return bit, ok
+}
+
+// registeredType returns the type registered on the engine under the name.
+// The caller must hold componentMtx.
+func (e *Engine) registeredType(name string) (reflect.Type, bool) {
+	bit, ok := e.typesByName[name]
+	if !ok {
+		return nil, false
+	}
+	return e.typesByBit[bit], true
+}
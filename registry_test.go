@@ -0,0 +1,72 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_RegisterType(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	id := tinyecs.RegisterType[position](e, "position")
+	assert.Equal(t, id, tinyecs.TypeIDOf[position](e))
+	assert.NotEqual(t, id, tinyecs.TypeIDOf[velocity](e))
+
+	name, ok := e.TypeName(id)
+	assert.True(t, ok)
+	assert.Equal(t, "position", name)
+	_, ok = e.TypeName(tinyecs.TypeIDOf[velocity](e))
+	assert.False(t, ok)
+
+	byName, ok := e.TypeIDByName("position")
+	assert.True(t, ok)
+	assert.Equal(t, id, byName)
+
+	// The type ID is the type's bit in masks, whichever way the type was first seen.
+	other := tinyecs.NewEngine()
+	other.AddComponents(&testEntity{}, floater{})
+	assert.Equal(t, tinyecs.TypeID(0), tinyecs.TypeIDOf[floater](other))
+	assert.Equal(t, 1, tinyecs.MaskOf[floater](other).Len())
+}
+
+func Test_SerializerUsesEngineRegistry(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.RegisterType[*savedEntity](e, "entity")
+	tinyecs.RegisterType[position](e, "position")
+
+	player := &savedEntity{Name: "player"}
+	e.AddComponents(player, position{X: 1})
+	e.AddEntity(player)
+
+	s := tinyecs.NewSerializer(nil)
+	data, err := s.Marshal(e)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"position"`)
+
+	loaded := tinyecs.NewEngine()
+	tinyecs.RegisterType[*savedEntity](loaded, "entity")
+	tinyecs.RegisterType[position](loaded, "position")
+	assert.NoError(t, s.Unmarshal(data, loaded))
+	assert.True(t, tinyecs.Diff(e, loaded).Empty())
+}
+
+func Test_RegisterTypeNameTaken(t *testing.T) {
+	e := tinyecs.NewEngine()
+	pos := tinyecs.RegisterType[position](e, "shared")
+	vel := tinyecs.RegisterType[velocity](e, "shared")
+
+	// The name moves to the new type, so the old one is no longer saved under a name decoding as another type.
+	_, ok := e.TypeName(pos)
+	assert.False(t, ok)
+	name, _ := e.TypeName(vel)
+	assert.Equal(t, "shared", name)
+	byName, _ := e.TypeIDByName("shared")
+	assert.Equal(t, vel, byName)
+
+	s := tinyecs.NewSerializer(nil)
+	tinyecs.Register[*testEntity](s, "entity")
+	e.AddComponents(&testEntity{}, position{X: 1})
+	_, err := s.Marshal(e)
+	assert.ErrorContains(t, err, "type tinyecs_test.position is not registered")
+}
@@ -0,0 +1,46 @@
+package tinyecs
+
+// Transform is a component placing an entity in 2D space.
+type Transform struct {
+	X, Y float64
+	// Rotation is in radians.
+	Rotation float64
+	// ScaleX and ScaleY scale the entity, where zero means no scaling.
+	ScaleX, ScaleY float64
+}
+
+// Sprite is a component which draws an entity at its Transform, using the graphics library of the game.
+// Draw is typically a closure around a sprite of the library, drawing into a batch so a frame needs few draw calls.
+// With Pixel, for example:
+//
+//	batch := pixel.NewBatch(&pixel.TrianglesData{}, sheet)
+//	sprite := pixel.NewSprite(sheet, frame)
+//	e.AddComponents(player, tinyecs.Transform{X: 100, Y: 100}, tinyecs.Sprite{Draw: func(t tinyecs.Transform) {
+//		sprite.Draw(batch, pixel.IM.Rotated(pixel.ZV, t.Rotation).Moved(pixel.V(t.X, t.Y)))
+//	}})
+type Sprite struct {
+	Draw func(t Transform)
+}
+
+// DrawSprites is a system which draws every entity with a Sprite and a Transform. Entities with a ZIndex are drawn in
+// draw order, after the ones without, which are drawn in the order their sprites were added.
+func DrawSprites(engine *Engine) {
+	draw := func(entity any) {
+		sprite, _, ok := Get[Sprite](engine, entity)
+		if !ok || sprite.Draw == nil {
+			return
+		}
+		if t, _, ok := Get[Transform](engine, entity); ok {
+			sprite.Draw(t)
+		}
+	}
+
+	EachEntity(engine, func(entity any, _ Sprite) {
+		if !Has[ZIndex](engine, entity) {
+			draw(entity)
+		}
+	})
+	engine.EachInDrawOrder(func(entity any, _ ZIndex) {
+		draw(entity)
+	})
+}
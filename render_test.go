@@ -0,0 +1,42 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeWindow struct {
+	frames int
+}
+
+func (w *fakeWindow) Closed() bool { return w.frames == 3 }
+func (w *fakeWindow) Update()      { w.frames++ }
+
+func TestRun_DrawSprites(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var drawn []string
+	sprite := func(name string) tinyecs.Sprite {
+		return tinyecs.Sprite{Draw: func(t tinyecs.Transform) {
+			drawn = append(drawn, name)
+		}}
+	}
+	e.AddComponents(&testEntity{}, tinyecs.Transform{}, sprite("front"), tinyecs.ZIndex{Z: 1})
+	e.AddComponents(&testEntity{}, tinyecs.Transform{}, sprite("back"), tinyecs.ZIndex{Z: -1})
+	e.AddComponents(&testEntity{}, tinyecs.Transform{}, sprite("ground"))
+	e.AddComponents(&testEntity{}, sprite("nowhere"))
+
+	var frames int
+	e.AddSystem(tinyecs.StagePostUpdate, "draw", func(e *tinyecs.Engine) {
+		frames++
+		drawn = nil
+		tinyecs.DrawSprites(e)
+	})
+
+	w := &fakeWindow{}
+	tinyecs.Run(e, w)
+	assert.Equal(t, 3, frames)
+	assert.Equal(t, uint64(3), e.Frame())
+	assert.Equal(t, []string{"ground", "back", "front"}, drawn)
+}
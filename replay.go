@@ -0,0 +1,245 @@
+package tinyecs
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"time"
+)
+
+// Replay is a recording of a simulation: the world it started from, the seed of the engine's random number generator,
+// and the input and commands of every tick. Replaying it on an engine with the same systems re-runs the simulation
+// exactly, as long as the systems are deterministic, which the world hashes recorded at checkpoints verify.
+// Replays can be saved using a Codec, such as JSONCodec.
+type Replay struct {
+	Seed int64 `json:"seed" msgpack:"seed"`
+	// World is the initial world, encoded using Serializer.Marshal.
+	World []byte       `json:"world" msgpack:"world"`
+	Ticks []ReplayTick `json:"ticks" msgpack:"ticks"`
+}
+
+// ReplayTick is a tick of a Replay.
+type ReplayTick struct {
+	Delta time.Duration `json:"delta" msgpack:"delta"`
+	// MouseX, MouseY, Pressed and Released are the input of the tick.
+	MouseX   float64 `json:"mouse_x" msgpack:"mouse_x"`
+	MouseY   float64 `json:"mouse_y" msgpack:"mouse_y"`
+	Pressed  []Key   `json:"pressed,omitempty" msgpack:"pressed"`
+	Released []Key   `json:"released,omitempty" msgpack:"released"`
+	// Commands holds the commands applied before the tick ran, in the order they were issued.
+	Commands []ReplayCommand `json:"commands,omitempty" msgpack:"commands"`
+	// Hash is the hash of the world after the tick ran, if the tick is a checkpoint, or zero otherwise.
+	Hash uint64 `json:"hash,omitempty" msgpack:"hash"`
+}
+
+// ReplayCommand is a recorded command, such as a network message, encoded as a type registered on the serializer.
+type ReplayCommand struct {
+	Type  string   `json:"type" msgpack:"type"`
+	Value RawValue `json:"value" msgpack:"value"`
+}
+
+// ReplayDivergedError is returned when the world hash of a replay does not match the recorded one, meaning that the
+// simulation is not deterministic or that the systems have changed since recording.
+type ReplayDivergedError struct {
+	Tick      int
+	Want, Got uint64
+}
+
+func (e *ReplayDivergedError) Error() string {
+	return fmt.Sprintf("replay diverged at tick %d: world hash is %016x, recorded %016x", e.Tick, e.Got, e.Want)
+}
+
+// Hash returns a hash of the world, computed from its snapshot, which equal worlds share.
+func (s *Serializer) Hash(engine *Engine) (uint64, error) {
+	data, err := s.Marshal(engine)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64(), nil
+}
+
+// Recorder records a Replay of an engine. Ticks are run through Update instead of the engine's own Update, and
+// commands through Command, so that they are recorded along with the input of the engine.
+//
+//	rec, err := s.NewRecorder(e, time.Now().UnixNano(), 60, applyCommand)
+//	for running {
+//		rec.Command(Chat{Text: "gg"})
+//		rec.Update(dt)
+//	}
+//	replay, err := rec.Replay()
+type Recorder struct {
+	engine     *Engine
+	serializer *Serializer
+	apply      func(engine *Engine, command any)
+	checkpoint int
+
+	replay   Replay
+	commands []ReplayCommand
+	queued   []any
+	err      error
+}
+
+// NewRecorder starts recording the engine, reseeding its random number generator with the seed. The world hash is
+// recorded every checkpoint ticks, if checkpoint is positive, and apply is called with every command.
+func (s *Serializer) NewRecorder(engine *Engine, seed int64, checkpoint int, apply func(engine *Engine, command any)) (*Recorder, error) {
+	world, err := s.Marshal(engine)
+	if err != nil {
+		return nil, fmt.Errorf("recording initial world: %w", err)
+	}
+	engine.Reseed(seed)
+
+	return &Recorder{
+		engine:     engine,
+		serializer: s,
+		apply:      apply,
+		checkpoint: checkpoint,
+		replay:     Replay{Seed: seed, World: world},
+	}, nil
+}
+
+// Command queues the command to be applied and recorded when the next tick runs.
+// Its type must be registered on the serializer or engine.
+func (r *Recorder) Command(command any) {
+	r.engine.componentMtx.RLock()
+	name, value, err := r.serializer.encode(r.engine, command)
+	r.engine.componentMtx.RUnlock()
+	if err != nil {
+		r.fail(fmt.Errorf("recording command: %w", err))
+		return
+	}
+	r.commands = append(r.commands, ReplayCommand{Type: name, Value: value})
+	r.queued = append(r.queued, command)
+}
+
+// Update applies the queued commands and runs a tick of the engine using Update, recording its input and commands.
+func (r *Recorder) Update(dt time.Duration) {
+	in := r.engine.Input()
+	tick := ReplayTick{
+		Delta:    dt,
+		MouseX:   in.MouseX,
+		MouseY:   in.MouseY,
+		Pressed:  sortedKeySet(in.pressed),
+		Released: sortedKeySet(in.released),
+		Commands: r.commands,
+	}
+	for _, command := range r.queued {
+		r.apply(r.engine, command)
+	}
+	r.commands, r.queued = nil, nil
+
+	r.engine.Update(dt)
+
+	if r.checkpoint > 0 && (len(r.replay.Ticks)+1)%r.checkpoint == 0 {
+		hash, err := r.serializer.Hash(r.engine)
+		if err != nil {
+			r.fail(fmt.Errorf("hashing tick %d: %w", len(r.replay.Ticks), err))
+		}
+		tick.Hash = hash
+	}
+	r.replay.Ticks = append(r.replay.Ticks, tick)
+}
+
+// Replay returns the recording so far, or the first error which occurred while recording.
+func (r *Recorder) Replay() (Replay, error) {
+	return r.replay, r.err
+}
+
+// fail records the first error.
+func (r *Recorder) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// ReplayPlayer plays a Replay back on an engine, one tick at a time, as spectator modes need.
+type ReplayPlayer struct {
+	engine     *Engine
+	serializer *Serializer
+	apply      func(engine *Engine, command any)
+
+	replay Replay
+	tick   int
+}
+
+// NewReplayPlayer loads the initial world of the replay into the engine, which should have the systems of the
+// recorded engine but no entities, and reseeds its random number generator. apply is called with every command.
+func (s *Serializer) NewReplayPlayer(replay Replay, engine *Engine, apply func(engine *Engine, command any)) (*ReplayPlayer, error) {
+	if err := s.Unmarshal(replay.World, engine); err != nil {
+		return nil, fmt.Errorf("loading initial world: %w", err)
+	}
+	engine.Reseed(replay.Seed)
+	return &ReplayPlayer{engine: engine, serializer: s, apply: apply, replay: replay}, nil
+}
+
+// Tick returns the number of ticks played so far.
+func (p *ReplayPlayer) Tick() int {
+	return p.tick
+}
+
+// Step plays the next tick, and verifies the world hash if the tick is a checkpoint, returning a
+// *ReplayDivergedError if it does not match. It returns io.EOF once every tick has been played.
+func (p *ReplayPlayer) Step() error {
+	if p.tick >= len(p.replay.Ticks) {
+		return io.EOF
+	}
+	tick := p.replay.Ticks[p.tick]
+
+	in := p.engine.Input()
+	in.MouseX, in.MouseY = tick.MouseX, tick.MouseY
+	for _, key := range tick.Pressed {
+		in.Press(key)
+	}
+	for _, key := range tick.Released {
+		in.Release(key)
+	}
+	for _, c := range tick.Commands {
+		command, err := p.serializer.decode(p.engine, c.Type, c.Value)
+		if err != nil {
+			return fmt.Errorf("tick %d: command: %w", p.tick, err)
+		}
+		p.apply(p.engine, command)
+	}
+
+	p.engine.Update(tick.Delta)
+	p.tick++
+
+	if tick.Hash != 0 {
+		hash, err := p.serializer.Hash(p.engine)
+		if err != nil {
+			return fmt.Errorf("tick %d: %w", p.tick-1, err)
+		}
+		if hash != tick.Hash {
+			return &ReplayDivergedError{Tick: p.tick - 1, Want: tick.Hash, Got: hash}
+		}
+	}
+	return nil
+}
+
+// Play plays every remaining tick, stopping at the first error.
+func (p *ReplayPlayer) Play() error {
+	for {
+		if err := p.Step(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// sortedKeySet returns the keys of the set in ascending order, or nil if it is empty.
+func sortedKeySet(set map[Key]bool) []Key {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]Key, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
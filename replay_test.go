@@ -0,0 +1,106 @@
+package tinyecs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReplayEngine returns an engine with a system moving positions randomly, and to the right while key 1 is pressed.
+func newReplayEngine() *tinyecs.Engine {
+	e := tinyecs.NewEngine()
+	e.AddSystem(tinyecs.StageUpdate, "wander", func(e *tinyecs.Engine) {
+		tinyecs.EachWithEntity(e, func(entity any, id uint64, pos position) {
+			pos.Y += e.Rand().Float64()
+			if e.Input().Pressed(1) {
+				pos.X++
+			}
+			tinyecs.Set(e, id, pos)
+		})
+	})
+	return e
+}
+
+// spawnCommand spawns an entity at the position.
+func spawnCommand(e *tinyecs.Engine, command any) {
+	spawned := &savedEntity{Name: "spawned"}
+	e.AddComponents(spawned, command.(position))
+	e.AddEntity(spawned)
+}
+
+func Test_ReplayPlayback(t *testing.T) {
+	s := newTestSerializer(nil)
+	e := newReplayEngine()
+	hero := &savedEntity{Name: "hero"}
+	e.AddComponents(hero, position{})
+	e.AddEntity(hero)
+
+	rec, err := s.NewRecorder(e, 42, 2, spawnCommand)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		if i == 1 {
+			e.Input().Press(1)
+			rec.Command(position{X: 10})
+		}
+		if i == 3 {
+			e.Input().Release(1)
+		}
+		rec.Update(time.Millisecond)
+	}
+	replay, err := rec.Replay()
+	assert.NoError(t, err)
+	assert.Len(t, replay.Ticks, 5)
+	assert.Equal(t, []tinyecs.Key{1}, replay.Ticks[1].Pressed)
+	assert.Len(t, replay.Ticks[1].Commands, 1)
+	assert.NotZero(t, replay.Ticks[1].Hash)
+	assert.Zero(t, replay.Ticks[2].Hash)
+
+	want, err := s.Hash(e)
+	assert.NoError(t, err)
+
+	// The replay survives encoding.
+	data, err := json.Marshal(replay)
+	assert.NoError(t, err)
+	var decoded tinyecs.Replay
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+
+	played := newReplayEngine()
+	p, err := s.NewReplayPlayer(decoded, played, spawnCommand)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Play())
+	assert.Equal(t, 5, p.Tick())
+
+	got, err := s.Hash(played)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Len(t, played.GetEntities(), 2)
+}
+
+func Test_ReplayDiverged(t *testing.T) {
+	s := newTestSerializer(nil)
+	e := newReplayEngine()
+	e.AddComponents(&savedEntity{Name: "hero"}, position{})
+
+	rec, err := s.NewRecorder(e, 1, 3, spawnCommand)
+	assert.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		rec.Update(time.Millisecond)
+	}
+	replay, err := rec.Replay()
+	assert.NoError(t, err)
+
+	// A different seed makes the random movement diverge at the first checkpoint.
+	replay.Seed = 2
+	p, err := s.NewReplayPlayer(replay, newReplayEngine(), spawnCommand)
+	assert.NoError(t, err)
+	err = p.Play()
+
+	var diverged *tinyecs.ReplayDivergedError
+	assert.True(t, errors.As(err, &diverged))
+	assert.Equal(t, 2, diverged.Tick)
+	assert.Equal(t, replay.Ticks[2].Hash, diverged.Want)
+}
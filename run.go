@@ -0,0 +1,90 @@
+package tinyecs
+
+import "time"
+
+// Window is the window of a game, as provided by its graphics library. A *pixelgl.Window of Pixel satisfies it.
+type Window interface {
+	// Closed returns whether the window has been closed, which ends the game.
+	Closed() bool
+	// Update presents the frame, and polls the input events of the window.
+	Update()
+}
+
+// Run runs the game until the window is closed: every frame it calls Update with the time elapsed since the previous
+// frame, which runs the systems of every stage, and then updates the window.
+//
+//	pixelgl.Run(func() {
+//		win, _ := pixelgl.NewWindow(pixelgl.WindowConfig{Title: "game", Bounds: pixel.R(0, 0, 1024, 768)})
+//		e.AddSystem(tinyecs.StagePostUpdate, "draw", func(e *tinyecs.Engine) {
+//			win.Clear(colornames.Black)
+//			batch.Clear()
+//			tinyecs.DrawSprites(e)
+//			batch.Draw(win)
+//		})
+//		tinyecs.Run(e, win)
+//	})
+//
+// The pixelecs package builds on this with camera and input handling and a batched sprite system.
+func Run(engine *Engine, window Window) {
+	Loop{
+		Poll:    func(*Input) bool { return !window.Closed() },
+		Present: window.Update,
+	}.Run(engine)
+}
+
+// Loop is a main loop for libraries which leave event handling to the game, such as SDL2.
+//
+//	renderer, _ := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+//	tinyecs.Loop{
+//		FPS: 60,
+//		Poll: func(in *tinyecs.Input) bool {
+//			for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+//				switch event := event.(type) {
+//				case *sdl.QuitEvent:
+//					return false
+//				case *sdl.KeyboardEvent:
+//					if event.State == sdl.PRESSED {
+//						in.Press(tinyecs.Key(event.Keysym.Sym))
+//					} else {
+//						in.Release(tinyecs.Key(event.Keysym.Sym))
+//					}
+//				}
+//			}
+//			return true
+//		},
+//		Present: renderer.Present,
+//	}.Run(e)
+//
+// Textures are drawn by Sprite components calling renderer.Copy, using DrawSprites. The sdlecs package provides this
+// event pump along with a renderer resource and a texture system.
+type Loop struct {
+	// FPS is the maximum number of frames per second, or zero for no limit.
+	FPS float64
+	// Poll feeds the pending input events into the engine's Input, and returns false to end the loop.
+	Poll func(in *Input) bool
+	// Present shows the frame once all systems have run. It is optional.
+	Present func()
+}
+
+// Run runs frames until Poll returns false. Every frame it polls the input, calls Update with the time elapsed since
+// the previous frame, presents the frame, and then waits for the next frame if the frame rate is limited.
+func (l Loop) Run(engine *Engine) {
+	var interval time.Duration
+	if l.FPS > 0 {
+		interval = time.Duration(float64(time.Second) / l.FPS)
+	}
+
+	last := time.Now()
+	for l.Poll(engine.Input()) {
+		now := time.Now()
+		engine.Update(now.Sub(last))
+		last = now
+
+		if l.Present != nil {
+			l.Present()
+		}
+		if wait := interval - time.Since(now); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package tinyecs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// saveExtension is the file extension of save slots.
+const saveExtension = ".save"
+
+// maxSaveMetadataSize is the largest encoded metadata Save writes and the slots are read with, which leaves room for
+// a screenshot while keeping a corrupt length from making reads allocate an arbitrary amount of memory.
+const maxSaveMetadataSize = 1 << 20
+
+// SaveMetadata describes a save slot, and can be read without loading the slot.
+type SaveMetadata struct {
+	Slot     string        `json:"slot"`
+	SavedAt  time.Time     `json:"saved_at"`
+	Playtime time.Duration `json:"playtime"`
+	// Screenshot is an encoded image shown when picking a slot, such as a PNG.
+	// Together with the other fields, it must fit in 1 MiB once encoded as JSON.
+	Screenshot []byte `json:"screenshot,omitempty"`
+}
+
+// SaveManager stores engines in named save slots, one file per slot in a directory.
+// Saves are written atomically, so that a crash while saving leaves the previous save of the slot intact.
+type SaveManager struct {
+	Dir        string
+	Serializer *Serializer
+
+	// OnError is called by the system returned by Autosave when saving fails.
+	OnError func(err error)
+}
+
+// NewSaveManager returns a SaveManager which saves to the directory using the serializer.
+func NewSaveManager(dir string, s *Serializer) *SaveManager {
+	return &SaveManager{Dir: dir, Serializer: s}
+}
+
+// Save saves the engine to the slot, replacing any previous save.
+// The metadata's Slot is set to the slot, and SavedAt to the current time if it is zero.
+func (m *SaveManager) Save(slot string, engine *Engine, meta SaveMetadata) error {
+	path, err := m.path(slot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	meta.Slot = slot
+	if meta.SavedAt.IsZero() {
+		meta.SavedAt = time.Now()
+	}
+
+	// Write to a temporary file first, and only replace the slot once the save is complete and synced.
+	f, err := os.CreateTemp(m.Dir, slot+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := m.write(f, engine, meta); err != nil {
+		f.Close()
+		return fmt.Errorf("saving slot %q: %w", slot, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(m.Dir)
+}
+
+// syncDir syncs the directory, so that a rename within it survives a crash.
+// Windows does not support syncing directories, and makes renames durable on its own.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// write writes the length-prefixed metadata as JSON, followed by the engine as written by Encode.
+func (m *SaveManager) write(w io.Writer, engine *Engine, meta SaveMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSaveMetadataSize {
+		return fmt.Errorf("metadata is %d bytes, more than the maximum of %d", len(data), maxSaveMetadataSize)
+	}
+
+	var prefix [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(prefix[:], uint64(len(data)))
+	if _, err := w.Write(prefix[:n]); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return m.Serializer.Encode(w, engine)
+}
+
+// Load loads the slot into the engine and returns its metadata.
+func (m *SaveManager) Load(slot string, engine *Engine) (SaveMetadata, error) {
+	path, err := m.path(slot)
+	if err != nil {
+		return SaveMetadata{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return SaveMetadata{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	meta, err := readSaveMetadata(r)
+	if err != nil {
+		return SaveMetadata{}, fmt.Errorf("loading slot %q: %w", slot, err)
+	}
+	if err := m.Serializer.Decode(r, engine); err != nil {
+		return SaveMetadata{}, fmt.Errorf("loading slot %q: %w", slot, err)
+	}
+	return meta, nil
+}
+
+// Metadata returns the metadata of the slot without loading it.
+func (m *SaveManager) Metadata(slot string) (SaveMetadata, error) {
+	path, err := m.path(slot)
+	if err != nil {
+		return SaveMetadata{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return SaveMetadata{}, err
+	}
+	defer f.Close()
+
+	meta, err := readSaveMetadata(bufio.NewReader(f))
+	if err != nil {
+		return SaveMetadata{}, fmt.Errorf("reading slot %q: %w", slot, err)
+	}
+	return meta, nil
+}
+
+// readSaveMetadata reads the metadata at the start of a save.
+func readSaveMetadata(r *bufio.Reader) (SaveMetadata, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return SaveMetadata{}, fmt.Errorf("reading metadata length: %w", err)
+	}
+	if size > maxSaveMetadataSize {
+		return SaveMetadata{}, fmt.Errorf("metadata length %d exceeds the maximum of %d", size, maxSaveMetadataSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return SaveMetadata{}, fmt.Errorf("reading metadata: %w", err)
+	}
+
+	var meta SaveMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return SaveMetadata{}, fmt.Errorf("reading metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// Slots returns the metadata of every save slot, most recently saved first.
+// A missing directory has no slots.
+func (m *SaveManager) Slots() ([]SaveMetadata, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []SaveMetadata
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, saveExtension) {
+			continue
+		}
+
+		meta, err := m.Metadata(strings.TrimSuffix(name, saveExtension))
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, meta)
+	}
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].SavedAt.After(slots[j].SavedAt) })
+	return slots, nil
+}
+
+// Delete deletes the slot.
+func (m *SaveManager) Delete(slot string) error {
+	path, err := m.path(slot)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Autosave returns a system which saves the engine to the slot every interval of game time, as reported by DeltaTime.
+// meta is called for the metadata of each save, and may be nil.
+//
+//	e.AddSystem(tinyecs.StagePostUpdate, "autosave", saves.Autosave("autosave", 5*time.Minute, nil))
+func (m *SaveManager) Autosave(slot string, interval time.Duration, meta func() SaveMetadata) System {
+	var elapsed time.Duration
+	return func(engine *Engine) {
+		if elapsed += engine.DeltaTime(); elapsed < interval {
+			return
+		}
+		elapsed = 0
+
+		var md SaveMetadata
+		if meta != nil {
+			md = meta()
+		}
+		if err := m.Save(slot, engine, md); err != nil && m.OnError != nil {
+			m.OnError(err)
+		}
+	}
+}
+
+// path returns the path of the slot's file.
+func (m *SaveManager) path(slot string) (string, error) {
+	if slot == "" || slot == "." || slot == ".." || strings.ContainsAny(slot, `/\`) {
+		return "", fmt.Errorf("invalid save slot name %q", slot)
+	}
+	return filepath.Join(m.Dir, slot+saveExtension), nil
+}
@@ -0,0 +1,119 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_SaveManager(t *testing.T) {
+	saves := tinyecs.NewSaveManager(filepath.Join(t.TempDir(), "saves"), newTestSerializer(tinyecs.MsgpackCodec))
+
+	slots, err := saves.Slots()
+	assert.NoError(t, err)
+	assert.Empty(t, slots)
+
+	e := tinyecs.NewEngine()
+	player := &savedEntity{Name: "player"}
+	e.AddComponents(player, position{X: 3, Y: 4})
+	e.AddEntity(player)
+
+	early := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, saves.Save("one", e, tinyecs.SaveMetadata{SavedAt: early, Playtime: time.Hour}))
+	assert.NoError(t, saves.Save("two", e, tinyecs.SaveMetadata{Screenshot: []byte{1, 2, 3}}))
+
+	slots, err = saves.Slots()
+	assert.NoError(t, err)
+	assert.Len(t, slots, 2)
+	assert.Equal(t, "two", slots[0].Slot)
+	assert.Equal(t, []byte{1, 2, 3}, slots[0].Screenshot)
+	assert.Equal(t, "one", slots[1].Slot)
+	assert.Equal(t, time.Hour, slots[1].Playtime)
+	assert.True(t, early.Equal(slots[1].SavedAt))
+
+	loaded := tinyecs.NewEngine()
+	meta, err := saves.Load("one", loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", meta.Slot)
+	assert.True(t, tinyecs.Diff(e, loaded).Empty())
+
+	assert.NoError(t, saves.Delete("one"))
+	slots, err = saves.Slots()
+	assert.NoError(t, err)
+	assert.Len(t, slots, 1)
+
+	// No temporary files are left behind.
+	files, err := os.ReadDir(saves.Dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func Test_SaveManagerFailedSaveKeepsSlot(t *testing.T) {
+	saves := tinyecs.NewSaveManager(t.TempDir(), newTestSerializer(nil))
+
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{Name: "player"}, position{X: 1})
+	assert.NoError(t, saves.Save("slot", e, tinyecs.SaveMetadata{}))
+
+	// The velocity component is not registered, so saving fails half way through.
+	type velocity struct{ X float64 }
+	e.AddComponents(&savedEntity{Name: "enemy"}, velocity{X: 1})
+	assert.Error(t, saves.Save("slot", e, tinyecs.SaveMetadata{}))
+
+	loaded := tinyecs.NewEngine()
+	_, err := saves.Load("slot", loaded)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.GetComponents(), 1)
+
+	files, err := os.ReadDir(saves.Dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func Test_SaveManagerCorruptMetadataLength(t *testing.T) {
+	saves := tinyecs.NewSaveManager(t.TempDir(), newTestSerializer(nil))
+	// A metadata length of 2^62 followed by nothing.
+	corrupt := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}
+	assert.NoError(t, os.WriteFile(filepath.Join(saves.Dir, "corrupt.save"), corrupt, 0o644))
+
+	_, err := saves.Metadata("corrupt")
+	assert.EqualError(t, err, `reading slot "corrupt": metadata length 4611686018427387904 exceeds the maximum of 1048576`)
+	_, err = saves.Slots()
+	assert.Error(t, err)
+	_, err = saves.Load("corrupt", tinyecs.NewEngine())
+	assert.Error(t, err)
+
+	// Metadata too large to be read back is not saved either.
+	err = saves.Save("big", tinyecs.NewEngine(), tinyecs.SaveMetadata{Screenshot: make([]byte, 1<<20)})
+	assert.ErrorContains(t, err, "more than the maximum of 1048576")
+}
+
+func Test_SaveManagerInvalidSlot(t *testing.T) {
+	saves := tinyecs.NewSaveManager(t.TempDir(), newTestSerializer(nil))
+	e := tinyecs.NewEngine()
+	assert.Error(t, saves.Save("../escape", e, tinyecs.SaveMetadata{}))
+	assert.Error(t, saves.Save("", e, tinyecs.SaveMetadata{}))
+}
+
+func Test_Autosave(t *testing.T) {
+	saves := tinyecs.NewSaveManager(t.TempDir(), newTestSerializer(nil))
+
+	e := tinyecs.NewEngine()
+	var playtime time.Duration
+	e.AddSystem(tinyecs.StageUpdate, "playtime", func(e *tinyecs.Engine) { playtime += e.DeltaTime() })
+	e.AddSystem(tinyecs.StagePostUpdate, "autosave", saves.Autosave("auto", time.Minute, func() tinyecs.SaveMetadata {
+		return tinyecs.SaveMetadata{Playtime: playtime}
+	}))
+
+	for i := 0; i < 5; i++ {
+		e.Update(20 * time.Second)
+	}
+
+	// Saved once after a minute of game time, and not again yet.
+	meta, err := saves.Metadata("auto")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, meta.Playtime)
+}
@@ -0,0 +1,326 @@
+package tinyecs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scene is a human-editable description of entities and their components, written in YAML.
+// Entity and component types are referred to by the names they were registered under with Register,
+// and their values are decoded using yaml.v3, so fields can be renamed with `yaml` tags.
+//
+//	entities:
+//	  - type: player
+//	    value: {name: Hero}
+//	    components:
+//	      - position: {x: 1, y: 2}
+//	      - velocity: {}
+//
+// Scenes can be composed of reusable pieces. Prefabs are entity templates which entities (and other prefabs) reference
+// by name, overriding individual fields of the value and components. Included scenes are loaded relative to the
+// including file; their entities are spawned first, and their prefabs can be referenced unless redefined.
+//
+//	include: [enemies.yaml]
+//	prefabs:
+//	  goblin:
+//	    type: enemy
+//	    value: {name: Goblin, hp: 10}
+//	    components:
+//	      - position: {x: 0, y: 0}
+//	entities:
+//	  - prefab: goblin
+//	    value: {name: Goblin chief}
+//	    components:
+//	      - position: {x: 5}
+type Scene struct {
+	Include  []string               `yaml:"include"`
+	Prefabs  map[string]SceneEntity `yaml:"prefabs"`
+	Entities []SceneEntity          `yaml:"entities"`
+}
+
+// SceneEntity is an entity in a scene.
+type SceneEntity struct {
+	// Prefab is the name of the prefab the entity is based on, if any.
+	// Type then defaults to the prefab's type, and the entity's value and components override the prefab's:
+	// mappings are merged key by key, and components are matched by type, with new types being added.
+	Prefab string    `yaml:"prefab"`
+	Type   string    `yaml:"type"`
+	Value  yaml.Node `yaml:"value"`
+	// Components holds the components of the entity, each as a map with a single key: the component type.
+	Components []map[string]yaml.Node `yaml:"components"`
+}
+
+// ParseScene parses a scene from YAML.
+func ParseScene(data []byte) (Scene, error) {
+	var scene Scene
+	if err := yaml.Unmarshal(data, &scene); err != nil {
+		return Scene{}, fmt.Errorf("parsing scene: %w", err)
+	}
+	return scene, nil
+}
+
+// LoadScene reads the scene file at the path and spawns its entities, using the types registered on the serializer.
+func (e *Engine) LoadScene(path string, s *Serializer) error {
+	var r sceneReader
+	scene, err := r.read(path, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := s.spawn(scene, e); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// sceneReader reads scene files along with the scenes they include.
+type sceneReader struct {
+	// files holds every file read so far.
+	files []string
+}
+
+// read reads and parses the scene file at the path, along with the scenes it includes.
+// including holds the files currently being read, to detect include cycles.
+func (r *sceneReader) read(path string, including []string) (Scene, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Scene{}, err
+	}
+	for _, p := range including {
+		if p == abs {
+			return Scene{}, fmt.Errorf("%s: scene includes itself", path)
+		}
+	}
+
+	r.files = append(r.files, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scene{}, err
+	}
+
+	scene, err := ParseScene(data)
+	if err != nil {
+		return Scene{}, fmt.Errorf("%s: %w", path, err)
+	}
+	scene, err = r.include(scene, filepath.Dir(path), append(including, abs))
+	if err != nil {
+		return Scene{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return scene, nil
+}
+
+// include merges the scenes included by the scene into it, resolving their paths relative to dir.
+func (r *sceneReader) include(scene Scene, dir string, including []string) (Scene, error) {
+	if len(scene.Include) == 0 {
+		return scene, nil
+	}
+
+	merged := Scene{Prefabs: make(map[string]SceneEntity)}
+	for _, path := range scene.Include {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		included, err := r.read(path, including)
+		if err != nil {
+			return Scene{}, err
+		}
+		for name, prefab := range included.Prefabs {
+			merged.Prefabs[name] = prefab
+		}
+		merged.Entities = append(merged.Entities, included.Entities...)
+	}
+
+	for name, prefab := range scene.Prefabs {
+		merged.Prefabs[name] = prefab
+	}
+	merged.Entities = append(merged.Entities, scene.Entities...)
+	return merged, nil
+}
+
+// Spawn adds the entities of the scene to the engine, along with their components.
+// Included scenes are loaded relative to the working directory.
+// Nothing is added if any entity or component fails to decode.
+func (s *Serializer) Spawn(scene Scene, engine *Engine) error {
+	var r sceneReader
+	scene, err := r.include(scene, ".", nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.spawn(scene, engine)
+	return err
+}
+
+// spawn adds the entities of the scene, whose includes have already been merged, and returns them.
+func (s *Serializer) spawn(scene Scene, engine *Engine) ([]ecsEntity, error) {
+	spawns, err := s.decodeScene(scene, engine)
+	if err != nil {
+		return nil, err
+	}
+	return addSpawns(spawns, engine), nil
+}
+
+// sceneSpawn is a decoded scene entity along with its components.
+type sceneSpawn struct {
+	entity     ecsEntity
+	components []any
+}
+
+// decodeScene decodes the entities of the scene, using the types registered on the serializer and the engine.
+func (s *Serializer) decodeScene(scene Scene, engine *Engine) ([]sceneSpawn, error) {
+	spawns := make([]sceneSpawn, 0, len(scene.Entities))
+	for i, ent := range scene.Entities {
+		ent, err := scene.resolve(ent, nil)
+		if err != nil {
+			return nil, fmt.Errorf("entity %d: %w", i, err)
+		}
+
+		entity, err := s.decodeNode(engine, ent.Type, &ent.Value)
+		if err != nil {
+			return nil, fmt.Errorf("entity %d: %w", i, err)
+		}
+		ecsEnt, ok := entity.(ecsEntity)
+		if !ok {
+			return nil, fmt.Errorf("entity %d: type %q does not embed tinyecs.Entity", i, ent.Type)
+		}
+
+		sp := sceneSpawn{entity: ecsEnt}
+		for j, c := range ent.Components {
+			if len(c) != 1 {
+				return nil, fmt.Errorf("entity %d: component %d must have exactly one type, has %d", i, j, len(c))
+			}
+			for name, value := range c {
+				value := value
+				component, err := s.decodeNode(engine, name, &value)
+				if err != nil {
+					return nil, fmt.Errorf("entity %d: component %d: %w", i, j, err)
+				}
+				sp.components = append(sp.components, component)
+			}
+		}
+		spawns = append(spawns, sp)
+	}
+	return spawns, nil
+}
+
+// addSpawns adds the decoded entities and their components to the engine, and returns the entities.
+func addSpawns(spawns []sceneSpawn, engine *Engine) []ecsEntity {
+	entities := make([]ecsEntity, len(spawns))
+	for i, sp := range spawns {
+		engine.AddComponents(sp.entity, sp.components...)
+		engine.AddEntity(sp.entity)
+		engine.loaded(sp.entity)
+		entities[i] = sp.entity
+	}
+	return entities
+}
+
+// decodeNode decodes a YAML node as the type registered under the name.
+// Pointer types are allocated even when the node is empty, so that every entity gets its own identity.
+func (s *Serializer) decodeNode(engine *Engine, name string, node *yaml.Node) (any, error) {
+	t, err := s.typeOf(engine, name)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.New(t)
+	target := v
+	if t.Kind() == reflect.Pointer {
+		v.Elem().Set(reflect.New(t.Elem()))
+		target = v.Elem()
+	}
+
+	if node.Kind != 0 {
+		if err := node.Decode(target.Interface()); err != nil {
+			return nil, fmt.Errorf("decoding %q: %w", name, err)
+		}
+	}
+	return v.Elem().Interface(), nil
+}
+
+// resolve applies the entity's overrides to the prefab it references, if any.
+// chain holds the prefabs currently being resolved, to detect reference cycles.
+func (scene Scene) resolve(ent SceneEntity, chain []string) (SceneEntity, error) {
+	if ent.Prefab == "" {
+		return ent, nil
+	}
+	for _, name := range chain {
+		if name == ent.Prefab {
+			return SceneEntity{}, fmt.Errorf("prefab %q references itself", name)
+		}
+	}
+
+	prefab, ok := scene.Prefabs[ent.Prefab]
+	if !ok {
+		return SceneEntity{}, fmt.Errorf("prefab %q is not defined", ent.Prefab)
+	}
+	base, err := scene.resolve(prefab, append(chain, ent.Prefab))
+	if err != nil {
+		return SceneEntity{}, err
+	}
+
+	resolved := SceneEntity{
+		Type:  base.Type,
+		Value: mergeNodes(base.Value, ent.Value),
+	}
+	if ent.Type != "" {
+		resolved.Type = ent.Type
+	}
+
+	resolved.Components = make([]map[string]yaml.Node, 0, len(base.Components)+len(ent.Components))
+	index := make(map[string]int)
+	for _, c := range base.Components {
+		for name := range c {
+			index[name] = len(resolved.Components)
+		}
+		resolved.Components = append(resolved.Components, c)
+	}
+	for _, c := range ent.Components {
+		for name, value := range c {
+			i, ok := index[name]
+			if !ok {
+				index[name] = len(resolved.Components)
+				resolved.Components = append(resolved.Components, map[string]yaml.Node{name: value})
+				continue
+			}
+			resolved.Components[i] = map[string]yaml.Node{name: mergeNodes(resolved.Components[i][name], value)}
+		}
+	}
+	return resolved, nil
+}
+
+// mergeNodes returns the base node overridden by the override node.
+// Mappings are merged key by key, while any other override replaces the base node entirely.
+// Neither node is modified.
+func mergeNodes(base, override yaml.Node) yaml.Node {
+	if override.Kind == 0 {
+		return base
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := base
+	merged.Content = append([]*yaml.Node(nil), base.Content...)
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+
+		found := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				v := mergeNodes(*merged.Content[j+1], *value)
+				merged.Content[j+1] = &v
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
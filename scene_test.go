@@ -0,0 +1,141 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testScene = `
+entities:
+  - type: entity
+    value: {name: Hero}
+    components:
+      - position: {x: 1, y: 2}
+      - inventory:
+          items: {potion: 2}
+  - type: entity
+    components:
+      - position: {}
+`
+
+func writeScene(t *testing.T, scene string) string {
+	path := filepath.Join(t.TempDir(), "scene.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(scene), 0o644))
+	return path
+}
+
+func Test_LoadScene(t *testing.T) {
+	e := tinyecs.NewEngine()
+	assert.NoError(t, e.LoadScene(writeScene(t, testScene), newTestSerializer(nil)))
+
+	entities := e.GetEntities()
+	assert.Len(t, entities, 2)
+	assert.Equal(t, "Hero", entities[0].(*savedEntity).Name)
+	assert.NotSame(t, entities[0], entities[1])
+
+	var positions []position
+	tinyecs.EachEntity(e, func(entity *savedEntity, p position) {
+		if entity.Name == "Hero" {
+			assert.Equal(t, position{X: 1, Y: 2}, p)
+		}
+		positions = append(positions, p)
+	})
+	assert.Len(t, positions, 2)
+
+	tinyecs.Each(e, func(id uint64, inv inventory) {
+		assert.Equal(t, map[string]int{"potion": 2}, inv.Items)
+	})
+}
+
+func Test_LoadSceneUnregisteredType(t *testing.T) {
+	e := tinyecs.NewEngine()
+	scene := `
+entities:
+  - type: entity
+    components:
+      - position: {x: 1}
+      - velocity: {x: 1}
+`
+	err := e.LoadScene(writeScene(t, scene), newTestSerializer(nil))
+	assert.ErrorContains(t, err, `"velocity" is not registered`)
+
+	// Nothing is spawned when the scene fails to load.
+	assert.Empty(t, e.GetEntities())
+	assert.Empty(t, e.GetComponents())
+}
+
+func Test_LoadSceneMissingFile(t *testing.T) {
+	e := tinyecs.NewEngine()
+	assert.Error(t, e.LoadScene(filepath.Join(t.TempDir(), "missing.yaml"), newTestSerializer(nil)))
+}
+
+func Test_LoadScenePrefabs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "common.yaml"), []byte(`
+prefabs:
+  crate:
+    type: entity
+    value: {name: Crate}
+    components:
+      - position: {x: 1, y: 1}
+      - inventory: {items: {nail: 5}}
+entities:
+  - prefab: crate
+`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "level.yaml"), []byte(`
+include: [common.yaml]
+prefabs:
+  big crate:
+    prefab: crate
+    value: {name: Big crate}
+    components:
+      - inventory: {items: {plank: 2}}
+entities:
+  - prefab: big crate
+    components:
+      - position: {x: 7}
+`), 0o644))
+
+	e := tinyecs.NewEngine()
+	assert.NoError(t, e.LoadScene(filepath.Join(dir, "level.yaml"), newTestSerializer(nil)))
+
+	entities := e.GetEntities()
+	assert.Len(t, entities, 2)
+	assert.Equal(t, "Crate", entities[0].(*savedEntity).Name)
+	assert.Equal(t, "Big crate", entities[1].(*savedEntity).Name)
+
+	tinyecs.EachEntity(e, func(entity *savedEntity, p position) {
+		if entity.Name == "Big crate" {
+			assert.Equal(t, position{X: 7, Y: 1}, p)
+		} else {
+			assert.Equal(t, position{X: 1, Y: 1}, p)
+		}
+	})
+	tinyecs.EachEntity(e, func(entity *savedEntity, inv inventory) {
+		if entity.Name == "Big crate" {
+			assert.Equal(t, map[string]int{"nail": 5, "plank": 2}, inv.Items)
+		} else {
+			assert.Equal(t, map[string]int{"nail": 5}, inv.Items)
+		}
+	})
+}
+
+func Test_LoadScenePrefabCycle(t *testing.T) {
+	e := tinyecs.NewEngine()
+	scene := `
+prefabs:
+  a: {prefab: b}
+  b: {prefab: a}
+entities:
+  - prefab: a
+`
+	assert.ErrorContains(t, e.LoadScene(writeScene(t, scene), newTestSerializer(nil)), "references itself")
+}
+
+func Test_LoadSceneIncludeCycle(t *testing.T) {
+	e := tinyecs.NewEngine()
+	assert.ErrorContains(t, e.LoadScene(writeScene(t, "include: [scene.yaml]"), newTestSerializer(nil)), "includes itself")
+}
@@ -0,0 +1,212 @@
+package tinyecs
+
+import (
+	"context"
+	"runtime/pprof"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Stage is a named phase of a frame. Update runs the systems of each stage in the order the stages were added.
+type Stage string
+
+// The stages every engine starts out with, in the order they run.
+const (
+	StagePreUpdate  Stage = "pre_update"
+	StageUpdate     Stage = "update"
+	StagePostUpdate Stage = "post_update"
+)
+
+// System is a function which is run once per frame by Update.
+type System func(engine *Engine)
+
+// scheduledSystem is a system along with the name it was added under.
+type scheduledSystem struct {
+	name     string
+	system   System
+	disabled bool
+
+	// labels are the pprof labels set while the system runs.
+	labels pprof.LabelSet
+	// duration is how long the system took the last time it ran.
+	duration time.Duration
+	// seen is the engine's change sequence when the system last finished running.
+	seen uint64
+}
+
+// scheduledStage holds the systems of a stage, in the order they were added.
+type scheduledStage struct {
+	stage   Stage
+	systems []scheduledSystem
+}
+
+// AddStage adds a stage which runs after all existing stages. Adding a stage which already exists does nothing.
+func (e *Engine) AddStage(stage Stage) {
+	if e.stageOf(stage) == nil {
+		e.stages = append(e.stages, &scheduledStage{stage: stage})
+	}
+}
+
+// AddSystem adds a system to the stage, where it runs after the systems already in it.
+// The stage is added if it does not exist yet.
+//
+//	e.AddSystem(tinyecs.StageUpdate, "movement", func(e *tinyecs.Engine) {
+//		tinyecs.Each[Velocity](e, func(id uint64, v Velocity) { ... })
+//	})
+func (e *Engine) AddSystem(stage Stage, name string, system System) {
+	e.AddStage(stage)
+
+	s := e.stageOf(stage)
+	s.systems = append(s.systems, scheduledSystem{
+		name:   name,
+		system: system,
+		labels: pprof.Labels("tinyecs_stage", string(stage), "tinyecs_system", name),
+	})
+}
+
+// stageOf returns the scheduled stage, or nil if it does not exist.
+func (e *Engine) stageOf(stage Stage) *scheduledStage {
+	for _, s := range e.stages {
+		if s.stage == stage {
+			return s
+		}
+	}
+	return nil
+}
+
+// Update runs a single frame: every stage in order along with its systems, followed by EndFrame.
+// dt is the time elapsed since the previous frame, which systems can read through DeltaTime.
+// Systems run with the pprof labels tinyecs_stage and tinyecs_system set, so that CPU profiles attribute samples to
+// the system which was running.
+func (e *Engine) Update(dt time.Duration) {
+	e.UpdateContext(context.Background(), dt)
+}
+
+// UpdateContext is like Update, but runs the systems with a context derived from ctx, which they can read through
+// Context. When a tracer is set using SetTracer, the frame's spans are children of the span in ctx.
+func (e *Engine) UpdateContext(ctx context.Context, dt time.Duration) {
+	e.delta = dt
+	ctx, frame := e.startSpan(ctx, "Update", attribute.Int64("tinyecs.frame", int64(e.frame)))
+	report := e.beginFrame()
+
+	for _, s := range e.stages {
+		stageStart := time.Now()
+		report.beginStage(s.stage)
+		e.beginStageWrites(s.stage)
+		e.deliverEvents(s.stage)
+		stageCtx, stage := e.startSpan(ctx, string(s.stage), attribute.String("tinyecs.stage", string(s.stage)))
+
+		for i := range s.systems {
+			system := &s.systems[i]
+			if system.disabled {
+				continue
+			}
+			pprof.Do(stageCtx, system.labels, func(ctx context.Context) {
+				ctx, span := e.startSpan(ctx, system.name,
+					attribute.String("tinyecs.stage", string(s.stage)),
+					attribute.String("tinyecs.system", system.name),
+				)
+				e.ctx, e.system = ctx, system
+				mallocs := report.mallocs()
+				start := time.Now()
+				system.system(e)
+				system.duration = time.Since(start)
+				system.seen = e.changeSeq
+				report.system(system.name, system.duration, mallocs)
+				e.endSpan(span)
+			})
+		}
+		e.endSpan(stage)
+		report.endStage(stageStart)
+	}
+	e.ctx, e.system = nil, nil
+
+	e.EndFrame()
+	report.end()
+	e.endSpan(frame)
+}
+
+// Context returns the context of the running system, which carries its pprof labels.
+// Outside of Update it returns context.Background.
+func (e *Engine) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// EndFrame marks the end of a frame, removing the entities despawned using DespawnDeferred, resetting all frame arenas,
+// applying the values of double-buffered components, publishing the changes of the frame to mirrors, forgetting the key presses of the frame, and delivering the events and channel messages sent during the frame.
+// It is called by Update, and only needs to be called directly when not using Update.
+func (e *Engine) EndFrame() {
+	e.despawnQueued()
+
+	e.componentMtx.Lock()
+	for _, a := range e.arenas {
+		a.Reset()
+	}
+	e.swapBuffers()
+	e.frame++
+	e.trimLogs()
+	e.publishMirrors()
+	e.componentMtx.Unlock()
+
+	e.input.endFrame()
+
+	e.deliverEvents("")
+	e.swapChannels()
+}
+
+// DeltaTime returns the time elapsed since the previous frame, as passed to Update.
+func (e *Engine) DeltaTime() time.Duration {
+	return e.delta
+}
+
+// Frame returns the number of frames which have ended.
+func (e *Engine) Frame() uint64 {
+	return e.frame
+}
+
+// SystemInfo describes a system added using AddSystem.
+type SystemInfo struct {
+	Stage   Stage
+	Name    string
+	Enabled bool
+	// Duration is how long the system took the last time it ran.
+	Duration time.Duration
+}
+
+// Systems returns every system in the order Update runs them.
+func (e *Engine) Systems() []SystemInfo {
+	var systems []SystemInfo
+	for _, s := range e.stages {
+		for _, system := range s.systems {
+			systems = append(systems, SystemInfo{
+				Stage:    s.stage,
+				Name:     system.name,
+				Enabled:  !system.disabled,
+				Duration: system.duration,
+			})
+		}
+	}
+	return systems
+}
+
+// EnableSystem enables or disables every system added under the name, and returns whether there were any.
+// Disabled systems are skipped by Update until they are enabled again.
+func (e *Engine) EnableSystem(name string, enabled bool) bool {
+	found := false
+	for _, s := range e.stages {
+		for i := range s.systems {
+			if s.systems[i].name == name {
+				s.systems[i].disabled = !enabled
+				found = true
+			}
+		}
+	}
+	if !found {
+		e.logf("EnableSystem called with unknown system %q", name)
+	}
+	return found
+}
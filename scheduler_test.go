@@ -0,0 +1,95 @@
+package tinyecs_test
+
+import (
+	"context"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func TestEngine_UpdateRunsStagesInOrder(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var order []string
+	record := func(name string) tinyecs.System {
+		return func(*tinyecs.Engine) {
+			order = append(order, name)
+		}
+	}
+
+	e.AddStage("render")
+	e.AddSystem("render", "draw", record("draw"))
+	e.AddSystem(tinyecs.StagePostUpdate, "camera", record("camera"))
+	e.AddSystem(tinyecs.StageUpdate, "movement", record("movement"))
+	e.AddSystem(tinyecs.StageUpdate, "collision", record("collision"))
+	e.AddSystem(tinyecs.StagePreUpdate, "input", record("input"))
+
+	e.Update(time.Millisecond)
+	assert.Equal(t, []string{"input", "movement", "collision", "camera", "draw"}, order)
+}
+
+func TestEngine_UpdateEndsFrame(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var delta time.Duration
+	e.AddSystem(tinyecs.StageUpdate, "timer", func(e *tinyecs.Engine) {
+		delta = e.DeltaTime()
+		tinyecs.FrameArena[velocity](e).New()
+	})
+
+	e.Update(16 * time.Millisecond)
+	e.Update(16 * time.Millisecond)
+
+	assert.Equal(t, 16*time.Millisecond, delta)
+	assert.Equal(t, uint64(2), e.Frame())
+	assert.Equal(t, 0, tinyecs.FrameArena[velocity](e).Len())
+}
+
+func Test_EnableSystem(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var ran []string
+	e.AddSystem(tinyecs.StageUpdate, "a", func(*tinyecs.Engine) { ran = append(ran, "a") })
+	e.AddSystem(tinyecs.StagePostUpdate, "b", func(*tinyecs.Engine) { ran = append(ran, "b") })
+
+	assert.True(t, e.EnableSystem("a", false))
+	assert.False(t, e.EnableSystem("missing", false))
+	assert.Equal(t, []tinyecs.SystemInfo{
+		{Stage: tinyecs.StageUpdate, Name: "a", Enabled: false},
+		{Stage: tinyecs.StagePostUpdate, Name: "b", Enabled: true},
+	}, e.Systems())
+
+	e.Update(0)
+	assert.Equal(t, []string{"b"}, ran)
+
+	e.EnableSystem("a", true)
+	e.Update(0)
+	assert.Equal(t, []string{"b", "a", "b"}, ran)
+}
+
+func Test_SystemsRunWithProfilerLabels(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	var stage, system string
+	e.AddSystem(tinyecs.StagePostUpdate, "physics", func(e *tinyecs.Engine) {
+		stage, _ = pprof.Label(e.Context(), "tinyecs_stage")
+		system, _ = pprof.Label(e.Context(), "tinyecs_system")
+	})
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "outer")
+	var value any
+	e.AddSystem(tinyecs.StageUpdate, "reader", func(e *tinyecs.Engine) {
+		value = e.Context().Value(key{})
+	})
+
+	e.UpdateContext(ctx, 0)
+	assert.Equal(t, "post_update", stage)
+	assert.Equal(t, "physics", system)
+	assert.Equal(t, "outer", value)
+
+	_, ok := pprof.Label(e.Context(), "tinyecs_system")
+	assert.False(t, ok)
+}
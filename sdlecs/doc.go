@@ -0,0 +1,17 @@
+// Package sdlecs runs a tinyecs engine in an SDL2 window using go-sdl2 (github.com/veandco/go-sdl2): a Game holds
+// the window and renderer resources, pumps SDL events into the engine's Input, and draws the Texture components of
+// entities at their tinyecs.Transform.
+//
+//	game, err := sdlecs.NewGame("game", 1024, 768)
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer game.Close()
+//
+//	e.AddComponents(player, tinyecs.Transform{X: 100, Y: 100}, sdlecs.Texture{Texture: playerTexture})
+//	e.AddSystem(tinyecs.StagePostUpdate, "draw", game.DrawTextures)
+//	game.FPS = 60
+//	game.Run(e)
+//
+// SDL2 requires cgo, so the package is only built with the sdl build tag: go build -tags sdl.
+package sdlecs
@@ -0,0 +1,150 @@
+//go:build sdl
+
+package sdlecs
+
+import (
+	"math"
+
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Texture is a component drawing a texture, or the Src part of it, centered on the entity's tinyecs.Transform.
+type Texture struct {
+	Texture *sdl.Texture
+	// Src is the part of the texture to draw, or nil for all of it.
+	Src *sdl.Rect
+	// W and H are the size to draw the texture at before scaling, where zero means the size of Src or the texture.
+	W, H int32
+}
+
+// Game holds the window and renderer resources of a game drawn using SDL2.
+type Game struct {
+	Window   *sdl.Window
+	Renderer *sdl.Renderer
+	// Background is the color the window is cleared with every frame.
+	Background sdl.Color
+	// FPS is the number of frames per second Run updates the engine at, or zero for no limit.
+	FPS float64
+}
+
+// NewGame initializes SDL2 and opens a centered window of the size, along with an accelerated renderer.
+func NewGame(title string, width, height int32) (*Game, error) {
+	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+		return nil, err
+	}
+	win, err := sdl.CreateWindow(title, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	if err != nil {
+		sdl.Quit()
+		return nil, err
+	}
+	renderer, err := sdl.CreateRenderer(win, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_PRESENTVSYNC)
+	if err != nil {
+		win.Destroy()
+		sdl.Quit()
+		return nil, err
+	}
+	return &Game{Window: win, Renderer: renderer, Background: sdl.Color{A: 255}}, nil
+}
+
+// Close destroys the renderer and window, and shuts SDL2 down.
+func (g *Game) Close() {
+	g.Renderer.Destroy()
+	g.Window.Destroy()
+	sdl.Quit()
+}
+
+// Run runs the engine until the window is closed, using a tinyecs.Loop at the game's FPS: every frame it pumps the
+// events into the engine's Input, calls Update, which runs the systems of every stage, and presents the renderer.
+func (g *Game) Run(engine *tinyecs.Engine) {
+	tinyecs.Loop{
+		FPS:     g.FPS,
+		Poll:    PumpEvents,
+		Present: g.Renderer.Present,
+	}.Run(engine)
+}
+
+// MouseButton returns the key identifying the mouse button, such as sdl.BUTTON_LEFT, in the Input.
+// SDL2 key codes below 8 are unused, so mouse buttons never clash with keys.
+func MouseButton(button uint8) tinyecs.Key {
+	return tinyecs.Key(button)
+}
+
+// PumpEvents feeds the pending SDL events into the input, and returns false once the window has been closed.
+// Keys are sdl.Keycode values, and mouse buttons are given by MouseButton.
+func PumpEvents(in *tinyecs.Input) bool {
+	running := true
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		switch event := event.(type) {
+		case *sdl.QuitEvent:
+			running = false
+		case *sdl.KeyboardEvent:
+			if event.Repeat != 0 {
+				continue
+			}
+			if event.State == sdl.PRESSED {
+				in.Press(tinyecs.Key(event.Keysym.Sym))
+			} else {
+				in.Release(tinyecs.Key(event.Keysym.Sym))
+			}
+		case *sdl.MouseMotionEvent:
+			in.MouseX, in.MouseY = float64(event.X), float64(event.Y)
+		case *sdl.MouseButtonEvent:
+			if event.State == sdl.PRESSED {
+				in.Press(MouseButton(event.Button))
+			} else {
+				in.Release(MouseButton(event.Button))
+			}
+		}
+	}
+	return running
+}
+
+// DrawTextures is a system clearing the renderer and drawing every entity with a Texture and a Transform.
+// Entities with a tinyecs.ZIndex are drawn in draw order, after the ones without.
+func (g *Game) DrawTextures(engine *tinyecs.Engine) {
+	c := g.Background
+	g.Renderer.SetDrawColor(c.R, c.G, c.B, c.A)
+	g.Renderer.Clear()
+
+	draw := func(entity any) {
+		tex, _, ok := tinyecs.Get[Texture](engine, entity)
+		if !ok || tex.Texture == nil {
+			return
+		}
+		if t, _, ok := tinyecs.Get[tinyecs.Transform](engine, entity); ok {
+			g.Renderer.CopyExF(tex.Texture, tex.Src, Dst(tex, t), t.Rotation*180/math.Pi, nil, sdl.FLIP_NONE)
+		}
+	}
+	tinyecs.EachEntity(engine, func(entity any, _ Texture) {
+		if !tinyecs.Has[tinyecs.ZIndex](engine, entity) {
+			draw(entity)
+		}
+	})
+	engine.EachInDrawOrder(func(entity any, _ tinyecs.ZIndex) {
+		draw(entity)
+	})
+}
+
+// Dst returns the rectangle the texture is drawn into at the transform, before rotation.
+// Negative scales are drawn unmirrored.
+func Dst(tex Texture, t tinyecs.Transform) *sdl.FRect {
+	w, h := tex.W, tex.H
+	if w == 0 || h == 0 {
+		if tex.Src != nil {
+			w, h = tex.Src.W, tex.Src.H
+		} else if tex.Texture != nil {
+			_, _, w, h, _ = tex.Texture.Query()
+		}
+	}
+
+	sx, sy := math.Abs(t.ScaleX), math.Abs(t.ScaleY)
+	if sx == 0 {
+		sx = 1
+	}
+	if sy == 0 {
+		sy = 1
+	}
+	fw, fh := float32(float64(w)*sx), float32(float64(h)*sy)
+	return &sdl.FRect{X: float32(t.X) - fw/2, Y: float32(t.Y) - fh/2, W: fw, H: fh}
+}
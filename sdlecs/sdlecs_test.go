@@ -0,0 +1,21 @@
+//go:build sdl
+
+package sdlecs_test
+
+import (
+	"testing"
+
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/kaiaverkvist/tinyecs/sdlecs"
+	"github.com/stretchr/testify/assert"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func Test_Dst(t *testing.T) {
+	tex := sdlecs.Texture{Src: &sdl.Rect{W: 16, H: 8}}
+	assert.Equal(t, &sdl.FRect{X: 92, Y: 96, W: 16, H: 8}, sdlecs.Dst(tex, tinyecs.Transform{X: 100, Y: 100}))
+	assert.Equal(t, &sdl.FRect{X: 84, Y: 96, W: 32, H: 8}, sdlecs.Dst(tex, tinyecs.Transform{X: 100, Y: 100, ScaleX: -2}))
+
+	tex.W, tex.H = 10, 10
+	assert.Equal(t, &sdl.FRect{X: -5, Y: -5, W: 10, H: 10}, sdlecs.Dst(tex, tinyecs.Transform{}))
+}
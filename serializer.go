@@ -0,0 +1,407 @@
+package tinyecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Codec encodes and decodes the values written by a Serializer.
+// Codecs must write a RawValue as-is, so that values encoded on their own can be embedded in a snapshot.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes values as JSON. It is slower and larger than MsgpackCodec, but easy to read and debug.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	rv, index := unknownJSON(reflect.ValueOf(v))
+	if index == nil {
+		return data, nil
+	}
+
+	// Replace the UnknownFields field by the fields it holds.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, jsonName(rv.Type().FieldByIndex(index)))
+	unknown := rv.FieldByIndex(index)
+	for _, key := range unknown.MapKeys() {
+		if _, ok := fields[key.String()]; !ok {
+			fields[key.String()] = json.RawMessage(unknown.MapIndex(key).Bytes())
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	rv, index := unknownJSON(reflect.ValueOf(v))
+	if index == nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// Not an object, so there are no fields to keep.
+		return nil
+	}
+	known := jsonFieldsOf(rv.Type())
+	for name, value := range fields {
+		if !known[strings.ToLower(name)] {
+			keepUnknown(rv.FieldByIndex(index), name, value)
+		}
+	}
+	return nil
+}
+
+var jsonFields sync.Map // map[reflect.Type]map[string]bool
+
+// unknownJSON dereferences v and returns it along with the index of its UnknownFields field,
+// or a nil index if v is not a struct with one.
+func unknownJSON(v reflect.Value) (reflect.Value, []int) {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, nil
+	}
+	return v, unknownFieldsOf(v.Type())
+}
+
+// jsonName returns the key of the struct field in JSON.
+func jsonName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return f.Name
+}
+
+// jsonFieldsOf returns the lowercased keys of the fields of the struct type t in JSON, which encoding/json matches
+// case-insensitively.
+func jsonFieldsOf(t reflect.Type) map[string]bool {
+	if fields, ok := jsonFields.Load(t); ok {
+		return fields.(map[string]bool)
+	}
+
+	fields := make(map[string]bool)
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if name, _, _ := strings.Cut(tag, ","); f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				collect(ft)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			fields[strings.ToLower(jsonName(f))] = true
+		}
+	}
+	collect(t)
+
+	jsonFields.Store(t, fields)
+	return fields
+}
+
+// RawValue is a value which has already been encoded by a Codec.
+type RawValue []byte
+
+// MarshalJSON returns the raw value, which must be valid JSON.
+func (r RawValue) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON stores a copy of the data.
+func (r *RawValue) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// Snapshot is the serialized form of an engine.
+type Snapshot struct {
+	Entities []EntitySnapshot `json:"entities" msgpack:"entities"`
+}
+
+// EntitySnapshot is the serialized form of an entity along with its components.
+type EntitySnapshot struct {
+	Type  string   `json:"type" msgpack:"type"`
+	Value RawValue `json:"value" msgpack:"value"`
+	// Added reports whether the entity had been added to the engine using AddEntity,
+	// as opposed to only having components linked to it.
+	Added      bool                `json:"added" msgpack:"added"`
+	Components []ComponentSnapshot `json:"components" msgpack:"components"`
+}
+
+// ComponentSnapshot is the serialized form of a component.
+type ComponentSnapshot struct {
+	ID    uint64   `json:"id" msgpack:"id"`
+	Type  string   `json:"type" msgpack:"type"`
+	Value RawValue `json:"value" msgpack:"value"`
+}
+
+// Serializer saves and loads engines using a Codec.
+// Since entities and components are stored as interfaces, every entity and component type has to be registered under
+// a name before it can be saved or loaded, using Register or, for all serializers of an engine, RegisterType. Components marked with MarkTransient are skipped.
+type Serializer struct {
+	Codec Codec
+
+	// Fallback is called when loading a component whose type is not registered, such as a component type which has
+	// since been removed. It may add a replacement component to the entity, or return nil to drop the component.
+	// Without a fallback, loading fails.
+	Fallback func(engine *Engine, entity any, component ComponentSnapshot) error
+
+	types   map[string]reflect.Type
+	names   map[reflect.Type]string
+	aliases map[string]string
+}
+
+// NewSerializer returns a Serializer which uses the codec, or JSONCodec if codec is nil.
+func NewSerializer(codec Codec) *Serializer {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	return &Serializer{
+		Codec:   codec,
+		types:   make(map[string]reflect.Type),
+		names:   make(map[reflect.Type]string),
+		aliases: make(map[string]string),
+	}
+}
+
+// Register registers the entity or component type T under the name.
+// Entities which are used as pointers should be registered as pointer types.
+//
+//	tinyecs.Register[*Player](s, "player")
+//	tinyecs.Register[Position](s, "position")
+func Register[T any](s *Serializer, name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	s.types[name] = t
+	s.names[t] = name
+}
+
+// Snapshot returns the serialized form of the engine.
+// Snapshots are deterministic: entities are in the order they were added, followed by the entities which were only
+// linked to components, and components are sorted by id, so equal engines produce identical output.
+func (s *Serializer) Snapshot(engine *Engine) (Snapshot, error) {
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	order, ids, added := engine.snapshotOrder()
+
+	snapshot := Snapshot{Entities: make([]EntitySnapshot, 0, len(order))}
+	for _, entity := range order {
+		ent, err := s.snapshotEntity(engine, entity, added[entity], ids[entity])
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Entities = append(snapshot.Entities, ent)
+	}
+
+	return snapshot, nil
+}
+
+// snapshotOrder returns the entities to serialize in a stable order: the added entities in the order they were added,
+// followed by the entities which were only linked to components, by their lowest component id.
+// ids holds the ids of the non-transient components of every entity in ascending order.
+// The caller must hold componentMtx.
+func (e *Engine) snapshotOrder() (order []any, ids map[any][]uint64, added map[any]bool) {
+	ids = make(map[any][]uint64)
+	added = make(map[any]bool)
+	for _, entity := range e.entities {
+		if !added[entity] {
+			order = append(order, entity)
+			ids[entity] = nil
+		}
+		added[entity] = true
+	}
+
+	sorted := make([]uint64, 0, len(e.links))
+	for id, link := range e.links {
+		if !e.transient.has(link.componentType) && !e.disabled[link.entity] {
+			sorted = append(sorted, id)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, id := range sorted {
+		entity := e.links[id].entity
+		if _, ok := ids[entity]; !ok {
+			order = append(order, entity)
+		}
+		ids[entity] = append(ids[entity], id)
+	}
+	return order, ids, added
+}
+
+// Marshal encodes the engine using the serializer's codec.
+func (s *Serializer) Marshal(engine *Engine) ([]byte, error) {
+	snapshot, err := s.Snapshot(engine)
+	if err != nil {
+		return nil, err
+	}
+	return s.Codec.Marshal(snapshot)
+}
+
+// Unmarshal decodes data produced by Marshal and loads it into the engine.
+func (s *Serializer) Unmarshal(data []byte, engine *Engine) error {
+	var snapshot Snapshot
+	if err := s.Codec.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	return s.Load(snapshot, engine)
+}
+
+// Load adds the entities and components of the snapshot to the engine.
+// Component IDs are kept when loading into an engine which does not use them yet, and reassigned otherwise.
+func (s *Serializer) Load(snapshot Snapshot, engine *Engine) error {
+	for _, ent := range snapshot.Entities {
+		if err := s.loadEntity(ent, engine); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadEntity adds the components of the entity snapshot to the engine, along with the entity if it had been added.
+func (s *Serializer) loadEntity(ent EntitySnapshot, engine *Engine) error {
+	entity, err := s.decode(engine, ent.Type, ent.Value)
+	if err != nil {
+		return fmt.Errorf("entity: %w", err)
+	}
+
+	for _, c := range ent.Components {
+		if _, err := s.typeOf(engine, c.Type); err != nil && s.Fallback != nil {
+			if err := s.Fallback(engine, entity, c); err != nil {
+				return fmt.Errorf("component %d: %w", c.ID, err)
+			}
+			continue
+		}
+
+		component, err := s.decode(engine, c.Type, c.Value)
+		if err != nil {
+			return fmt.Errorf("component %d: %w", c.ID, err)
+		}
+		engine.loadComponent(entity, c.ID, component)
+	}
+
+	if ent.Added {
+		e, ok := entity.(ecsEntity)
+		if !ok {
+			return fmt.Errorf("entity type %q does not embed tinyecs.Entity", ent.Type)
+		}
+		engine.AddEntity(e)
+	}
+
+	engine.loaded(entity)
+	return nil
+}
+
+// encode returns the registered name of the value's type along with the encoded value.
+// Types not registered on the serializer may be registered on the engine. The caller must hold componentMtx.
+func (s *Serializer) encode(engine *Engine, v any) (string, RawValue, error) {
+	name, ok := s.nameOf(engine, reflect.TypeOf(v))
+	if !ok {
+		return "", nil, fmt.Errorf("type %T is not registered", v)
+	}
+
+	data, err := s.Codec.Marshal(v)
+	if err != nil {
+		return "", nil, fmt.Errorf("encoding %q: %w", name, err)
+	}
+	return name, data, nil
+}
+
+// nameOf returns the name the type is registered under, on the serializer or otherwise on the engine.
+// The caller must hold componentMtx.
+func (s *Serializer) nameOf(engine *Engine, t reflect.Type) (string, bool) {
+	if name, ok := s.names[t]; ok {
+		return name, true
+	}
+	if bit, ok := engine.componentTypes[t]; ok {
+		name, ok := engine.typeNames[bit]
+		return name, ok
+	}
+	return "", false
+}
+
+// decode decodes the value as the type registered under the name.
+func (s *Serializer) decode(engine *Engine, name string, value RawValue) (any, error) {
+	t, err := s.typeOf(engine, name)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.New(t)
+	if err := s.Codec.Unmarshal(value, v.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding %q: %w", name, err)
+	}
+	return v.Elem().Interface(), nil
+}
+
+// loadComponent adds a loaded component, keeping its id unless it is already in use.
+func (e *Engine) loadComponent(entity any, id uint64, component any) {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if _, taken := e.components[id]; taken {
+		id = e.nextComponentID
+	}
+	e.insertComponent(entity, id, component)
+
+	if id >= e.nextComponentID {
+		e.nextComponentID = id + 1
+	}
+}
+
+// sortedKeys returns the keys of the map value in a stable order, so that codecs encode maps deterministically.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		}
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	})
+	return keys
+}
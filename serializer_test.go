@@ -0,0 +1,130 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type savedEntity struct {
+	tinyecs.Entity
+
+	Name string
+}
+
+type position struct {
+	X, Y float64
+}
+
+type inventory struct {
+	Items map[string]int
+	Slots []string `msgpack:"slots"`
+}
+
+func newTestSerializer(codec tinyecs.Codec) *tinyecs.Serializer {
+	s := tinyecs.NewSerializer(codec)
+	tinyecs.Register[*savedEntity](s, "entity")
+	tinyecs.Register[position](s, "position")
+	tinyecs.Register[inventory](s, "inventory")
+	return s
+}
+
+func Test_SerializerRoundTrip(t *testing.T) {
+	for name, codec := range map[string]tinyecs.Codec{
+		"json":     tinyecs.JSONCodec,
+		"msgpack":  tinyecs.MsgpackCodec,
+		"protobuf": tinyecs.ProtobufCodec,
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := tinyecs.NewEngine()
+
+			player := &savedEntity{Name: "player"}
+			e.AddComponents(player,
+				position{X: 1, Y: 2},
+				inventory{Items: map[string]int{"potion": 3}, Slots: []string{"sword"}},
+			)
+			e.AddEntity(player)
+
+			// Only linked to components, never added.
+			e.AddComponents(&savedEntity{Name: "marker"}, position{X: -5})
+
+			s := newTestSerializer(codec)
+			data, err := s.Marshal(e)
+			assert.NoError(t, err)
+
+			loaded := tinyecs.NewEngine()
+			assert.NoError(t, s.Unmarshal(data, loaded))
+
+			assert.Len(t, loaded.GetEntities(), 1)
+			assert.Equal(t, "player", loaded.GetEntities()[0].(*savedEntity).Name)
+			assert.Equal(t, e.GetComponents(), loaded.GetComponents())
+
+			var names []string
+			tinyecs.EachEntity[*savedEntity, position](loaded, func(entity *savedEntity, component position) {
+				names = append(names, entity.Name)
+			})
+			assert.ElementsMatch(t, []string{"player", "marker"}, names)
+
+			// The loaded entity is linked to its components.
+			assert.True(t, tinyecs.Has[inventory](loaded, loaded.GetEntities()[0]))
+		})
+	}
+}
+
+func Test_SerializerKeepsOrReassignsIDs(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{}, position{X: 1})
+
+	s := newTestSerializer(nil)
+	data, err := s.Marshal(e)
+	assert.NoError(t, err)
+
+	// Loading into an engine which already uses the id assigns a new one.
+	assert.NoError(t, s.Unmarshal(data, e))
+	assert.Len(t, e.GetComponents(), 2)
+
+	e.AddComponents(&savedEntity{}, position{X: 2})
+	assert.Len(t, e.GetComponents(), 3)
+}
+
+func Test_SerializerUnregisteredType(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{}, velocity{})
+
+	_, err := newTestSerializer(nil).Marshal(e)
+	assert.ErrorContains(t, err, "tinyecs_test.velocity is not registered")
+}
+
+func Test_SerializerDeterministic(t *testing.T) {
+	for name, codec := range map[string]tinyecs.Codec{
+		"json":     tinyecs.JSONCodec,
+		"msgpack":  tinyecs.MsgpackCodec,
+		"protobuf": tinyecs.ProtobufCodec,
+	} {
+		t.Run(name, func(t *testing.T) {
+			build := func() *tinyecs.Engine {
+				e := tinyecs.NewEngine()
+				for i := 0; i < 20; i++ {
+					ent := &savedEntity{Name: "entity"}
+					e.AddComponents(ent,
+						position{X: float64(i)},
+						inventory{Items: map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}},
+					)
+					if i%3 == 0 {
+						e.AddEntity(ent)
+					}
+				}
+				return e
+			}
+
+			s := newTestSerializer(codec)
+			first, err := s.Marshal(build())
+			assert.NoError(t, err)
+			for i := 0; i < 10; i++ {
+				data, err := s.Marshal(build())
+				assert.NoError(t, err)
+				assert.Equal(t, first, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package tinyecs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// componentStorage is the type-erased interface of a denseStorage, used where the component type is not known statically.
+type componentStorage interface {
+	insert(id uint64, component any)
+	remove(id uint64)
+	set(id uint64, component any)
+	len() int
+	componentIDs() []uint64
+	capacity() (components int, indexed int)
+	compact()
+}
+
+// denseStorage holds every component of type T in one contiguous slice, along with a parallel slice of component IDs.
+// Removal swaps the last component into the freed slot, so the order is not stable.
+type denseStorage[T any] struct {
+	ids        []uint64
+	components []T
+	index      map[uint64]int
+}
+
+func (s *denseStorage[T]) insert(id uint64, component any) {
+	s.index[id] = len(s.components)
+	s.ids = append(s.ids, id)
+	s.components = append(s.components, component.(T))
+}
+
+func (s *denseStorage[T]) remove(id uint64) {
+	i, ok := s.index[id]
+	if !ok {
+		return
+	}
+
+	last := len(s.components) - 1
+	if i != last {
+		s.ids[i] = s.ids[last]
+		s.components[i] = s.components[last]
+		s.index[s.ids[i]] = i
+	}
+
+	// Zero the freed slot so it doesn't keep anything alive.
+	var zero T
+	s.components[last] = zero
+
+	s.ids = s.ids[:last]
+	s.components = s.components[:last]
+	delete(s.index, id)
+}
+
+func (s *denseStorage[T]) set(id uint64, component any) {
+	if i, ok := s.index[id]; ok {
+		s.components[i] = component.(T)
+	}
+}
+
+func (s *denseStorage[T]) len() int {
+	return len(s.components)
+}
+
+func (s *denseStorage[T]) componentIDs() []uint64 {
+	return s.ids
+}
+
+func (s *denseStorage[T]) capacity() (int, int) {
+	return cap(s.components), len(s.index)
+}
+
+// compact reallocates the storage to fit its current contents.
+func (s *denseStorage[T]) compact() {
+	s.ids = append(make([]uint64, 0, len(s.ids)), s.ids...)
+	s.components = append(make([]T, 0, len(s.components)), s.components...)
+
+	index := make(map[uint64]int, len(s.index))
+	for id, i := range s.index {
+		index[id] = i
+	}
+	s.index = index
+}
+
+// storageOf returns the dense storage of component type T.
+// The storage is built from the existing components on first use, and kept up to date by the engine afterwards.
+func storageOf[T any](engine *Engine) *denseStorage[T] {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	bit := typeBit[T](engine)
+	if s, ok := engine.storages[bit]; ok {
+		return s.(*denseStorage[T])
+	}
+
+	s := &denseStorage[T]{index: make(map[uint64]int)}
+
+	// Insert in ID order, so the initial layout does not depend on map iteration.
+	var ids []uint64
+	for id, link := range engine.links {
+		if link.componentType == bit {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		s.insert(id, engine.components[id])
+	}
+
+	engine.storages[bit] = s
+	return s
+}
+
+// relink updates the link, mask and storage of the component with the id after it has been replaced by Set.
+// The caller must hold componentMtx.
+func (e *Engine) relink(id uint64, component any) {
+	link, ok := e.links[id]
+	if !ok {
+		return
+	}
+
+	bit := e.componentType(reflect.TypeOf(component))
+	e.drawOrder.remove(link.componentType, id)
+	e.drawOrder.insert(bit, id, link.entity, component)
+
+	if bit == link.componentType {
+		if s, ok := e.storages[bit]; ok {
+			s.set(id, component)
+		}
+		return
+	}
+
+	// The component changed type, so it has to move to another storage.
+	if s, ok := e.storages[link.componentType]; ok {
+		s.remove(id)
+	}
+	if s, ok := e.storages[bit]; ok {
+		s.insert(id, component)
+	}
+
+	m := e.maskOf(link.entity)
+	m.add(bit, id)
+	m.remove(link.componentType, id)
+
+	e.logRemoved(link.componentType, id, link.entity, *link.component)
+	e.invalidatePaths(link.componentType)
+	e.logAdded(bit, id, link.entity, component)
+	e.structuralChange()
+
+	*link.component = component
+	link.componentType = bit
+	e.links[id] = link
+}
+
+// EachChunk is a generic function that iterates over the dense storage of component type T in chunks of up to chunkSize
+// components, passing the components along with their IDs. A chunkSize of zero or less passes every component in a single chunk.
+// T must be a concrete component type, since the storage is kept per type. It returns the number of components visited.
+//
+// The slices point directly into the storage, are only valid during the call, and must not be modified;
+// write changes back using Set. Structural changes made during the call are deferred, as with Each.
+// Components of entities despawned using DespawnDeferred are skipped, so chunks end where they are stored.
+//
+//	tinyecs.EachChunk[Position](e, 256, func(positions []Position, ids []uint64) {
+//		for i := range positions {
+//			tinyecs.Set(e, ids[i], Position{X: positions[i].X + 1})
+//		}
+//	})
+func EachChunk[T any](engine *Engine, chunkSize int, f func(components []T, ids []uint64)) uint64 {
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	s := storageOf[T](engine)
+
+	if chunkSize <= 0 {
+		chunkSize = len(s.components)
+	}
+
+	var counter uint64
+	for start := 0; start < len(s.components); {
+		if engine.hiddenID(s.ids[start]) {
+			start++
+			continue
+		}
+
+		end := start + 1
+		for end < len(s.components) && end-start < chunkSize && !engine.hiddenID(s.ids[end]) {
+			end++
+		}
+
+		counter += uint64(end - start)
+		f(s.components[start:end], s.ids[start:end])
+		start = end
+	}
+	engine.recordQuery(queryStatsKey{query: "EachChunk", component: typeKey[T]()}, uint64(len(s.components)), counter)
+	return counter
+}
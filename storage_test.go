@@ -0,0 +1,68 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_EachChunk(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	for i := 0; i < 10; i++ {
+		e.AddComponents(entity, floater{f: float64(i)}, velocity{})
+	}
+
+	var chunks []int
+	var sum float64
+	c := tinyecs.EachChunk[floater](e, 4, func(components []floater, ids []uint64) {
+		assert.Len(t, ids, len(components))
+		chunks = append(chunks, len(components))
+		for _, component := range components {
+			sum += component.f
+		}
+	})
+
+	assert.Equal(t, uint64(10), c)
+	assert.Equal(t, []int{4, 4, 2}, chunks)
+	assert.Equal(t, 45.0, sum)
+}
+
+func Test_EachChunkTracksChanges(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{}
+	e.AddComponents(entity, floater{f: 1})
+
+	// The first call builds the storage, the rest must keep it in sync.
+	assert.Equal(t, uint64(1), tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {}))
+
+	e.AddComponents(entity, floater{f: 2}, floater{f: 3})
+	e.DeleteComponent(floater{f: 1})
+
+	tinyecs.EachChunk[floater](e, 0, func(components []floater, ids []uint64) {
+		for i := range components {
+			tinyecs.Set(e, ids[i], floater{f: components[i].f * 10})
+		}
+	})
+
+	var values []float64
+	c := tinyecs.EachChunk[floater](e, 0, func(components []floater, ids []uint64) {
+		for _, component := range components {
+			values = append(values, component.f)
+		}
+	})
+	assert.Equal(t, uint64(2), c)
+	assert.ElementsMatch(t, []float64{20, 30}, values)
+
+	// Replacing a component with another type moves it to the other storage.
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		if obj.f == 20 {
+			tinyecs.Set(e, id, velocity{v: 1})
+		}
+	})
+	assert.Equal(t, uint64(1), tinyecs.EachChunk[floater](e, 0, func([]floater, []uint64) {}))
+	assert.Equal(t, uint64(1), tinyecs.EachChunk[velocity](e, 0, func([]velocity, []uint64) {}))
+	assert.True(t, tinyecs.Has[velocity](e, entity))
+}
@@ -0,0 +1,101 @@
+package tinyecs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxRecordSize is the largest record Encode writes and Decode reads, so a corrupt length prefix cannot make Decode
+// allocate an arbitrary amount of memory.
+const maxRecordSize = 64 << 20
+
+// Encode writes the engine to w one entity at a time, as a stream of length-prefixed EntitySnapshot records
+// encoded with the serializer's codec, in the same order as Snapshot. Unlike Marshal, only a single entity is held
+// in memory in encoded form, which suits worlds too large to snapshot at once. The engine is locked for reading until Encode returns.
+func (s *Serializer) Encode(w io.Writer, engine *Engine) error {
+	engine.componentMtx.RLock()
+	defer engine.componentMtx.RUnlock()
+
+	order, ids, added := engine.snapshotOrder()
+
+	bw := bufio.NewWriter(w)
+	var prefix [binary.MaxVarintLen64]byte
+	for _, entity := range order {
+		ent, err := s.snapshotEntity(engine, entity, added[entity], ids[entity])
+		if err != nil {
+			return err
+		}
+
+		data, err := s.Codec.Marshal(ent)
+		if err != nil {
+			return fmt.Errorf("encoding entity: %w", err)
+		}
+		if len(data) > maxRecordSize {
+			return fmt.Errorf("encoded entity is %d bytes, more than the maximum of %d", len(data), maxRecordSize)
+		}
+		n := binary.PutUvarint(prefix[:], uint64(len(data)))
+		if _, err := bw.Write(prefix[:n]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Decode reads a stream written by Encode from r and loads it into the engine, one entity at a time.
+// Entities read before an error occurred remain loaded. Records larger than 64 MiB are rejected.
+func (s *Serializer) Decode(r io.Reader, engine *Engine) error {
+	br := bufio.NewReader(r)
+	var buf []byte
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading record length: %w", err)
+		}
+		if size > maxRecordSize {
+			return fmt.Errorf("record length %d exceeds the maximum of %d", size, maxRecordSize)
+		}
+
+		if uint64(cap(buf)) < size {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return fmt.Errorf("reading record: %w", err)
+		}
+
+		var ent EntitySnapshot
+		if err := s.Codec.Unmarshal(buf, &ent); err != nil {
+			return err
+		}
+		if err := s.loadEntity(ent, engine); err != nil {
+			return err
+		}
+	}
+}
+
+// snapshotEntity returns the serialized form of the entity and its components with the ids.
+// The caller must hold componentMtx.
+func (s *Serializer) snapshotEntity(engine *Engine, entity any, added bool, ids []uint64) (EntitySnapshot, error) {
+	name, value, err := s.encode(engine, entity)
+	if err != nil {
+		return EntitySnapshot{}, fmt.Errorf("entity: %w", err)
+	}
+
+	ent := EntitySnapshot{Type: name, Value: value, Added: added, Components: make([]ComponentSnapshot, 0, len(ids))}
+	for _, id := range ids {
+		name, value, err := s.encode(engine, engine.components[id])
+		if err != nil {
+			return EntitySnapshot{}, fmt.Errorf("component %d: %w", id, err)
+		}
+		ent.Components = append(ent.Components, ComponentSnapshot{ID: id, Type: name, Value: value})
+	}
+	return ent, nil
+}
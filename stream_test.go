@@ -0,0 +1,80 @@
+package tinyecs_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func Test_SerializerStream(t *testing.T) {
+	for name, codec := range map[string]tinyecs.Codec{
+		"json":     tinyecs.JSONCodec,
+		"msgpack":  tinyecs.MsgpackCodec,
+		"protobuf": tinyecs.ProtobufCodec,
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := tinyecs.NewEngine()
+			for i := 0; i < 100; i++ {
+				ent := &savedEntity{Name: strconv.Itoa(i)}
+				e.AddComponents(ent, position{X: float64(i)}, inventory{Slots: []string{"slot"}})
+				if i%2 == 0 {
+					e.AddEntity(ent)
+				}
+			}
+
+			s := newTestSerializer(codec)
+			var buf bytes.Buffer
+			assert.NoError(t, s.Encode(&buf, e))
+
+			loaded := tinyecs.NewEngine()
+			assert.NoError(t, s.Decode(&buf, loaded))
+
+			assert.Len(t, loaded.GetEntities(), 50)
+			assert.Equal(t, e.GetComponents(), loaded.GetComponents())
+		})
+	}
+}
+
+func Test_SerializerStreamTruncated(t *testing.T) {
+	e := tinyecs.NewEngine()
+	e.AddComponents(&savedEntity{Name: "a"}, position{X: 1})
+	e.AddComponents(&savedEntity{Name: "b"}, position{X: 2})
+
+	s := newTestSerializer(nil)
+	var buf bytes.Buffer
+	assert.NoError(t, s.Encode(&buf, e))
+
+	loaded := tinyecs.NewEngine()
+	err := s.Decode(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), loaded)
+	assert.ErrorContains(t, err, "reading record")
+
+	// The first entity was loaded before the stream ended.
+	assert.Len(t, loaded.GetComponents(), 1)
+}
+
+func Test_SerializerStreamRecordTooLarge(t *testing.T) {
+	// A length prefix of 1<<40 followed by no data.
+	prefix := make([]byte, binary.MaxVarintLen64)
+	stream := prefix[:binary.PutUvarint(prefix, 1<<40)]
+
+	loaded := tinyecs.NewEngine()
+	err := newTestSerializer(nil).Decode(bytes.NewReader(stream), loaded)
+	assert.EqualError(t, err, "record length 1099511627776 exceeds the maximum of 67108864")
+	assert.Empty(t, loaded.GetComponents())
+}
+
+func Test_SerializerStreamCorruptRecord(t *testing.T) {
+	// A small record whose components array claims 2^31 elements.
+	record := append([]byte{0x81, 0xaa}, "components"...)
+	record = append(record, 0xdd, 0x7f, 0xff, 0xff, 0xff)
+	prefix := make([]byte, binary.MaxVarintLen64)
+	stream := append(prefix[:binary.PutUvarint(prefix, uint64(len(record)))], record...)
+
+	loaded := tinyecs.NewEngine()
+	err := newTestSerializer(tinyecs.MsgpackCodec).Decode(bytes.NewReader(stream), loaded)
+	assert.ErrorContains(t, err, "array of 2147483647 elements is longer than the 0 bytes left")
+	assert.Empty(t, loaded.GetComponents())
+}
@@ -1,73 +1,246 @@
 package tinyecs
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"reflect"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // entityComponentLink is used to store a relationship between an entity and a component.
 type entityComponentLink struct {
 	entity    any
 	component *any
+
+	// componentType is the mask bit of the component's type.
+	componentType int
 }
 
 // Engine represents the tinyecs engine itself.
 type Engine struct {
 	nextComponentID uint64
+	// touched counts the components visited by queries. It is accessed atomically, so it is kept 64-bit aligned.
+	touched uint64
+	// structural and emitted count the structural changes made and events emitted, for FrameReport.
+	// They are accessed atomically as well.
+	structural uint64
+	emitted    uint64
 
 	components   map[uint64]any
-	componentMtx sync.RWMutex
+	componentMtx engineMutex
+	// versions holds the change tick of every component, and tick the latest change tick.
+	versions map[uint64]uint64
+	tick     uint64
 
 	entities []ecsEntity
 
 	links map[uint64]entityComponentLink
+
+	// componentTypes maps each component type seen by the engine to its bit in a componentMask.
+	componentTypes map[reflect.Type]int
+	// typesByBit holds the type of every bit, and typeKeys the bit of every type seen through a type parameter.
+	typesByBit []reflect.Type
+	typeKeys   map[any]int
+	// typeNames and typesByName hold the names of the types registered using RegisterType.
+	typeNames   map[int]string
+	typesByName map[string]int
+	// masks holds the component mask of every entity which has at least one component.
+	masks map[any]*entityMask
+
+	// storages holds the dense storage of component types which have been queried by type.
+	storages map[int]componentStorage
+	// queries holds the compiled query plans, by their masks.
+	queries map[string]*Query
+	// parsedQueries holds the query plans compiled by ParseQuery, by their text.
+	parsedQueries map[string]*Query
+	// drawOrder holds the ZIndex components in draw order, once EachInDrawOrder has been called.
+	drawOrder *drawOrder
+	// groups holds the named groups of entities.
+	groups map[string]*entityGroup
+	// componentLogs holds the components added and removed per tracked type bit, for EachAdded and EachRemoved.
+	// changeSeq counts the changes logged, and frameSeq is its value when the previous frame ended.
+	componentLogs map[int]*componentLog
+	changeSeq     uint64
+	frameSeq      uint64
+
+	// arenas holds the frame arenas, which are reset by EndFrame.
+	arenas map[reflect.Type]resetter
+
+	// iterating is the number of queries currently running.
+	iterating int
+	// pending holds the structural changes deferred until no query is running.
+	pending []func()
+
+	// stages holds the systems run by Update, per stage.
+	stages []*scheduledStage
+	delta  time.Duration
+	frame  uint64
+	// ctx is the context of the running system, and system the running system itself.
+	ctx    context.Context
+	system *scheduledSystem
+	// lastReport is the report of the last frame run by Update, and reportAllocs whether it counts allocations.
+	lastReport   FrameReport
+	reportAllocs bool
+	// deterministic makes scans of all components visit them in id order, and logger receives diagnostics, if set.
+	deterministic bool
+	logger        Logger
+	// rng is the engine's random number generator, created on first use.
+	rng *rand.Rand
+	// races detects systems of a stage writing the same component types, if enabled.
+	races *raceDetector
+	// requireRegistered makes the error returning variants reject types not registered using RegisterType.
+	requireRegistered bool
+	// tracer records the spans of Update, if set.
+	tracer trace.Tracer
+	// queryStats holds the statistics of every query which has been run.
+	queryStats map[queryStatsKey]*QueryStats
+	statsMtx   sync.Mutex
+
+	events   map[reflect.Type]eventQueue
+	channels map[string]frameSwapper
+	eventMtx sync.Mutex
+
+	// input is the keyboard and mouse state fed by a Loop.
+	input Input
+
+	// watchers holds the change watchers per component type bit.
+	watchers map[int]func(id uint64, entity any, old any, new any)
+
+	// paths indexes the entities by their paths in the hierarchy, once FindPath has been called. It is dropped whenever
+	// a Name or Parent component changes, and pathBits holds the type bits of those.
+	paths    map[string]any
+	pathBits [2]int
+
+	// removed holds the entities removed using RemoveEntity which still have components, for GC.
+	removed map[any]bool
+
+	// despawned holds the entities despawned using DespawnDeferred, which are removed in despawnQueue order at the end
+	// of the frame.
+	despawned    map[any]bool
+	despawnQueue []ecsEntity
+	// disabled holds the entities released to a Pool, which keep their components but are hidden from queries.
+	disabled map[any]bool
+
+	// doubleBuffered holds the double-buffered component types, and backBuffer the values held back until EndFrame.
+	doubleBuffered componentMask
+	backBuffer     map[uint64]any
+
+	// transient holds the component types which are never serialized.
+	transient componentMask
+	loadHooks []func(entity any)
+
+	// mirrors holds the mirrors recording the changes of the engine.
+	mirrors []*Mirror
 }
 
 // AddComponents adds one or more component to the entity.
 // This also updates the Engine's global component list.
+// When called during a query such as Each, the components are added once the query has finished.
 func (e *Engine) AddComponents(entity ecsEntity, components ...any) {
+	checkEntity(entity, "AddComponents")
+	if e.deferIfIterating(func() { e.AddComponents(entity, components...) }) {
+		return
+	}
+
 	for _, component := range components {
 		e.addComponent(entity, component)
 	}
 }
 
 // DeleteComponent deletes a component.
+// When called during a query such as Each, the component is deleted once the query has finished.
 func (e *Engine) DeleteComponent(component any) {
+	if e.deferIfIterating(func() { e.DeleteComponent(component) }) {
+		return
+	}
+
 	for id, comp := range e.components {
 		if comp == component {
-			// Reset the link
-			delete(e.links, id)
-
-			//ent.components = append(ent.components[:i], ent.components[i+1:]...)
 			e.deleteComponent(id)
 		}
 	}
 }
 
+// DeleteComponentID deletes the component with the id, as returned by ComponentIDs.
+// When called during a query such as Each, the component is deleted once the query has finished.
+func (e *Engine) DeleteComponentID(id uint64) {
+	e.deleteComponentID(id)
+}
+
+// deleteComponentID deletes the component with the id, once no query is running.
+func (e *Engine) deleteComponentID(id uint64) {
+	if e.deferIfIterating(func() { e.deleteComponent(id) }) {
+		return
+	}
+	e.deleteComponent(id)
+}
+
 // addComponent takes a slice of components and adds it to the engine and increments the nextComponentID variable.
 func (e *Engine) addComponent(entity any, component any) uint64 {
 	e.componentMtx.Lock()
 	defer e.componentMtx.Unlock()
 
 	id := e.nextComponentID
+	e.insertComponent(entity, id, component)
+
+	e.nextComponentID++
+	return id
+}
+
+// insertComponent adds the component under the id and links it to the entity.
+// The caller must hold componentMtx.
+func (e *Engine) insertComponent(entity any, id uint64, component any) {
 	e.components[id] = component
 
 	// Set the link relationship.
+	bit := e.componentType(reflect.TypeOf(component))
 	e.links[id] = entityComponentLink{
-		entity:    entity,
-		component: &component,
+		entity:        entity,
+		component:     &component,
+		componentType: bit,
 	}
-
-	e.nextComponentID++
-	return id
+	e.maskOf(entity).add(bit, id)
+	if s, ok := e.storages[bit]; ok {
+		s.insert(id, component)
+	}
+	e.drawOrder.insert(bit, id, entity, component)
+	e.logAdded(bit, id, entity, component)
+	e.structuralChange()
+	e.bumpVersion(id)
+	e.recordWrite(bit)
+	e.invalidatePaths(bit)
+	e.mirrorComponent(mirrorAdd, bit, id, entity, component)
 }
 
 // deleteComponent is an internal function used to delete a component by id.
+// It also resets the link and updates the component mask of the linked entity.
 func (e *Engine) deleteComponent(id uint64) {
 	e.componentMtx.Lock()
 	defer e.componentMtx.Unlock()
 
+	if link, ok := e.links[id]; ok {
+		if m, ok := e.masks[link.entity]; ok && m.remove(link.componentType, id) {
+			delete(e.masks, link.entity)
+			delete(e.removed, link.entity)
+		}
+		if s, ok := e.storages[link.componentType]; ok {
+			s.remove(id)
+		}
+		e.drawOrder.remove(link.componentType, id)
+		e.logRemoved(link.componentType, id, link.entity, e.components[id])
+		e.structuralChange()
+		e.recordWrite(link.componentType)
+		e.invalidatePaths(link.componentType)
+		e.mirrorComponent(mirrorDelete, link.componentType, id, nil, nil)
+		delete(e.links, id)
+	}
+
 	delete(e.components, id)
+	delete(e.versions, id)
 }
 
 // GetComponents returns the components map held by the engine.
@@ -80,30 +253,87 @@ func (e *Engine) GetEntities() []ecsEntity {
 	return e.entities
 }
 
-// AddEntity adds an entity to the engine.
+// AddEntity adds an entity to the engine, and emits an EntitySpawned event.
 func (e *Engine) AddEntity(entity ecsEntity) {
+	checkEntity(entity, "AddEntity")
+	e.componentMtx.Lock()
+	delete(e.removed, entity)
+	e.componentMtx.Unlock()
+
 	e.entities = append(e.entities, entity)
+	e.structuralChange()
+	e.mirror(mirrorDelta{op: mirrorSpawn, entity: entity})
+
+	Emit(e, EntitySpawned{Entity: entity})
 }
 
-// RemoveEntity takes in an entity instance and removes it from the engine.
+// RemoveEntity takes in an entity instance and removes it from the engine and from every group,
+// and emits an EntityDespawned event.
 // Note: This is pretty slow due to the use of reflect.DeepEqual.
 func (e *Engine) RemoveEntity(entity ecsEntity) {
+	if !e.removeEntity(entity) {
+		e.logf("RemoveEntity called with an entity which was never added: %+v", entity)
+	}
+}
+
+// removeEntity is RemoveEntity, returning whether the entity had been added.
+func (e *Engine) removeEntity(entity ecsEntity) bool {
 	for i, ent := range e.entities {
 		// TODO: Replace DeepEqual since it is pretty slow.
 		if reflect.DeepEqual(ent, entity) {
 			e.entities = append(e.entities[:i], e.entities[i+1:]...)
-			return
+			e.removeFromGroups(ent)
+			e.markRemoved(ent)
+			e.structuralChange()
+			e.mirror(mirrorDelta{op: mirrorDespawn, entity: ent})
+
+			Emit(e, EntityDespawned{Entity: ent, Components: e.componentsOf(ent)})
+			return true
 		}
 	}
+	return false
 }
 
-// NewEngine returns a prepared Engine instance ready for use.
+// NewEngine returns a prepared Engine instance ready for use, configured by the options.
 // This should be the entry point for the tinyecs library.
-func NewEngine() Engine {
-	return Engine{
+//
+//	e := tinyecs.NewEngine(tinyecs.WithCapacity(1000, 5000), tinyecs.Deterministic())
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
 		links:      make(map[uint64]entityComponentLink),
 		components: make(map[uint64]any),
+		versions:   make(map[uint64]uint64),
+		backBuffer: make(map[uint64]any),
+		despawned:  make(map[any]bool),
+		removed:    make(map[any]bool),
+		disabled:   make(map[any]bool),
+
+		componentTypes: make(map[reflect.Type]int),
+		typeKeys:       make(map[any]int),
+		typeNames:      make(map[int]string),
+		typesByName:    make(map[string]int),
+		masks:          make(map[any]*entityMask),
+		storages:       make(map[int]componentStorage),
+		queries:        make(map[string]*Query),
+		parsedQueries:  make(map[string]*Query),
+		queryStats:     make(map[queryStatsKey]*QueryStats),
+		groups:         make(map[string]*entityGroup),
+		componentLogs:  make(map[int]*componentLog),
+		arenas:         make(map[reflect.Type]resetter),
+		events:         make(map[reflect.Type]eventQueue),
+		channels:       make(map[string]frameSwapper),
+		watchers:       make(map[int]func(id uint64, entity any, old any, new any)),
+
+		stages: []*scheduledStage{
+			{stage: StagePreUpdate},
+			{stage: StageUpdate},
+			{stage: StagePostUpdate},
+		},
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
 // Each is a generic function that iterates over the engine's components
@@ -111,56 +341,260 @@ func NewEngine() Engine {
 // component along with the component instance of type T.
 // Note that Each does not provide the actual entity used. Use EachEntity
 // instead for this purpose.
+// For concrete component types Each iterates the type's dense storage and does not allocate.
 //
-// 		tinyecs.Each[Timer](&e, func(id uint64, obj Timer) {
+// 		tinyecs.Each[Timer](e, func(id uint64, obj Timer) {
 //			obj.currentTime += 0.35
-//			tinyecs.Set(&e, id, obj)
+//			tinyecs.Set(e, id, obj)
 //		})
 //
 // The example above illustrates a basic use case where one updates a variable on a component, using the Set function.
 func Each[T any](engine *Engine, f func(id uint64, component T)) uint64 {
+	// Interface types can match components of any type, so they have to scan every component.
+	if isInterface[T]() {
+		return eachMatching(engine, f)
+	}
+
+	// Store a counter of objects touched which will be returned out of the function.
+	var counter uint64
+
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	// Iterate the dense storage of T, which neither boxes components nor allocates.
+	s := storageOf[T](engine)
+	for i := 0; i < len(s.components); i++ {
+		if engine.hiddenID(s.ids[i]) {
+			continue
+		}
+		counter++
+		f(s.ids[i], s.components[i])
+	}
+	engine.recordQuery(queryStatsKey{query: "Each", component: typeKey[T]()}, uint64(len(s.components)), counter)
+	return counter
+}
+
+// eachMatching is the slow path of Each, which iterates over all engine components and type asserts each of them.
+func eachMatching[T any](engine *Engine, f func(id uint64, component T)) uint64 {
 
 	// Store a counter of objects touched which will be returned out of the function.
 	var counter uint64
 
+	engine.beginIteration()
+	defer engine.endIteration()
+
 	// Iterate through all engine components.
-	for idx, component := range engine.components {
+	engine.rangeLinks(func(idx uint64, _ entityComponentLink) {
 		// Attempt to cast, and call the func on each of the components that can be successfully cast.
-		if c, ok := component.(T); ok {
+		if c, ok := engine.components[idx].(T); ok {
 			counter++
 			f(idx, c)
 		}
-	}
+	})
+	engine.recordQuery(queryStatsKey{query: "Each", component: typeKey[T]()}, uint64(len(engine.components)), counter)
 	return counter
 }
 
 // EachEntity is a generic function that iterates over the components belonging to entity E as component C.
 //
-// 		tinyecs.Each[MyEntity, Timer](&e, func(entity MyEntity, component Timer) {
+// 		tinyecs.Each[MyEntity, Timer](e, func(entity MyEntity, component Timer) {
 //			log.Println("MyEntity: ", entity)
 //		})
 func EachEntity[E any, C any](engine *Engine, f func(entity E, component C)) uint64 {
 	var counter uint64
 
-	for idx, link := range engine.links {
-		component := *link.component
-		if _, ok := component.(C); ok {
-			if e, entOk := link.entity.(E); entOk {
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	if isInterface[C]() {
+		engine.rangeLinks(func(idx uint64, link entityComponentLink) {
+			component := *link.component
+			if _, ok := component.(C); ok {
+				if e, entOk := link.entity.(E); entOk {
+					counter++
+					f(e, engine.components[idx].(C))
+				}
+			}
+		})
+
+		engine.recordQuery(queryStatsKey{query: "EachEntity", entity: typeKey[E](), component: typeKey[C]()}, uint64(len(engine.links)), counter)
+		return counter
+	}
+
+	s := storageOf[C](engine)
+	for i := 0; i < len(s.components); i++ {
+		if e, entOk := engine.links[s.ids[i]].entity.(E); entOk && !engine.hidden(e) {
+			counter++
+			f(e, s.components[i])
+		}
+	}
+
+	engine.recordQuery(queryStatsKey{query: "EachEntity", entity: typeKey[E](), component: typeKey[C]()}, uint64(len(s.components)), counter)
+	return counter
+}
+
+// EachWithEntity is a generic function that iterates over every component of type T, passing the entity it belongs to,
+// its ID and the component itself. It suits systems needing all three, which would otherwise have to combine Each and Get.
+//
+//	tinyecs.EachWithEntity(e, func(entity any, id uint64, timer Timer) {
+//		timer.currentTime += 0.35
+//		tinyecs.Set(e, id, timer)
+//	})
+func EachWithEntity[T any](engine *Engine, f func(entity any, id uint64, component T)) uint64 {
+	var counter uint64
+
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	if isInterface[T]() {
+		engine.rangeLinks(func(idx uint64, link entityComponentLink) {
+			if c, ok := engine.components[idx].(T); ok {
 				counter++
-				f(e, engine.components[idx].(C))
+				f(link.entity, idx, c)
 			}
+		})
+
+		engine.recordQuery(queryStatsKey{query: "EachWithEntity", component: typeKey[T]()}, uint64(len(engine.links)), counter)
+		return counter
+	}
+
+	s := storageOf[T](engine)
+	for i := 0; i < len(s.components); i++ {
+		entity := engine.links[s.ids[i]].entity
+		if engine.hidden(entity) {
+			continue
 		}
+		counter++
+		f(entity, s.ids[i], s.components[i])
 	}
 
+	engine.recordQuery(queryStatsKey{query: "EachWithEntity", component: typeKey[T]()}, uint64(len(s.components)), counter)
 	return counter
 }
 
+// EachEntityRef is like EachEntity, but also passes the component ID and a pointer to the component, so that f can modify
+// it in place. Once f returns, the component is written back using Set, which notifies change observers.
+//
+//	tinyecs.EachEntityRef(e, func(entity *MyEntity, id uint64, timer *Timer) {
+//		timer.currentTime += 0.35
+//	})
+func EachEntityRef[E any, C any](engine *Engine, f func(entity E, id uint64, component *C)) uint64 {
+	var counter uint64
+
+	engine.beginIteration()
+	defer engine.endIteration()
+
+	var c C
+	if isInterface[C]() {
+		ids := make([]uint64, 0)
+		engine.rangeLinks(func(idx uint64, link entityComponentLink) {
+			if _, ok := engine.components[idx].(C); ok {
+				if _, entOk := link.entity.(E); entOk {
+					ids = append(ids, idx)
+				}
+			}
+		})
+		// Collect the components first, since writing them back may change their types.
+		for _, idx := range ids {
+			link := engine.links[idx]
+			c = engine.components[idx].(C)
+			counter++
+			f(link.entity.(E), idx, &c)
+			Set(engine, idx, c)
+		}
+
+		engine.recordQuery(queryStatsKey{query: "EachEntityRef", entity: typeKey[E](), component: typeKey[C]()}, uint64(len(engine.links)), counter)
+		return counter
+	}
+
+	s := storageOf[C](engine)
+	for i := 0; i < len(s.components); i++ {
+		id := s.ids[i]
+		if e, entOk := engine.links[id].entity.(E); entOk && !engine.hidden(e) {
+			c = s.components[i]
+			counter++
+			f(e, id, &c)
+			Set(engine, id, c)
+		}
+	}
+
+	engine.recordQuery(queryStatsKey{query: "EachEntityRef", entity: typeKey[E](), component: typeKey[C]()}, uint64(len(s.components)), counter)
+	return counter
+}
+
+// isInterface returns whether T is an interface type.
+func isInterface[T any]() bool {
+	return reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Interface
+}
+
 // Set takes in an engine instance and updates a component with the id specified.
+// Note: Set stores the component in the engine's component map, which boxes (and allocates) components larger than a pointer.
+// Replacing a component with one of another type during a query is deferred until the query has finished.
 func Set(engine *Engine, id uint64, component any) {
 	engine.componentMtx.Lock()
 	defer engine.componentMtx.Unlock()
 
-	engine.components[id] = component
+	engine.set(id, component)
+}
+
+// set replaces the component with the id, deferring the change if it is structural and a query is running, or until
+// the end of the frame if the component is double-buffered. The caller must hold componentMtx.
+func (e *Engine) set(id uint64, component any) {
+	if e.changesType(id, component) && e.deferLocked(func() { Set(e, id, component) }) {
+		return
+	}
+	if e.buffer(id, component) {
+		return
+	}
+	e.setNow(id, component)
+}
+
+// setNow replaces the component with the id right away. The caller must hold componentMtx.
+func (e *Engine) setNow(id uint64, component any) {
+	if old, ok := e.components[id]; ok {
+		e.notifyChange(id, old, component)
+	} else {
+		e.logf("Set called with unknown component id %d", id)
+	}
+
+	e.components[id] = component
+	e.relink(id, component)
+	e.bumpVersion(id)
+	if link, ok := e.links[id]; ok {
+		e.recordWrite(link.componentType)
+		e.invalidatePaths(link.componentType)
+		e.mirrorComponent(mirrorSet, link.componentType, id, link.entity, component)
+	}
+}
+
+// SetBatch updates many components at once, acquiring the engine lock a single time.
+// This suits systems which compute their results in parallel and commit them at the end of the frame.
+//
+//	updates := make(map[uint64]any)
+//	// ... fill updates from worker goroutines ...
+//	tinyecs.SetBatch(e, updates)
+func SetBatch(engine *Engine, updates map[uint64]any) {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	for id, component := range updates {
+		engine.set(id, component)
+	}
+}
+
+// SetBatchOf is the typed variant of SetBatch, for when all updated components are of type T.
+// The ids and components slices are matched by index, and must have the same length.
+func SetBatchOf[T any](engine *Engine, ids []uint64, components []T) {
+	if len(ids) != len(components) {
+		panic("tinyecs: SetBatchOf called with mismatched ids and components")
+	}
+
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	for i, id := range ids {
+		engine.set(id, components[i])
+	}
 }
 
 // ecsEntity is an internal type used to represent an entity.
@@ -168,6 +602,18 @@ type ecsEntity interface {
 	GetComponents(engine *Engine) []uint64
 }
 
+// comparableEntity returns whether the entity can be used as a map key, as the engine indexes entities by value.
+func comparableEntity(entity any) bool {
+	return entity == nil || reflect.TypeOf(entity).Comparable()
+}
+
+// checkEntity panics if the entity is not comparable, such as a struct with a slice field, naming the caller.
+func checkEntity(entity any, caller string) {
+	if !comparableEntity(entity) {
+		panic(fmt.Sprintf("tinyecs: %s called with an entity of type %T, which is not comparable; pass a pointer to it instead", caller, entity))
+	}
+}
+
 // Entity is a collection of components.
 // Consumers should extend this by embedding the struct.
 type Entity struct{}
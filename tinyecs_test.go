@@ -120,13 +120,13 @@ func Test_EachAndUpdateInstances(t *testing.T) {
 
 	e.AddEntity(&entity)
 
-	tinyecs.Each[floater](&e, func(id uint64, obj floater) {
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
 		assert.Equal(t, 1.0, obj.f)
 		obj.f += 0.35
-		tinyecs.Set(&e, id, obj)
+		tinyecs.Set(e, id, obj)
 	})
 
-	tinyecs.Each[floater](&e, func(id uint64, obj floater) {
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
 		assert.Equal(t, 1.35, obj.f)
 	})
 }
@@ -143,10 +143,10 @@ func Test_EntityWithComponents(t *testing.T) {
 	)
 	e.AddEntity(&entity)
 
-	c := tinyecs.Each[velocity](&e, func(id uint64, obj velocity) {})
+	c := tinyecs.Each[velocity](e, func(id uint64, obj velocity) {})
 	assert.Equal(t, uint64(1), c)
 
-	tinyecs.Each[playerData](&e, func(id uint64, obj playerData) {
+	tinyecs.Each[playerData](e, func(id uint64, obj playerData) {
 		assert.Equal(t, "test", obj.name)
 	})
 }
@@ -163,9 +163,91 @@ func Test_EachEntity(t *testing.T) {
 	)
 	e.AddEntity(&entity)
 
-	c := tinyecs.EachEntity[testEntity, velocity](&e, func(entity testEntity, component velocity) {
+	c := tinyecs.EachEntity[testEntity, velocity](e, func(entity testEntity, component velocity) {
 		assert.Equal(t, entity.name, "a1")
 	})
 	assert.Equal(t, uint64(1), c)
 
 }
+
+func Test_EachWithEntity(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	a := &testEntity{name: "a"}
+	b := &testEntity{name: "b"}
+	e.AddComponents(a, velocity{v: 1}, playerData{name: "test"})
+	e.AddComponents(b, velocity{v: 2})
+
+	found := make(map[uint64]any)
+	c := tinyecs.EachWithEntity(e, func(entity any, id uint64, component velocity) {
+		v, _, _ := tinyecs.Get[velocity](e, entity)
+		assert.Equal(t, v, component)
+		found[id] = entity
+	})
+	assert.Equal(t, uint64(2), c)
+
+	_, idA, _ := tinyecs.Get[velocity](e, a)
+	_, idB, _ := tinyecs.Get[velocity](e, b)
+	assert.Equal(t, map[uint64]any{idA: a, idB: b}, found)
+}
+
+func Test_EachEntityRef(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := &testEntity{name: "a1"}
+	e.AddComponents(entity, velocity{v: 1}, playerData{name: "test", health: 100.0})
+
+	var ids []uint64
+	c := tinyecs.EachEntityRef(e, func(entity *testEntity, id uint64, component *velocity) {
+		assert.Equal(t, "a1", entity.name)
+		component.v += 2
+		ids = append(ids, id)
+	})
+	assert.Equal(t, uint64(1), c)
+
+	v, id, ok := tinyecs.Get[velocity](e, entity)
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{id}, ids)
+	assert.Equal(t, velocity{v: 3}, v)
+}
+
+func Test_SetBatch(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	entity := testEntity{}
+	e.AddComponents(
+		entity,
+
+		floater{f: 1.0},
+		floater{f: 2.0},
+		velocity{v: 5.0},
+	)
+
+	updates := make(map[uint64]any)
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		updates[id] = floater{f: obj.f * 10}
+	})
+	tinyecs.SetBatch(e, updates)
+
+	var values []float64
+	tinyecs.Each[floater](e, func(id uint64, obj floater) {
+		values = append(values, obj.f)
+	})
+	assert.ElementsMatch(t, []float64{10, 20}, values)
+
+	var ids []uint64
+	var velocities []velocity
+	tinyecs.Each[velocity](e, func(id uint64, obj velocity) {
+		ids = append(ids, id)
+		velocities = append(velocities, velocity{v: obj.v + 1})
+	})
+	tinyecs.SetBatchOf(e, ids, velocities)
+
+	tinyecs.Each[velocity](e, func(id uint64, obj velocity) {
+		assert.Equal(t, 6.0, obj.v)
+	})
+
+	assert.Panics(t, func() {
+		tinyecs.SetBatchOf(e, ids, []velocity{})
+	})
+}
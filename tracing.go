@@ -0,0 +1,51 @@
+package tinyecs
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetTracer enables tracing of Update using the OpenTelemetry tracer, or disables it if the tracer is nil.
+// Each frame is recorded as an Update span, with a child span per stage and a grandchild span per system.
+// Every span has a tinyecs.entities_touched attribute holding the number of components visited by queries while it
+// was open, and the context of a system's span is available through Context, so systems can add spans of their own.
+//
+//	e.SetTracer(otel.Tracer("game"))
+func (e *Engine) SetTracer(tracer trace.Tracer) {
+	e.tracer = tracer
+}
+
+// touch records that a query visited n components.
+func (e *Engine) touch(n uint64) {
+	atomic.AddUint64(&e.touched, n)
+}
+
+// engineSpan is a span opened by the engine, along with the touched count when it was started.
+type engineSpan struct {
+	span    trace.Span
+	touched uint64
+}
+
+// startSpan starts a span named name if tracing is enabled, and returns it along with its context.
+func (e *Engine) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, engineSpan) {
+	if e.tracer == nil {
+		return ctx, engineSpan{}
+	}
+
+	ctx, span := e.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, engineSpan{span: span, touched: atomic.LoadUint64(&e.touched)}
+}
+
+// endSpan records the components touched since the span was started, and ends it.
+func (e *Engine) endSpan(s engineSpan) {
+	if s.span == nil {
+		return
+	}
+
+	touched := atomic.LoadUint64(&e.touched) - s.touched
+	s.span.SetAttributes(attribute.Int64("tinyecs.entities_touched", int64(touched)))
+	s.span.End()
+}
@@ -0,0 +1,97 @@
+package tinyecs_test
+
+import (
+	"context"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	"time"
+)
+
+// recordedSpan is a span recorded by recordingTracer.
+type recordedSpan struct {
+	trace.Span
+
+	name   string
+	parent *recordedSpan
+	attrs  map[attribute.Key]attribute.Value
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
+
+type spanKey struct{}
+
+// recordingTracer is a tracer which records every span started with it.
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{
+		Span:   trace.SpanFromContext(ctx),
+		name:   name,
+		parent: parent,
+		attrs:  make(map[attribute.Key]attribute.Value),
+	}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestEngine_SetTracer(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tracer := &recordingTracer{}
+	e.SetTracer(tracer)
+
+	entity := &testEntity{name: "a"}
+	e.AddComponents(entity, velocity{v: 1}, velocity{v: 2})
+	e.AddEntity(entity)
+
+	var systemSpan any
+	e.AddSystem(tinyecs.StageUpdate, "movement", func(e *tinyecs.Engine) {
+		systemSpan = e.Context().Value(spanKey{})
+		tinyecs.Each(e, func(id uint64, v velocity) {})
+	})
+	e.AddSystem(tinyecs.StagePostUpdate, "idle", func(e *tinyecs.Engine) {})
+
+	e.Update(time.Millisecond)
+
+	var names []string
+	for _, s := range tracer.spans {
+		names = append(names, s.name)
+		assert.True(t, s.ended, s.name)
+	}
+	assert.Equal(t, []string{"Update", "pre_update", "update", "movement", "post_update", "idle"}, names)
+
+	frame, update, movement, idle := tracer.spans[0], tracer.spans[2], tracer.spans[3], tracer.spans[5]
+	assert.Nil(t, frame.parent)
+	assert.Equal(t, frame, update.parent)
+	assert.Equal(t, update, movement.parent)
+	assert.Equal(t, movement, systemSpan)
+
+	assert.Equal(t, int64(0), frame.attrs["tinyecs.frame"].AsInt64())
+	assert.Equal(t, "update", movement.attrs["tinyecs.stage"].AsString())
+	assert.Equal(t, "movement", movement.attrs["tinyecs.system"].AsString())
+
+	assert.Equal(t, int64(2), frame.attrs["tinyecs.entities_touched"].AsInt64())
+	assert.Equal(t, int64(2), update.attrs["tinyecs.entities_touched"].AsInt64())
+	assert.Equal(t, int64(2), movement.attrs["tinyecs.entities_touched"].AsInt64())
+	assert.Equal(t, int64(0), idle.attrs["tinyecs.entities_touched"].AsInt64())
+
+	e.SetTracer(nil)
+	e.Update(time.Millisecond)
+	assert.Len(t, tracer.spans, 6)
+}
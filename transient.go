@@ -0,0 +1,31 @@
+package tinyecs
+
+// MarkTransient marks the component type T as transient: a runtime-only component such as a cache, a GPU handle
+// or a reference to a goroutine. Transient components are never written by a Serializer, and have to be rebuilt
+// after loading, typically from an OnLoad hook.
+//
+//	tinyecs.MarkTransient[TextureHandle](e)
+func MarkTransient[T any](engine *Engine) {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	engine.transient.set(typeBit[T](engine))
+}
+
+// OnLoad adds a hook which is called for every entity loaded by a Serializer, once its components have been added.
+//
+//	e.OnLoad(func(entity any) {
+//		if s, ok := entity.(*Sprite); ok {
+//			e.AddComponents(s, loadTexture(s.Path))
+//		}
+//	})
+func (e *Engine) OnLoad(hook func(entity any)) {
+	e.loadHooks = append(e.loadHooks, hook)
+}
+
+// loaded runs the OnLoad hooks for the entity.
+func (e *Engine) loaded(entity any) {
+	for _, hook := range e.loadHooks {
+		hook(entity)
+	}
+}
@@ -0,0 +1,50 @@
+package tinyecs_test
+
+import (
+	"bytes"
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type textureHandle struct {
+	id int
+}
+
+func Test_TransientComponentsAreNotSerialized(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.MarkTransient[textureHandle](e)
+
+	player := &savedEntity{Name: "player"}
+	e.AddComponents(player, position{X: 1}, textureHandle{id: 7})
+	e.AddEntity(player)
+
+	// The texture handle type is not even registered.
+	s := newTestSerializer(nil)
+	data, err := s.Marshal(e)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.Encode(&buf, e))
+
+	for name, load := range map[string]func(*tinyecs.Engine) error{
+		"snapshot": func(e *tinyecs.Engine) error { return s.Unmarshal(data, e) },
+		"stream":   func(e *tinyecs.Engine) error { return s.Decode(bytes.NewReader(buf.Bytes()), e) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			loaded := tinyecs.NewEngine()
+
+			var rebuilt []string
+			loaded.OnLoad(func(entity any) {
+				ent := entity.(*savedEntity)
+				rebuilt = append(rebuilt, ent.Name)
+				loaded.AddComponents(ent, textureHandle{id: 8})
+			})
+			assert.NoError(t, load(loaded))
+
+			assert.Equal(t, []string{"player"}, rebuilt)
+			assert.True(t, tinyecs.Has[position](loaded, loaded.GetEntities()[0]))
+			assert.True(t, tinyecs.Has[textureHandle](loaded, loaded.GetEntities()[0]))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package tinyecs
+
+// Tick returns the engine's change tick, which is incremented every time a component is added or Set.
+// Comparing it with the versions of components tells which of them changed since an earlier tick:
+//
+//	since := e.Tick()
+//	// ... run a frame ...
+//	tinyecs.Each(e, func(id uint64, p Position) {
+//		if v, _ := e.Version(id); v > since {
+//			replicate(id, p)
+//		}
+//	})
+func (e *Engine) Tick() uint64 {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	return e.tick
+}
+
+// Version returns the change tick of the component with the id: the value of Tick right after the component was
+// last added or Set. It returns false if there is no component with the id.
+func (e *Engine) Version(id uint64) (uint64, bool) {
+	e.componentMtx.RLock()
+	defer e.componentMtx.RUnlock()
+
+	v, ok := e.versions[id]
+	return v, ok
+}
+
+// bumpVersion advances the change tick and stamps the component with it. The caller must hold componentMtx.
+func (e *Engine) bumpVersion(id uint64) {
+	e.tick++
+	e.versions[id] = e.tick
+}
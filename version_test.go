@@ -0,0 +1,37 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Version(t *testing.T) {
+	e := tinyecs.NewEngine()
+	assert.Equal(t, uint64(0), e.Tick())
+
+	entity := &testEntity{}
+	e.AddComponents(entity, velocity{}, floater{})
+	_, vid, _ := tinyecs.Get[velocity](e, entity)
+	_, fid, _ := tinyecs.Get[floater](e, entity)
+
+	since := e.Tick()
+	assert.Equal(t, uint64(2), since)
+	v, ok := e.Version(fid)
+	assert.True(t, ok)
+	assert.Equal(t, since, v)
+
+	tinyecs.Set(e, vid, velocity{v: 1})
+	v, _ = e.Version(vid)
+	assert.Greater(t, v, since)
+	v, _ = e.Version(fid)
+	assert.LessOrEqual(t, v, since)
+
+	tinyecs.SetBatch(e, map[uint64]any{fid: floater{f: 1}})
+	v, _ = e.Version(fid)
+	assert.Equal(t, e.Tick(), v)
+
+	e.DeleteComponent(floater{f: 1})
+	_, ok = e.Version(fid)
+	assert.False(t, ok)
+}
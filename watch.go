@@ -0,0 +1,60 @@
+package tinyecs
+
+// Change is the event emitted when the value of a component of a watched type changes.
+type Change[T any] struct {
+	// ID is the id of the changed component.
+	ID uint64
+	// Entity is the entity the component belongs to.
+	Entity any
+
+	Old T
+	New T
+}
+
+// Watch makes the engine compare components of type T whenever they are Set, and emit a Change[T] event when the
+// new value differs from the old one. The events are read like any other event:
+//
+//	tinyecs.Watch[Health](e)
+//
+//	tinyecs.ReadEvents[tinyecs.Change[Health]](e, func(c tinyecs.Change[Health]) {
+//		log.Println("health changed from ", c.Old, " to ", c.New)
+//	})
+func Watch[T comparable](engine *Engine) {
+	WatchFunc(engine, func(a, b T) bool {
+		return a == b
+	})
+}
+
+// WatchFunc is like Watch, but compares components using equal, for component types which are not comparable.
+func WatchFunc[T any](engine *Engine, equal func(a, b T) bool) {
+	engine.componentMtx.Lock()
+	defer engine.componentMtx.Unlock()
+
+	bit := typeBit[T](engine)
+	engine.watchers[bit] = func(id uint64, entity any, old any, new any) {
+		o := old.(T)
+
+		// Replacing the component with another type is not a change in value.
+		n, ok := new.(T)
+		if !ok || equal(o, n) {
+			return
+		}
+
+		Emit(engine, Change[T]{ID: id, Entity: entity, Old: o, New: n})
+	}
+}
+
+// notifyChange calls the watcher of old's type, if there is one. The caller must hold componentMtx.
+func (e *Engine) notifyChange(id uint64, old any, new any) {
+	if len(e.watchers) == 0 {
+		return
+	}
+
+	link, ok := e.links[id]
+	if !ok {
+		return
+	}
+	if watcher, ok := e.watchers[link.componentType]; ok {
+		watcher(id, link.entity, old, new)
+	}
+}
@@ -0,0 +1,58 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_WatchEmitsChanges(t *testing.T) {
+	e := tinyecs.NewEngine()
+	tinyecs.Watch[playerData](e)
+
+	entity := &testEntity{}
+	e.AddComponents(entity, playerData{name: "test", health: 100}, velocity{})
+
+	tinyecs.Each[playerData](e, func(id uint64, obj playerData) {
+		// Unchanged, so no event.
+		tinyecs.Set(e, id, obj)
+
+		obj.health -= 10
+		tinyecs.Set(e, id, obj)
+	})
+	tinyecs.Each[velocity](e, func(id uint64, obj velocity) {
+		tinyecs.Set(e, id, velocity{v: 1})
+	})
+	e.EndFrame()
+
+	var changes []tinyecs.Change[playerData]
+	tinyecs.ReadEvents[tinyecs.Change[playerData]](e, func(c tinyecs.Change[playerData]) {
+		changes = append(changes, c)
+	})
+
+	assert.Len(t, changes, 1)
+	assert.Equal(t, entity, changes[0].Entity)
+	assert.Equal(t, float32(100), changes[0].Old.health)
+	assert.Equal(t, float32(90), changes[0].New.health)
+	assert.Zero(t, tinyecs.Events[tinyecs.Change[velocity]](e).Len())
+}
+
+func Test_WatchFunc(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	type path struct {
+		points []int
+	}
+	tinyecs.WatchFunc(e, func(a, b path) bool {
+		return len(a.points) == len(b.points)
+	})
+
+	e.AddComponents(&testEntity{}, path{points: []int{1}})
+	tinyecs.Each[path](e, func(id uint64, obj path) {
+		tinyecs.SetBatch(e, map[uint64]any{id: path{points: []int{2}}})
+		tinyecs.SetBatchOf(e, []uint64{id}, []path{{points: []int{1, 2}}})
+	})
+	e.EndFrame()
+
+	assert.Equal(t, 1, tinyecs.Events[tinyecs.Change[path]](e).Len())
+}
@@ -0,0 +1,119 @@
+package tinyecs
+
+import "sort"
+
+// ZIndex is a component giving the draw order of an entity: entities are drawn by ascending Layer, and within a
+// layer by ascending Z. Entities with equal indices are drawn in the order their ZIndex components were added.
+type ZIndex struct {
+	Layer int
+	Z     float64
+}
+
+// less returns whether z is drawn before other.
+func (z ZIndex) less(other ZIndex) bool {
+	if z.Layer != other.Layer {
+		return z.Layer < other.Layer
+	}
+	return z.Z < other.Z
+}
+
+// drawEntry is a ZIndex component in the draw order.
+type drawEntry struct {
+	z      ZIndex
+	id     uint64
+	entity any
+}
+
+// drawOrder holds the ZIndex components sorted in draw order. It is kept up to date as ZIndex components are added,
+// replaced and deleted, so that rendering never has to sort all drawables.
+type drawOrder struct {
+	bit     int
+	entries []drawEntry
+	// indices holds the current index of every component in entries, by id.
+	indices map[uint64]ZIndex
+}
+
+// search returns the position of the entry with the index and id, or where it would be inserted.
+func (d *drawOrder) search(z ZIndex, id uint64) int {
+	return sort.Search(len(d.entries), func(i int) bool {
+		e := d.entries[i]
+		if e.z != z {
+			return z.less(e.z)
+		}
+		return e.id >= id
+	})
+}
+
+// insert adds the component with the id to the draw order, if it is a ZIndex. Calling it on a nil drawOrder does
+// nothing, so the engine only pays for the draw order once it has been used.
+func (d *drawOrder) insert(bit int, id uint64, entity any, component any) {
+	if d == nil || bit != d.bit {
+		return
+	}
+
+	z := component.(ZIndex)
+	i := d.search(z, id)
+	d.entries = append(d.entries, drawEntry{})
+	copy(d.entries[i+1:], d.entries[i:])
+	d.entries[i] = drawEntry{z: z, id: id, entity: entity}
+	d.indices[id] = z
+}
+
+// remove removes the component with the id from the draw order, if it is a ZIndex.
+func (d *drawOrder) remove(bit int, id uint64) {
+	if d == nil || bit != d.bit {
+		return
+	}
+
+	z, ok := d.indices[id]
+	if !ok {
+		return
+	}
+	i := d.search(z, id)
+	d.entries = append(d.entries[:i], d.entries[i+1:]...)
+	delete(d.indices, id)
+}
+
+// EachInDrawOrder calls f with every ZIndex component in draw order, along with its entity, and returns the number of
+// components visited. The order is built the first time it is called, and then updated as ZIndex components change.
+// Structural changes made during the call are deferred, as with the other queries.
+//
+//	e.EachInDrawOrder(func(entity any, z tinyecs.ZIndex) {
+//		if sprite, _, ok := tinyecs.Get[Sprite](e, entity); ok {
+//			sprite.Draw(target)
+//		}
+//	})
+func (e *Engine) EachInDrawOrder(f func(entity any, z ZIndex)) uint64 {
+	d := e.drawOrderOf()
+
+	e.beginIteration()
+	defer e.endIteration()
+
+	var counter uint64
+	for _, entry := range d.entries {
+		if e.hidden(entry.entity) {
+			continue
+		}
+		counter++
+		f(entry.entity, entry.z)
+	}
+	e.touch(counter)
+	return counter
+}
+
+// drawOrderOf returns the draw order, building it from the existing ZIndex components the first time.
+func (e *Engine) drawOrderOf() *drawOrder {
+	e.componentMtx.Lock()
+	defer e.componentMtx.Unlock()
+
+	if e.drawOrder != nil {
+		return e.drawOrder
+	}
+
+	d := &drawOrder{bit: typeBit[ZIndex](e), indices: make(map[uint64]ZIndex)}
+	for id, link := range e.links {
+		d.insert(link.componentType, id, link.entity, e.components[id])
+	}
+	e.drawOrder = d
+	return d
+}
@@ -0,0 +1,42 @@
+package tinyecs_test
+
+import (
+	"github.com/kaiaverkvist/tinyecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEngine_EachInDrawOrder(t *testing.T) {
+	e := tinyecs.NewEngine()
+
+	background := &testEntity{name: "background"}
+	e.AddComponents(background, tinyecs.ZIndex{Layer: -1})
+	player := &testEntity{name: "player"}
+	e.AddComponents(player, tinyecs.ZIndex{Z: 2})
+	tree := &testEntity{name: "tree"}
+	e.AddComponents(tree, tinyecs.ZIndex{Z: 1})
+
+	order := func() []string {
+		var names []string
+		e.EachInDrawOrder(func(entity any, z tinyecs.ZIndex) {
+			names = append(names, entity.(*testEntity).name)
+		})
+		return names
+	}
+	assert.Equal(t, []string{"background", "tree", "player"}, order())
+
+	// The order is updated as components are added, replaced and deleted.
+	hud := &testEntity{name: "hud"}
+	e.AddComponents(hud, tinyecs.ZIndex{Layer: 1})
+	bush := &testEntity{name: "bush"}
+	e.AddComponents(bush, tinyecs.ZIndex{Z: 1})
+	assert.Equal(t, []string{"background", "tree", "bush", "player", "hud"}, order())
+
+	_, id, _ := tinyecs.Get[tinyecs.ZIndex](e, player)
+	tinyecs.Set(e, id, tinyecs.ZIndex{Z: 0})
+	_, id, _ = tinyecs.Get[tinyecs.ZIndex](e, tree)
+	tinyecs.Set(e, id, velocity{})
+	_, id, _ = tinyecs.Get[tinyecs.ZIndex](e, hud)
+	e.DeleteComponent(e.GetComponents()[id])
+	assert.Equal(t, []string{"background", "player", "bush"}, order())
+}